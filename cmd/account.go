@@ -4,12 +4,15 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/spf13/cobra"
 	"trading-journal-cli/internal/models"
 )
 
+var accountListAsOf string
+
 var accountCmd = &cobra.Command{
 	Use:   "account",
 	Short: "账户管理",
@@ -30,7 +33,8 @@ var accountAddCmd = &cobra.Command{
 
 var accountUpdateCmd = &cobra.Command{
 	Use:   "update",
-	Short: "更新账户余额",
+	Short: "校正账户余额",
+	Long:  `将账户余额校正为指定值，差额记为一笔调整流水。入金和出金请使用 account deposit / account withdraw。`,
 	RunE:  runAccountUpdate,
 }
 
@@ -47,6 +51,8 @@ var accountTemplateCmd = &cobra.Command{
 }
 
 func init() {
+	accountListCmd.Flags().StringVar(&accountListAsOf, "as-of", "", asOfFlagUsage)
+
 	accountCmd.AddCommand(accountListCmd)
 	accountCmd.AddCommand(accountAddCmd)
 	accountCmd.AddCommand(accountUpdateCmd)
@@ -64,10 +70,33 @@ func getAccountManager() *models.AccountManager {
 }
 
 func runAccountList(cmd *cobra.Command, args []string) error {
+	var asOf time.Time
+	if err := parseAsOf(&asOf, accountListAsOf); err != nil {
+		return err
+	}
+
 	am := getAccountManager()
 	accounts := am.ListAccounts()
 
 	printTitle("💼 账户管理")
+	printAsOf(asOf)
+
+	// 查看历史状态时不显示之后才创建的账户
+	if !asOf.IsZero() {
+		existing := make([]models.Account, 0, len(accounts))
+		for _, acc := range accounts {
+			createdAt, err := ops.AccountCreatedAt(acc.Name)
+			if err != nil {
+				return fmt.Errorf("读取账户 %s 的创建时间失败: %w", acc.Name, err)
+			}
+			if !createdAt.After(asOf) {
+				existing = append(existing, acc)
+			}
+		}
+		accounts = existing
+		printInfo("余额和锁定状态为该时刻的状态，风控限制、熔断规则和模板为当前设置")
+		fmt.Println()
+	}
 
 	if len(accounts) == 0 {
 		printWarning("暂无账户")
@@ -87,7 +116,23 @@ func runAccountList(cmd *cobra.Command, args []string) error {
 
 		// 账户名称
 		printHighlightField("账户", acc.Name)
-		printField("余额", fmt.Sprintf("%.2f %s", acc.Balance, currency))
+		balance := acc.Balance
+		if !asOf.IsZero() {
+			// 按资金流水计算当时的余额
+			b, err := ops.AccountBalanceAt(acc.Name, asOf)
+			if err != nil {
+				return fmt.Errorf("计算账户 %s 的历史余额失败: %w", acc.Name, err)
+			}
+			balance = b
+		}
+		printField("余额", fmt.Sprintf("%.2f %s", balance, currency))
+		if limits := formatRiskLimits(acc.RiskLimits); limits != "" {
+			printField("风控限制", limits)
+		}
+		if rules := formatCircuitBreaker(acc.CircuitBreaker); rules != "" {
+			printField("熔断规则", rules)
+		}
+		printAccountLock(acc.Lock, asOf)
 
 		// 模板信息
 		if acc.Template != nil {
@@ -219,7 +264,8 @@ func runAccountUpdate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("无效的余额格式: %w", err)
 	}
 
-	if err := am.UpdateAccount(selectedAccount.Name, newBalance); err != nil {
+	// 余额由资金流水汇总得到，差额记为一笔调整流水
+	if _, err := ops.SetBalance(selectedAccount.Name, newBalance, ""); err != nil {
 		return fmt.Errorf("更新账户失败: %w", err)
 	}
 
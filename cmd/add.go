@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/cobra"
+	"trading-journal-cli/internal/models"
+	"trading-journal-cli/internal/operations"
+)
+
+var (
+	addPrice    float64
+	addQuantity float64
+	addMargin   float64
+	addNote     string
+	addTime     string
+	addFees     models.Fees
+)
+
+var addCmd = &cobra.Command{
+	Use:   "add [positionID]",
+	Short: "加仓记录",
+	Long: `对未平仓的仓位加仓，按成交量重新计算加权平均开仓价、数量和保证金。
+所有提示都有对应的参数，只有缺少的值才会提示输入；使用 --yes 可完全以非交互方式运行。`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runAdd,
+}
+
+func init() {
+	addCmd.Flags().Float64Var(&addPrice, "price", 0, "加仓价格")
+	addCmd.Flags().Float64Var(&addQuantity, "qty", 0, "加仓数量")
+	addCmd.Flags().Float64Var(&addMargin, "margin", 0, "追加保证金/成本，默认 0")
+	addCmd.Flags().StringVar(&addNote, "note", "", "加仓备注")
+	addCmd.Flags().StringVar(&addTime, "time", "", "加仓时间 (格式: 2006-01-02 15:04:05)，默认当前时间")
+	addFeeFlags(addCmd, &addFees, false)
+	addYesFlag(addCmd)
+
+	rootCmd.AddCommand(addCmd)
+}
+
+func runAdd(cmd *cobra.Command, args []string) error {
+	printTitle("➕ 加仓记录")
+
+	// 获取所有未平仓位
+	openPositions, err := ops.GetOpenPositions()
+	if err != nil {
+		printError(fmt.Sprintf("无法读取未平仓位: %v", err))
+		return err
+	}
+
+	if len(openPositions) == 0 {
+		printWarning("暂无未平仓位")
+		return nil
+	}
+
+	// 选择仓位（命令行指定ID时直接使用）
+	positionID := ""
+	if len(args) == 1 {
+		positionID = args[0]
+	}
+	selectedPos, err := selectOpenPosition(openPositions, positionID, "选择要加仓的仓位:")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	printDivider()
+	printHighlightField("仓位ID", selectedPos.PositionID)
+	printField("品种", fmt.Sprintf("%s (%s)", selectedPos.Symbol, selectedPos.MarketType))
+	printField("方向", selectedPos.Direction)
+	printField("平均开仓价", fmt.Sprintf("%.4f", selectedPos.OpenPrice))
+	printField("数量", fmt.Sprintf("%.4f", selectedPos.Quantity))
+	printField("止损", fmt.Sprintf("%.4f", selectedPos.StopLoss))
+	printField("止盈", fmt.Sprintf("%.4f", selectedPos.TakeProfit))
+	printField("保证金", fmt.Sprintf("%.2f", selectedPos.Margin))
+	printDivider()
+	fmt.Println()
+
+	var params operations.AddParams
+
+	// 加仓价格
+	params.Price = addPrice
+	if ask, err := needPrompt(cmd, "price", false); err != nil {
+		return err
+	} else if ask {
+		if params.Price, err = promptFloat("加仓价格:", ""); err != nil {
+			return err
+		}
+	}
+
+	// 加仓数量
+	params.Quantity = addQuantity
+	if ask, err := needPrompt(cmd, "qty", false); err != nil {
+		return err
+	} else if ask {
+		if params.Quantity, err = promptFloat("加仓数量:", ""); err != nil {
+			return err
+		}
+	}
+
+	// 追加保证金（默认 0）
+	params.Margin = addMargin
+	if ask, err := needPrompt(cmd, "margin", true); err != nil {
+		return err
+	} else if ask {
+		if params.Margin, err = promptFloat("追加保证金/成本:", "0"); err != nil {
+			return err
+		}
+	}
+
+	// 加仓费用（可选）
+	if err := promptFees(cmd, &addFees, false); err != nil {
+		return err
+	}
+	params.Fees = addFees
+
+	// 加仓备注（可选）
+	params.Note = addNote
+	if ask, err := needPrompt(cmd, "note", true); err != nil {
+		return err
+	} else if ask {
+		notePrompt := &survey.Input{
+			Message: "加仓备注 (可选):",
+		}
+		survey.AskOne(notePrompt, &params.Note)
+	}
+
+	// 加仓时间（可选）
+	timeStr := addTime
+	if ask, err := needPrompt(cmd, "time", true); err != nil {
+		return err
+	} else if ask {
+		useCurrentTime := true
+		timePrompt := &survey.Confirm{
+			Message: "使用当前时间?",
+			Default: true,
+		}
+		if err := survey.AskOne(timePrompt, &useCurrentTime); err != nil {
+			return err
+		}
+
+		if !useCurrentTime {
+			customTimePrompt := &survey.Input{
+				Message: "加仓时间 (格式: 2006-01-02 15:04:05):",
+			}
+			if err := survey.AskOne(customTimePrompt, &timeStr); err != nil {
+				return err
+			}
+		}
+	}
+	if timeStr != "" {
+		t, err := parseLocalTime(timeStr)
+		if err != nil {
+			return err
+		}
+		params.Time = &t
+	}
+
+	// 执行加仓操作
+	pos, err := ops.AddToPosition(selectedPos.PositionID, params)
+	if err != nil {
+		printError(fmt.Sprintf("加仓失败: %v", err))
+		return err
+	}
+
+	// 显示成功信息
+	fmt.Println()
+	printSuccess("加仓已记录")
+	printHighlightField("仓位ID", pos.PositionID)
+	printDivider()
+	printField("平均开仓价", fmt.Sprintf("%.4f -> %.4f", selectedPos.OpenPrice, pos.OpenPrice))
+	printField("数量", fmt.Sprintf("%.4f -> %.4f", selectedPos.Quantity, pos.Quantity))
+	printField("保证金", fmt.Sprintf("%.2f -> %.2f", selectedPos.Margin, pos.Margin))
+	printField("开仓次数", len(pos.Entries))
+	fmt.Println()
+
+	return nil
+}
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/cobra"
+	"trading-journal-cli/internal/operations"
+)
+
+var (
+	adjustStopLoss     float64
+	adjustTakeProfit   float64
+	adjustCurrentPrice float64
+	adjustNote         string
+	adjustTime         string
+)
+
+var adjustCmd = &cobra.Command{
+	Use:   "adjust [positionID]",
+	Short: "调整止损止盈",
+	Long: `调整未平仓位的止损和止盈价格，并记录调整历史。
+新价格按开仓时相同的多空范围规则验证：提供 --price 时以当前价格为参考（如移动止损到保本），否则以开仓价为参考。`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runAdjust,
+}
+
+func init() {
+	adjustCmd.Flags().Float64Var(&adjustStopLoss, "sl", 0, "新的止损价格，默认保持不变")
+	adjustCmd.Flags().Float64Var(&adjustTakeProfit, "tp", 0, "新的止盈价格，默认保持不变")
+	adjustCmd.Flags().Float64Var(&adjustCurrentPrice, "price", 0, "当前价格（可选，用于验证止损止盈范围）")
+	adjustCmd.Flags().StringVar(&adjustNote, "note", "", "调整备注")
+	adjustCmd.Flags().StringVar(&adjustTime, "time", "", "调整时间 (格式: 2006-01-02 15:04:05)，默认当前时间")
+	addYesFlag(adjustCmd)
+
+	rootCmd.AddCommand(adjustCmd)
+}
+
+func runAdjust(cmd *cobra.Command, args []string) error {
+	printTitle("🎯 调整止损止盈")
+
+	// 获取所有未平仓位
+	openPositions, err := ops.GetOpenPositions()
+	if err != nil {
+		printError(fmt.Sprintf("无法读取未平仓位: %v", err))
+		return err
+	}
+
+	if len(openPositions) == 0 {
+		printWarning("暂无未平仓位")
+		return nil
+	}
+
+	// 选择仓位（命令行指定ID时直接使用）
+	positionID := ""
+	if len(args) == 1 {
+		positionID = args[0]
+	}
+	selectedPos, err := selectOpenPosition(openPositions, positionID, "选择要调整的仓位:")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	printDivider()
+	printHighlightField("仓位ID", selectedPos.PositionID)
+	printField("品种", fmt.Sprintf("%s (%s)", selectedPos.Symbol, selectedPos.MarketType))
+	printField("方向", selectedPos.Direction)
+	printField("开仓价格", fmt.Sprintf("%.4f", selectedPos.OpenPrice))
+	printField("止损", fmt.Sprintf("%.4f", selectedPos.StopLoss))
+	printField("止盈", fmt.Sprintf("%.4f", selectedPos.TakeProfit))
+	printDivider()
+	fmt.Println()
+
+	var params operations.AdjustParams
+
+	// 新止损价格（默认保持不变）
+	stopLoss := selectedPos.StopLoss
+	if cmd.Flags().Changed("sl") {
+		stopLoss = adjustStopLoss
+	}
+	if ask, err := needPrompt(cmd, "sl", true); err != nil {
+		return err
+	} else if ask {
+		var stopLossStr string
+		stopLossPrompt := &survey.Input{
+			Message: "新的止损价格:",
+			Default: fmt.Sprintf("%.4f", selectedPos.StopLoss),
+		}
+		if err := survey.AskOne(stopLossPrompt, &stopLossStr, survey.WithValidator(survey.Required)); err != nil {
+			return err
+		}
+		if _, err := fmt.Sscanf(stopLossStr, "%f", &stopLoss); err != nil {
+			return fmt.Errorf("无效的止损价格格式: %w", err)
+		}
+	}
+	params.StopLoss = &stopLoss
+
+	// 新止盈价格（默认保持不变）
+	takeProfit := selectedPos.TakeProfit
+	if cmd.Flags().Changed("tp") {
+		takeProfit = adjustTakeProfit
+	}
+	if ask, err := needPrompt(cmd, "tp", true); err != nil {
+		return err
+	} else if ask {
+		var takeProfitStr string
+		takeProfitPrompt := &survey.Input{
+			Message: "新的止盈价格:",
+			Default: fmt.Sprintf("%.4f", selectedPos.TakeProfit),
+		}
+		if err := survey.AskOne(takeProfitPrompt, &takeProfitStr, survey.WithValidator(survey.Required)); err != nil {
+			return err
+		}
+		if _, err := fmt.Sscanf(takeProfitStr, "%f", &takeProfit); err != nil {
+			return fmt.Errorf("无效的止盈价格格式: %w", err)
+		}
+	}
+	params.TakeProfit = &takeProfit
+
+	// 当前价格（可选）
+	params.CurrentPrice = adjustCurrentPrice
+	if ask, err := needPrompt(cmd, "price", true); err != nil {
+		return err
+	} else if ask {
+		var currentPriceStr string
+		currentPricePrompt := &survey.Input{
+			Message: "当前价格 (可选，留空则以开仓价验证):",
+		}
+		if err := survey.AskOne(currentPricePrompt, &currentPriceStr); err != nil {
+			return err
+		}
+		if currentPriceStr != "" {
+			if _, err := fmt.Sscanf(currentPriceStr, "%f", &params.CurrentPrice); err != nil {
+				return fmt.Errorf("无效的价格格式: %w", err)
+			}
+		}
+	}
+
+	// 调整备注（可选）
+	params.Note = adjustNote
+	if ask, err := needPrompt(cmd, "note", true); err != nil {
+		return err
+	} else if ask {
+		notePrompt := &survey.Input{
+			Message: "调整备注 (可选，如 \"移动止损到保本\"):",
+		}
+		survey.AskOne(notePrompt, &params.Note)
+	}
+
+	// 调整时间（可选，默认当前时间）
+	if adjustTime != "" {
+		t, err := parseLocalTime(adjustTime)
+		if err != nil {
+			return err
+		}
+		params.Time = &t
+	}
+
+	// 执行调整操作
+	pos, err := ops.AdjustPosition(selectedPos.PositionID, params)
+	if err != nil {
+		printError(fmt.Sprintf("调整失败: %v", err))
+		return err
+	}
+
+	// 显示成功信息
+	fmt.Println()
+	printSuccess("止损止盈已调整")
+	printHighlightField("仓位ID", pos.PositionID)
+	printDivider()
+	printField("止损", fmt.Sprintf("%.4f -> %.4f", selectedPos.StopLoss, pos.StopLoss))
+	printField("止盈", fmt.Sprintf("%.4f -> %.4f", selectedPos.TakeProfit, pos.TakeProfit))
+	printField("调整次数", len(pos.Adjustments))
+	fmt.Println()
+
+	return nil
+}
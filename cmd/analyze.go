@@ -0,0 +1,478 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"trading-journal-cli/internal/models"
+	"trading-journal-cli/internal/operations"
+)
+
+var (
+	analyzeAccountName string
+	analyzeFormat      string
+	analyzeCurrency    string
+	analyzeAsOf        string
+
+	perfFromDate   string
+	perfToDate     string
+	perfMarketType string
+	perfSymbol     string
+)
+
+var analyzeCmd = &cobra.Command{
+	Use:   "analyze",
+	Short: "交易数据分析",
+	Long:  `对交易记录进行风险和表现分析`,
+}
+
+var analyzeRiskCmd = &cobra.Command{
+	Use:   "risk",
+	Short: "分析未平仓位风险",
+	Long: `统计未平仓位的保证金、最大可能损失、仓位集中度和风险回报比。
+存在风险预警时以非零状态码退出，便于在脚本中使用。`,
+	RunE: runAnalyzeRisk,
+}
+
+var analyzePerformanceCmd = &cobra.Command{
+	Use:   "performance",
+	Short: "分析已平仓交易表现",
+	Long:  `统计胜率、盈亏、最佳/最差交易，并按品种、市场类型和平仓原因分类。筛选条件与 list 命令一致。`,
+	RunE:  runAnalyzePerformance,
+}
+
+func init() {
+	analyzeCmd.PersistentFlags().StringVar(&analyzeAccountName, "account", "", "筛选账户")
+	analyzeCmd.PersistentFlags().StringVar(&analyzeFormat, "format", "table", "输出格式 (table, json)")
+	analyzeCmd.PersistentFlags().StringVar(&analyzeCurrency, "currency", "", "报告币种，各账户金额按汇率换算后汇总，默认为账户币种（多币种时为 USD）")
+
+	analyzeRiskCmd.Flags().StringVar(&analyzeAsOf, "as-of", "", asOfFlagUsage)
+	analyzePerformanceCmd.Flags().StringVar(&analyzeAsOf, "as-of", "", asOfFlagUsage)
+	analyzePerformanceCmd.Flags().StringVar(&perfFromDate, "from", "", "起始日期 (YYYY-MM-DD)")
+	analyzePerformanceCmd.Flags().StringVar(&perfToDate, "to", "", "结束日期 (YYYY-MM-DD)")
+	analyzePerformanceCmd.Flags().StringVar(&perfMarketType, "market", "", "筛选市场类型")
+	analyzePerformanceCmd.Flags().StringVar(&perfSymbol, "symbol", "", "筛选交易品种")
+
+	analyzeCmd.AddCommand(analyzeRiskCmd)
+	analyzeCmd.AddCommand(analyzePerformanceCmd)
+	rootCmd.AddCommand(analyzeCmd)
+}
+
+// validateAnalyzeFormat 检查输出格式参数
+func validateAnalyzeFormat() error {
+	if analyzeFormat != "table" && analyzeFormat != "json" {
+		return fmt.Errorf("无效的输出格式: %s", analyzeFormat)
+	}
+	return nil
+}
+
+// outputReportJSON 以缩进 JSON 输出报告
+func outputReportJSON(report interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+func runAnalyzeRisk(cmd *cobra.Command, args []string) error {
+	if err := validateAnalyzeFormat(); err != nil {
+		return err
+	}
+
+	filter := operations.FilterParams{
+		AccountName: analyzeAccountName,
+		Currency:    analyzeCurrency,
+	}
+	if err := parseAsOf(&filter.AsOf, analyzeAsOf); err != nil {
+		return err
+	}
+
+	report, err := ops.AnalyzeRisk(filter)
+	if err != nil {
+		return fmt.Errorf("风险分析失败: %w", err)
+	}
+
+	if analyzeFormat == "json" {
+		if err := outputReportJSON(report); err != nil {
+			return err
+		}
+	} else {
+		outputRiskReport(report)
+	}
+
+	// 存在预警时返回错误，使进程以非零状态码退出
+	if len(report.Warnings) > 0 {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return fmt.Errorf("存在 %d 条风险预警", len(report.Warnings))
+	}
+
+	return nil
+}
+
+func outputRiskReport(report *operations.RiskReport) {
+	printTitle("⚠️  风险分析")
+	if report.AsOf != nil {
+		printAsOf(*report.AsOf)
+	}
+
+	if report.PositionCount == 0 {
+		printInfo("暂无未平仓位")
+		fmt.Println()
+		return
+	}
+
+	printField("持仓数量", report.PositionCount)
+	printField("报告币种", report.Currency)
+	printField("总保证金", fmt.Sprintf("%.2f", report.TotalMargin))
+	printField("最大可能损失", fmt.Sprintf("%.2f", report.MaxPossibleLoss))
+	printField("风险敞口", fmt.Sprintf("%.2f%%", report.RiskExposurePercent))
+	fmt.Println()
+	printDivider()
+	fmt.Println()
+
+	// 列宽定义
+	const (
+		colPosID   = 20
+		colAccount = 12
+		colSymbol  = 12
+		colDir     = 8
+		colMargin  = 12
+		colLoss    = 12
+		colRR      = 8
+	)
+
+	colorRed := color.New(color.FgRed)
+	colorGreen := color.New(color.FgGreen)
+
+	printTableHeader(
+		padRight("仓位ID", colPosID),
+		padRight("账户", colAccount),
+		padRight("品种", colSymbol),
+		padRight("方向", colDir),
+		padRight("保证金", colMargin),
+		padRight("可能损失", colLoss),
+		padRight("风险回报比", colRR),
+	)
+
+	for _, pr := range report.PositionRisks {
+		fmt.Print("  ")
+		colorGreen.Print(padRight(pr.PositionID, colPosID))
+		colorMuted.Print(" │ ")
+		fmt.Print(padRight(pr.AccountName, colAccount))
+		colorMuted.Print(" │ ")
+		fmt.Print(padRight(pr.Symbol, colSymbol))
+		colorMuted.Print(" │ ")
+		fmt.Print(padRight(string(pr.Direction), colDir))
+		colorMuted.Print(" │ ")
+		fmt.Print(padRight(fmt.Sprintf("%.2f", pr.Margin), colMargin))
+		colorMuted.Print(" │ ")
+		colorRed.Print(padRight(fmt.Sprintf("%.2f", pr.PossibleLoss), colLoss))
+		colorMuted.Print(" │ ")
+		rrStr := fmt.Sprintf("%.2f", pr.RiskRewardRatio)
+		if pr.RiskRewardRatio < 2 {
+			colorRed.Print(padRight(rrStr, colRR))
+		} else {
+			colorGreen.Print(padRight(rrStr, colRR))
+		}
+		fmt.Println()
+	}
+
+	fmt.Println()
+	printDivider()
+	fmt.Println()
+
+	// 集中度（按保证金从高到低）
+	printInfo("品种集中度")
+	symbols := make([]string, 0, len(report.ConcentrationBySymbol))
+	for symbol := range report.ConcentrationBySymbol {
+		symbols = append(symbols, symbol)
+	}
+	sort.Slice(symbols, func(i, j int) bool {
+		return report.ConcentrationBySymbol[symbols[i]] > report.ConcentrationBySymbol[symbols[j]]
+	})
+	for _, symbol := range symbols {
+		margin := report.ConcentrationBySymbol[symbol]
+		printField(symbol, fmt.Sprintf("%.2f (%.2f%%)", margin, margin/report.TotalMargin*100))
+	}
+	fmt.Println()
+
+	printInfo("市场类型集中度")
+	marketTypes := make([]models.MarketType, 0, len(report.ConcentrationByType))
+	for marketType := range report.ConcentrationByType {
+		marketTypes = append(marketTypes, marketType)
+	}
+	sort.Slice(marketTypes, func(i, j int) bool {
+		return report.ConcentrationByType[marketTypes[i]] > report.ConcentrationByType[marketTypes[j]]
+	})
+	for _, marketType := range marketTypes {
+		margin := report.ConcentrationByType[marketType]
+		printField(string(marketType), fmt.Sprintf("%.2f (%.2f%%)", margin, margin/report.TotalMargin*100))
+	}
+	fmt.Println()
+	printDivider()
+	fmt.Println()
+
+	// 风险预警
+	if len(report.Warnings) == 0 {
+		printSuccess("未发现风险预警")
+	} else {
+		for _, warning := range report.Warnings {
+			printWarning(warning)
+		}
+	}
+	fmt.Println()
+}
+
+func runAnalyzePerformance(cmd *cobra.Command, args []string) error {
+	if err := validateAnalyzeFormat(); err != nil {
+		return err
+	}
+
+	filter := operations.FilterParams{
+		Symbol:      perfSymbol,
+		MarketType:  perfMarketType,
+		AccountName: analyzeAccountName,
+		Currency:    analyzeCurrency,
+	}
+	if err := parseFilterDates(&filter, perfFromDate, perfToDate); err != nil {
+		return err
+	}
+	if err := parseAsOf(&filter.AsOf, analyzeAsOf); err != nil {
+		return err
+	}
+
+	report, err := ops.AnalyzePerformance(filter)
+	if err != nil {
+		return fmt.Errorf("表现分析失败: %w", err)
+	}
+
+	if analyzeFormat == "json" {
+		return outputReportJSON(report)
+	}
+	outputPerformanceReport(report)
+	return nil
+}
+
+// formatSignedPnL 格式化带符号的盈亏金额
+func formatSignedPnL(pnl float64) string {
+	if pnl > 0 {
+		return fmt.Sprintf("+%.2f", pnl)
+	}
+	return fmt.Sprintf("%.2f", pnl)
+}
+
+// formatEdgeRatio 格式化盈亏比和盈利因子，没有亏损交易时比值无意义
+func formatEdgeRatio(ratio *float64) string {
+	if ratio == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f", *ratio)
+}
+
+// printPnLCell 按盈亏颜色输出表格单元格
+func printPnLCell(pnl float64, width int) {
+	if pnl > 0 {
+		color.New(color.FgGreen, color.Bold).Print(padRight(formatSignedPnL(pnl), width))
+	} else if pnl < 0 {
+		color.New(color.FgRed).Print(padRight(formatSignedPnL(pnl), width))
+	} else {
+		fmt.Print(padRight(formatSignedPnL(pnl), width))
+	}
+}
+
+func outputPerformanceReport(report *operations.PerformanceReport) {
+	printTitle("📈 交易表现")
+	if report.AsOf != nil {
+		printAsOf(*report.AsOf)
+	}
+
+	if report.TotalTrades == 0 {
+		printInfo("暂无平仓成交")
+		fmt.Println()
+		return
+	}
+
+	printField("交易次数", fmt.Sprintf("%d (盈利 %d / 亏损 %d)",
+		report.TotalTrades, report.WinningTrades, report.LosingTrades))
+	if report.OpenTrades > 0 {
+		printField("部分平仓", fmt.Sprintf("%d 笔仍未平仓，只计已实现盈亏", report.OpenTrades))
+	}
+	printField("胜率", fmt.Sprintf("%.2f%%", report.WinRate))
+	printField("报告币种", report.Currency)
+	printHighlightField("净盈亏", fmt.Sprintf("%s (%.2f%%)", formatSignedPnL(report.TotalPnL), report.TotalPnLPercentage))
+	if report.TotalFees != 0 {
+		printField("毛盈亏", formatSignedPnL(report.TotalGrossPnL))
+		printField("总费用", fmt.Sprintf("%.2f", report.TotalFees))
+	}
+	printField("平均盈亏", formatSignedPnL(report.AveragePnL))
+	printField("平均盈利/亏损", fmt.Sprintf("%s / %s",
+		formatSignedPnL(report.AverageWin), formatSignedPnL(report.AverageLoss)))
+	printField("盈亏比", formatEdgeRatio(report.PayoffRatio))
+	printField("盈利因子", formatEdgeRatio(report.ProfitFactor))
+	printField("每笔期望", formatSignedPnL(report.Expectancy))
+	printField("最大连续盈亏", fmt.Sprintf("连赢 %d / 连亏 %d", report.MaxConsecutiveWins, report.MaxConsecutiveLosses))
+	printField("盈亏标准差", fmt.Sprintf("%.2f", report.PnLStdDev))
+	printField("平均持仓时长", models.FormatHoldingDuration(report.AverageHoldingTime))
+	if report.BestTrade != nil {
+		printField("最佳交易", fmt.Sprintf("%s %s %s",
+			report.BestTrade.PositionID, report.BestTrade.Symbol, formatSignedPnL(*report.BestTrade.RealizedPnL)))
+	}
+	if report.WorstTrade != nil {
+		printField("最差交易", fmt.Sprintf("%s %s %s",
+			report.WorstTrade.PositionID, report.WorstTrade.Symbol, formatSignedPnL(*report.WorstTrade.RealizedPnL)))
+	}
+	fmt.Println()
+	printDivider()
+	fmt.Println()
+
+	// 列宽定义
+	const (
+		colName    = 14
+		colTrades  = 8
+		colWinRate = 10
+		colPnL     = 14
+		colAvgPnL  = 12
+		colFees    = 10
+		colRatio   = 8
+		colExpect  = 12
+		colStreak  = 8
+	)
+
+	// 按品种统计（按总盈亏从高到低）
+	printInfo("按品种")
+	fmt.Println()
+	symbolStats := make([]*operations.SymbolStats, 0, len(report.BySymbol))
+	for _, stats := range report.BySymbol {
+		symbolStats = append(symbolStats, stats)
+	}
+	sort.Slice(symbolStats, func(i, j int) bool {
+		return symbolStats[i].TotalPnL > symbolStats[j].TotalPnL
+	})
+
+	printTableHeader(
+		padRight("品种", colName),
+		padRight("交易数", colTrades),
+		padRight("胜率", colWinRate),
+		padRight("净盈亏", colPnL),
+		padRight("平均盈亏", colAvgPnL),
+		padRight("费用", colFees),
+		padRight("盈亏比", colRatio),
+		padRight("盈利因子", colRatio),
+		padRight("每笔期望", colExpect),
+		padRight("连赢/亏", colStreak),
+	)
+	for _, stats := range symbolStats {
+		fmt.Print("  ")
+		fmt.Print(padRight(stats.Symbol, colName))
+		colorMuted.Print(" │ ")
+		fmt.Print(padRight(fmt.Sprintf("%d", stats.TotalTrades), colTrades))
+		colorMuted.Print(" │ ")
+		fmt.Print(padRight(fmt.Sprintf("%.2f%%", stats.WinRate), colWinRate))
+		colorMuted.Print(" │ ")
+		printPnLCell(stats.TotalPnL, colPnL)
+		colorMuted.Print(" │ ")
+		printPnLCell(stats.AveragePnL, colAvgPnL)
+		colorMuted.Print(" │ ")
+		fmt.Print(padRight(fmt.Sprintf("%.2f", stats.TotalFees), colFees))
+		colorMuted.Print(" │ ")
+		fmt.Print(padRight(formatEdgeRatio(stats.PayoffRatio), colRatio))
+		colorMuted.Print(" │ ")
+		fmt.Print(padRight(formatEdgeRatio(stats.ProfitFactor), colRatio))
+		colorMuted.Print(" │ ")
+		printPnLCell(stats.Expectancy, colExpect)
+		colorMuted.Print(" │ ")
+		fmt.Print(padRight(fmt.Sprintf("%d/%d", stats.MaxConsecutiveWins, stats.MaxConsecutiveLosses), colStreak))
+		fmt.Println()
+	}
+	fmt.Println()
+
+	// 按市场类型统计（按总盈亏从高到低）
+	printInfo("按市场类型")
+	fmt.Println()
+	marketStats := make([]*operations.MarketTypeStats, 0, len(report.ByMarketType))
+	for _, stats := range report.ByMarketType {
+		marketStats = append(marketStats, stats)
+	}
+	sort.Slice(marketStats, func(i, j int) bool {
+		return marketStats[i].TotalPnL > marketStats[j].TotalPnL
+	})
+
+	printTableHeader(
+		padRight("市场类型", colName),
+		padRight("交易数", colTrades),
+		padRight("胜率", colWinRate),
+		padRight("净盈亏", colPnL),
+		padRight("平均盈亏", colAvgPnL),
+		padRight("费用", colFees),
+		padRight("盈亏比", colRatio),
+		padRight("盈利因子", colRatio),
+		padRight("每笔期望", colExpect),
+		padRight("连赢/亏", colStreak),
+	)
+	for _, stats := range marketStats {
+		fmt.Print("  ")
+		fmt.Print(padRight(string(stats.MarketType), colName))
+		colorMuted.Print(" │ ")
+		fmt.Print(padRight(fmt.Sprintf("%d", stats.TotalTrades), colTrades))
+		colorMuted.Print(" │ ")
+		fmt.Print(padRight(fmt.Sprintf("%.2f%%", stats.WinRate), colWinRate))
+		colorMuted.Print(" │ ")
+		printPnLCell(stats.TotalPnL, colPnL)
+		colorMuted.Print(" │ ")
+		printPnLCell(stats.AveragePnL, colAvgPnL)
+		colorMuted.Print(" │ ")
+		fmt.Print(padRight(fmt.Sprintf("%.2f", stats.TotalFees), colFees))
+		colorMuted.Print(" │ ")
+		fmt.Print(padRight(formatEdgeRatio(stats.PayoffRatio), colRatio))
+		colorMuted.Print(" │ ")
+		fmt.Print(padRight(formatEdgeRatio(stats.ProfitFactor), colRatio))
+		colorMuted.Print(" │ ")
+		printPnLCell(stats.Expectancy, colExpect)
+		colorMuted.Print(" │ ")
+		fmt.Print(padRight(fmt.Sprintf("%d/%d", stats.MaxConsecutiveWins, stats.MaxConsecutiveLosses), colStreak))
+		fmt.Println()
+	}
+	fmt.Println()
+
+	// 按平仓原因统计
+	if len(report.ByCloseReason) > 0 {
+		printInfo("按平仓原因")
+		for _, reason := range []models.CloseReason{
+			models.CloseReasonTakeProfit, models.CloseReasonStopLoss, models.CloseReasonManual,
+		} {
+			if count, ok := report.ByCloseReason[reason]; ok {
+				printField(string(reason), fmt.Sprintf("%d (%.2f%%)",
+					count, float64(count)/float64(report.TotalTrades)*100))
+			}
+		}
+		fmt.Println()
+	}
+
+	// R 倍数统计
+	if report.RStats.Trades > 0 {
+		printInfo("R 倍数")
+		printField("统计交易", fmt.Sprintf("%d (能确定初始风险的交易)", report.RStats.Trades))
+		printField("总 R", fmt.Sprintf("%+.2fR", report.RStats.TotalR))
+		printField("平均 R", fmt.Sprintf("%+.2fR", report.RStats.AverageR))
+		for _, bucket := range report.RStats.Distribution {
+			fmt.Print("  ")
+			colorMuted.Print(padRight(bucket.Label, 16))
+			if bucket.Min != nil && *bucket.Min >= 0 {
+				colorSuccess.Print(strings.Repeat("█", bucket.Count))
+			} else {
+				colorWarning.Print(strings.Repeat("█", bucket.Count))
+			}
+			fmt.Printf(" %d\n", bucket.Count)
+		}
+		fmt.Println()
+	}
+
+	printDivider()
+	printHint("使用 --format json 可查看完整详细信息")
+	fmt.Println()
+}
@@ -0,0 +1,289 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/cobra"
+	"trading-journal-cli/internal/models"
+)
+
+var (
+	breakerAccountName  string
+	breakerMaxDailyLoss float64
+	breakerMaxDrawdown  float64
+	breakerMaxLosses    int
+	breakerUnlockAt     string
+	breakerLockHours    float64
+	breakerClear        bool
+
+	unlockAccountName string
+	unlockReason      string
+)
+
+var accountBreakerCmd = &cobra.Command{
+	Use:   "breaker",
+	Short: "设置账户熔断规则",
+	Long: `设置账户熔断规则，值为 0 表示不启用该规则：
+  --max-daily-loss  当日已实现净亏损达到该金额
+  --max-drawdown    距资金峰值的回撤达到该百分比
+  --max-losses      连续亏损达到该笔数
+
+开仓时任一规则触发即锁定账户，锁定期间拒绝开仓。默认锁定到下一个 --unlock-at 时刻（本地时间，默认 00:00），
+设置 --lock-hours 时改为锁定固定小时数。锁定解除后只统计之后的交易。
+可使用 'trading-cli account unlock' 提前解锁，解锁理由会写入审计记录。`,
+	RunE: runAccountBreaker,
+}
+
+var accountUnlockCmd = &cobra.Command{
+	Use:   "unlock",
+	Short: "提前解除账户熔断锁定",
+	Long:  `提前解除账户的熔断锁定。必须填写理由，解锁时间、理由和触发的规则会写入审计记录 (audit.jsonl)。`,
+	RunE:  runAccountUnlock,
+}
+
+func init() {
+	accountBreakerCmd.Flags().StringVar(&breakerAccountName, "account", "", "账户名称")
+	accountBreakerCmd.Flags().Float64Var(&breakerMaxDailyLoss, "max-daily-loss", 0, "当日最大已实现净亏损（账户币种）")
+	accountBreakerCmd.Flags().Float64Var(&breakerMaxDrawdown, "max-drawdown", 0, "距资金峰值的最大回撤百分比")
+	accountBreakerCmd.Flags().IntVar(&breakerMaxLosses, "max-losses", 0, "最多连续亏损笔数")
+	accountBreakerCmd.Flags().StringVar(&breakerUnlockAt, "unlock-at", "", "锁定到下一个该时刻 (HH:MM)，默认 00:00")
+	accountBreakerCmd.Flags().Float64Var(&breakerLockHours, "lock-hours", 0, "锁定固定小时数（大于 0 时忽略 --unlock-at）")
+	accountBreakerCmd.Flags().BoolVar(&breakerClear, "clear", false, "清除所有熔断规则")
+	addYesFlag(accountBreakerCmd)
+
+	accountUnlockCmd.Flags().StringVar(&unlockAccountName, "account", "", "账户名称")
+	accountUnlockCmd.Flags().StringVar(&unlockReason, "reason", "", "提前解锁理由（必填）")
+	addYesFlag(accountUnlockCmd)
+
+	accountCmd.AddCommand(accountBreakerCmd)
+	accountCmd.AddCommand(accountUnlockCmd)
+}
+
+// breakerRuleLabel 熔断规则的显示名称
+func breakerRuleLabel(rule models.BreakerRule) string {
+	switch rule {
+	case models.BreakerDailyLoss:
+		return "当日亏损"
+	case models.BreakerDrawdown:
+		return "回撤"
+	case models.BreakerConsecutiveLosses:
+		return "连续亏损"
+	}
+	return string(rule)
+}
+
+// formatLockReason 格式化触发锁定的规则和数值
+func formatLockReason(lock *models.AccountLock) string {
+	switch lock.Rule {
+	case models.BreakerDrawdown:
+		return fmt.Sprintf("%s %.2f%% 达到上限 %g%%", breakerRuleLabel(lock.Rule), lock.Value, lock.Limit)
+	case models.BreakerConsecutiveLosses:
+		return fmt.Sprintf("%s %d 笔达到上限 %d 笔", breakerRuleLabel(lock.Rule), int(lock.Value), int(lock.Limit))
+	}
+	return fmt.Sprintf("%s %.2f 达到上限 %g", breakerRuleLabel(lock.Rule), lock.Value, lock.Limit)
+}
+
+// formatCircuitBreaker 格式化账户熔断规则，未设置时返回空字符串
+func formatCircuitBreaker(breaker *models.CircuitBreaker) string {
+	if breaker.IsEmpty() {
+		return ""
+	}
+	var parts []string
+	if breaker.MaxDailyLoss > 0 {
+		parts = append(parts, fmt.Sprintf("当日亏损 < %g", breaker.MaxDailyLoss))
+	}
+	if breaker.MaxDrawdownPercent > 0 {
+		parts = append(parts, fmt.Sprintf("回撤 < %g%%", breaker.MaxDrawdownPercent))
+	}
+	if breaker.MaxConsecutiveLosses > 0 {
+		parts = append(parts, fmt.Sprintf("连亏 < %d 笔", breaker.MaxConsecutiveLosses))
+	}
+	if breaker.LockHours > 0 {
+		parts = append(parts, fmt.Sprintf("锁定 %g 小时", breaker.LockHours))
+	} else {
+		unlockAt := breaker.UnlockAt
+		if unlockAt == "" {
+			unlockAt = "00:00"
+		}
+		parts = append(parts, fmt.Sprintf("锁定至 %s", unlockAt))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// printAccountLock 输出账户锁定状态，未锁定时不输出；asOf 不为零时输出该时刻的锁定状态
+func printAccountLock(lock *models.AccountLock, asOf time.Time) {
+	locked := lock.ActiveAt(time.Now())
+	if !asOf.IsZero() {
+		locked = lock.HeldAt(asOf)
+	}
+	if !locked {
+		return
+	}
+	fmt.Print("  ")
+	colorMuted.Printf("%-15s ", "状态:")
+	colorWarning.Printf("🔒 已熔断锁定至 %s ", lock.Until.Local().Format("2006-01-02 15:04"))
+	colorMuted.Printf("(%s)\n", formatLockReason(lock))
+}
+
+func runAccountBreaker(cmd *cobra.Command, args []string) error {
+	am := getAccountManager()
+	account, err := selectAccount(cmd, am.ListAccounts(), breakerAccountName, "选择要设置熔断规则的账户:")
+	if err != nil {
+		return err
+	}
+
+	printTitle("🧯 账户熔断规则")
+
+	breaker := &models.CircuitBreaker{}
+	if account.CircuitBreaker != nil {
+		*breaker = *account.CircuitBreaker
+	}
+
+	if !breakerClear {
+		type floatRule struct {
+			flag    string
+			message string
+			value   *float64
+			flagVal float64
+		}
+		for _, r := range []floatRule{
+			{"max-daily-loss", "当日最大亏损 (账户币种，0 为不启用):", &breaker.MaxDailyLoss, breakerMaxDailyLoss},
+			{"max-drawdown", "最大回撤 (距峰值 %，0 为不启用):", &breaker.MaxDrawdownPercent, breakerMaxDrawdown},
+		} {
+			if cmd.Flags().Changed(r.flag) {
+				*r.value = r.flagVal
+			} else if ask, err := needPrompt(cmd, r.flag, true); err != nil {
+				return err
+			} else if ask {
+				if *r.value, err = promptFloat(r.message, fmt.Sprintf("%g", *r.value)); err != nil {
+					return err
+				}
+			}
+		}
+
+		if cmd.Flags().Changed("max-losses") {
+			breaker.MaxConsecutiveLosses = breakerMaxLosses
+		} else if ask, err := needPrompt(cmd, "max-losses", true); err != nil {
+			return err
+		} else if ask {
+			value, err := promptFloat("最多连续亏损笔数 (0 为不启用):", fmt.Sprintf("%d", breaker.MaxConsecutiveLosses))
+			if err != nil {
+				return err
+			}
+			breaker.MaxConsecutiveLosses = int(value)
+		}
+
+		if cmd.Flags().Changed("lock-hours") {
+			breaker.LockHours = breakerLockHours
+		}
+		if cmd.Flags().Changed("unlock-at") {
+			breaker.UnlockAt = breakerUnlockAt
+			if !cmd.Flags().Changed("lock-hours") {
+				breaker.LockHours = 0
+			}
+		} else if !cmd.Flags().Changed("lock-hours") {
+			if ask, err := needPrompt(cmd, "unlock-at", true); err != nil {
+				return err
+			} else if ask && breaker.LockHours == 0 {
+				unlockAt := breaker.UnlockAt
+				if unlockAt == "" {
+					unlockAt = "00:00"
+				}
+				unlockPrompt := &survey.Input{
+					Message: "锁定到下一个该时刻 (HH:MM):",
+					Default: unlockAt,
+				}
+				if err := survey.AskOne(unlockPrompt, &breaker.UnlockAt); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := breaker.Validate(); err != nil {
+			return fmt.Errorf("熔断规则无效: %w", err)
+		}
+	} else {
+		breaker = nil
+	}
+
+	if err := am.UpdateAccountCircuitBreaker(account.Name, breaker); err != nil {
+		printError(fmt.Sprintf("保存熔断规则失败: %v", err))
+		return err
+	}
+
+	printSuccess("熔断规则已更新")
+	printHighlightField("账户", account.Name)
+	if summary := formatCircuitBreaker(breaker); summary != "" {
+		printField("规则", summary)
+	} else {
+		printField("规则", "未启用")
+	}
+	fmt.Println()
+
+	return nil
+}
+
+func runAccountUnlock(cmd *cobra.Command, args []string) error {
+	am := getAccountManager()
+	now := time.Now()
+
+	// 只列出锁定中的账户
+	var locked []models.Account
+	for _, acc := range am.ListAccounts() {
+		if acc.Lock.ActiveAt(now) {
+			locked = append(locked, acc)
+		}
+	}
+	if unlockAccountName == "" && len(locked) == 0 {
+		printInfo("没有处于熔断锁定的账户")
+		return nil
+	}
+
+	candidates := locked
+	if unlockAccountName != "" {
+		candidates = am.ListAccounts()
+	}
+	account, err := selectAccount(cmd, candidates, unlockAccountName, "选择要解锁的账户:")
+	if err != nil {
+		return err
+	}
+	if !account.Lock.ActiveAt(now) {
+		printInfo(fmt.Sprintf("账户 %s 未处于熔断锁定", account.Name))
+		return nil
+	}
+
+	printTitle("🔓 提前解除熔断锁定")
+	printHighlightField("账户", account.Name)
+	printField("锁定时间", account.Lock.LockedAt.Local().Format("2006-01-02 15:04"))
+	printField("原定解锁", account.Lock.Until.Local().Format("2006-01-02 15:04"))
+	printField("触发规则", formatLockReason(account.Lock))
+	fmt.Println()
+
+	reason := unlockReason
+	if ask, err := needPrompt(cmd, "reason", false); err != nil {
+		return err
+	} else if ask {
+		reasonPrompt := &survey.Input{
+			Message: "提前解锁理由:",
+		}
+		if err := survey.AskOne(reasonPrompt, &reason, survey.WithValidator(survey.Required)); err != nil {
+			return err
+		}
+	}
+	if strings.TrimSpace(reason) == "" {
+		return fmt.Errorf("必须填写提前解锁理由")
+	}
+
+	if _, err := ops.UnlockAccount(account.Name, strings.TrimSpace(reason)); err != nil {
+		printError(fmt.Sprintf("解锁失败: %v", err))
+		return err
+	}
+
+	printSuccess("账户已解锁，已写入审计记录")
+	fmt.Println()
+
+	return nil
+}
@@ -2,23 +2,43 @@ package cmd
 
 import (
 	"fmt"
-	"time"
 
 	"github.com/AlecAivazis/survey/v2"
-	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 	"trading-journal-cli/internal/models"
 	"trading-journal-cli/internal/operations"
 )
 
+var (
+	closePositionID string
+	closePrice      float64
+	closeQuantity   float64
+	closeReason     string
+	closePnL        float64
+	closeNote       string
+	closeTime       string
+	closeFees       models.Fees
+)
+
 var closeCmd = &cobra.Command{
 	Use:   "close",
 	Short: "平仓记录",
-	Long:  `选择未平仓的仓位并记录平仓信息`,
-	RunE:  runClose,
+	Long: `选择未平仓的仓位并记录平仓信息。
+所有提示都有对应的参数，只有缺少的值才会提示输入；使用 --yes 可完全以非交互方式运行。`,
+	RunE: runClose,
 }
 
 func init() {
+	closeCmd.Flags().StringVar(&closePositionID, "id", "", "仓位ID")
+	closeCmd.Flags().Float64Var(&closePrice, "price", 0, "平仓价格")
+	closeCmd.Flags().Float64Var(&closeQuantity, "qty", 0, "平仓数量，默认全部平仓")
+	closeCmd.Flags().StringVar(&closeReason, "reason", "", "平仓原因 (stop_loss, take_profit, manual)")
+	closeCmd.Flags().Float64Var(&closePnL, "pnl", 0, "手动输入的盈亏金额（正数为盈利，负数为亏损）")
+	closeCmd.Flags().StringVar(&closeNote, "note", "", "平仓备注")
+	closeCmd.Flags().StringVar(&closeTime, "time", "", "平仓时间 (格式: 2006-01-02 15:04:05)，默认当前时间")
+	addFeeFlags(closeCmd, &closeFees, true)
+	addYesFlag(closeCmd)
+
 	rootCmd.AddCommand(closeCmd)
 }
 
@@ -34,30 +54,23 @@ func runClose(cmd *cobra.Command, args []string) error {
 
 	if len(openPositions) == 0 {
 		printWarning("暂无未平仓位")
+		if cmd.Flags().Changed("id") {
+			return fmt.Errorf("未找到未平仓位: %s", closePositionID)
+		}
 		return nil
 	}
 
-	// 显示未平仓位列表
-	printInfo(fmt.Sprintf("找到 %d 个未平仓位", len(openPositions)))
-	fmt.Println()
-
-	options := make([]string, len(openPositions))
-	for i, pos := range openPositions {
-		options[i] = fmt.Sprintf("[%s] %s (%s) @ %.4f x %.4f",
-			pos.PositionID[:13]+"...", pos.Symbol, pos.Direction, pos.OpenPrice, pos.Quantity)
+	closeReasonOptions := []string{"stop_loss", "take_profit", "manual"}
+	if err := checkOption(cmd, "reason", closeReason, closeReasonOptions); err != nil {
+		return err
 	}
 
-	// 选择仓位
-	var selectedIndex int
-	selectPrompt := &survey.Select{
-		Message: "选择要平仓的仓位:",
-		Options: options,
-	}
-	if err := survey.AskOne(selectPrompt, &selectedIndex); err != nil {
+	// 选择仓位（命令行指定ID时直接使用）
+	selectedPos, err := selectOpenPosition(openPositions, closePositionID, "选择要平仓的仓位:")
+	if err != nil {
 		return err
 	}
 
-	selectedPos := openPositions[selectedIndex]
 	fmt.Println()
 	printDivider()
 	printHighlightField("仓位ID", selectedPos.PositionID)
@@ -71,100 +84,137 @@ func runClose(cmd *cobra.Command, args []string) error {
 	var params operations.CloseParams
 
 	// 平仓价格
-	var closePriceStr string
-	closePricePrompt := &survey.Input{
-		Message: "平仓价格:",
-	}
-	if err := survey.AskOne(closePricePrompt, &closePriceStr, survey.WithValidator(survey.Required)); err != nil {
+	params.ClosePrice = closePrice
+	if ask, err := needPrompt(cmd, "price", false); err != nil {
 		return err
-	}
-	if _, err := fmt.Sscanf(closePriceStr, "%f", &params.ClosePrice); err != nil {
-		return fmt.Errorf("无效的价格格式: %w", err)
+	} else if ask {
+		var closePriceStr string
+		closePricePrompt := &survey.Input{
+			Message: "平仓价格:",
+		}
+		if err := survey.AskOne(closePricePrompt, &closePriceStr, survey.WithValidator(survey.Required)); err != nil {
+			return err
+		}
+		if _, err := fmt.Sscanf(closePriceStr, "%f", &params.ClosePrice); err != nil {
+			return fmt.Errorf("无效的价格格式: %w", err)
+		}
 	}
 
-	// 平仓数量
-	var closeQuantityStr string
-	closeQuantityPrompt := &survey.Input{
-		Message: fmt.Sprintf("平仓数量 (最大 %.4f):", selectedPos.Quantity),
-		Default: fmt.Sprintf("%.4f", selectedPos.Quantity),
+	// 平仓数量（默认全部平仓）
+	params.CloseQuantity = closeQuantity
+	if !cmd.Flags().Changed("qty") {
+		params.CloseQuantity = selectedPos.Quantity
 	}
-	if err := survey.AskOne(closeQuantityPrompt, &closeQuantityStr, survey.WithValidator(survey.Required)); err != nil {
+	if ask, err := needPrompt(cmd, "qty", true); err != nil {
 		return err
-	}
-	if _, err := fmt.Sscanf(closeQuantityStr, "%f", &params.CloseQuantity); err != nil {
-		return fmt.Errorf("无效的数量格式: %w", err)
+	} else if ask {
+		var closeQuantityStr string
+		closeQuantityPrompt := &survey.Input{
+			Message: fmt.Sprintf("平仓数量 (最大 %.4f):", selectedPos.Quantity),
+			Default: fmt.Sprintf("%.4f", selectedPos.Quantity),
+		}
+		if err := survey.AskOne(closeQuantityPrompt, &closeQuantityStr, survey.WithValidator(survey.Required)); err != nil {
+			return err
+		}
+		if _, err := fmt.Sscanf(closeQuantityStr, "%f", &params.CloseQuantity); err != nil {
+			return fmt.Errorf("无效的数量格式: %w", err)
+		}
 	}
 
 	// 询问是否手动输入盈亏
-	var useManualPnL bool
-	manualPnLPrompt := &survey.Confirm{
-		Message: "是否手动输入盈亏金额?",
-		Default: false,
-	}
-	if err := survey.AskOne(manualPnLPrompt, &useManualPnL); err != nil {
+	if cmd.Flags().Changed("pnl") {
+		params.ManualPnL = &closePnL
+	} else if ask, err := needPrompt(cmd, "pnl", true); err != nil {
 		return err
-	}
-
-	// 如果选择手动输入盈亏
-	if useManualPnL {
-		var pnlStr string
-		pnlPrompt := &survey.Input{
-			Message: "盈亏金额 (正数为盈利，负数为亏损):",
+	} else if ask {
+		var useManualPnL bool
+		manualPnLPrompt := &survey.Confirm{
+			Message: "是否手动输入盈亏金额?",
+			Default: false,
 		}
-		if err := survey.AskOne(pnlPrompt, &pnlStr, survey.WithValidator(survey.Required)); err != nil {
+		if err := survey.AskOne(manualPnLPrompt, &useManualPnL); err != nil {
 			return err
 		}
-		var pnl float64
-		if _, err := fmt.Sscanf(pnlStr, "%f", &pnl); err != nil {
-			return fmt.Errorf("无效的盈亏格式: %w", err)
+
+		// 如果选择手动输入盈亏
+		if useManualPnL {
+			var pnlStr string
+			pnlPrompt := &survey.Input{
+				Message: "盈亏金额 (正数为盈利，负数为亏损):",
+			}
+			if err := survey.AskOne(pnlPrompt, &pnlStr, survey.WithValidator(survey.Required)); err != nil {
+				return err
+			}
+			var pnl float64
+			if _, err := fmt.Sscanf(pnlStr, "%f", &pnl); err != nil {
+				return fmt.Errorf("无效的盈亏格式: %w", err)
+			}
+			params.ManualPnL = &pnl
 		}
-		params.ManualPnL = &pnl
 	}
 
-	// 平仓原因
-	var closeReasonStr string
-	closeReasonPrompt := &survey.Select{
-		Message: "平仓原因:",
-		Options: []string{"stop_loss", "take_profit", "manual"},
+	// 平仓费用及资金费（可选）
+	if err := promptFees(cmd, &closeFees, true); err != nil {
+		return err
 	}
-	if err := survey.AskOne(closeReasonPrompt, &closeReasonStr); err != nil {
+	params.Fees = closeFees
+
+	// 平仓原因
+	closeReasonStr := closeReason
+	if ask, err := needPrompt(cmd, "reason", false); err != nil {
 		return err
+	} else if ask {
+		closeReasonPrompt := &survey.Select{
+			Message: "平仓原因:",
+			Options: closeReasonOptions,
+		}
+		if err := survey.AskOne(closeReasonPrompt, &closeReasonStr); err != nil {
+			return err
+		}
 	}
 	params.CloseReason = models.CloseReason(closeReasonStr)
 
 	// 平仓备注（可选）
-	closeNotePrompt := &survey.Input{
-		Message: "平仓备注 (可选):",
+	params.CloseNote = closeNote
+	if ask, err := needPrompt(cmd, "note", true); err != nil {
+		return err
+	} else if ask {
+		closeNotePrompt := &survey.Input{
+			Message: "平仓备注 (可选):",
+		}
+		survey.AskOne(closeNotePrompt, &params.CloseNote)
 	}
-	survey.AskOne(closeNotePrompt, &params.CloseNote)
 
 	// 平仓时间（可选）
-	var useCurrentTime bool
-	timePrompt := &survey.Confirm{
-		Message: "使用当前时间?",
-		Default: true,
-	}
-	if err := survey.AskOne(timePrompt, &useCurrentTime); err != nil {
+	timeStr := closeTime
+	if ask, err := needPrompt(cmd, "time", true); err != nil {
 		return err
-	}
-
-	if !useCurrentTime {
-		var timeStr string
-		customTimePrompt := &survey.Input{
-			Message: "平仓时间 (格式: 2006-01-02 15:04:05):",
+	} else if ask {
+		useCurrentTime := true
+		timePrompt := &survey.Confirm{
+			Message: "使用当前时间?",
+			Default: true,
 		}
-		if err := survey.AskOne(customTimePrompt, &timeStr); err != nil {
+		if err := survey.AskOne(timePrompt, &useCurrentTime); err != nil {
 			return err
 		}
-		if timeStr != "" {
-			// 使用 ParseInLocation 确保时间使用本地时区
-			t, err := time.ParseInLocation("2006-01-02 15:04:05", timeStr, time.Local)
-			if err != nil {
-				return fmt.Errorf("无效的时间格式: %w", err)
+
+		if !useCurrentTime {
+			customTimePrompt := &survey.Input{
+				Message: "平仓时间 (格式: 2006-01-02 15:04:05):",
+			}
+			if err := survey.AskOne(customTimePrompt, &timeStr); err != nil {
+				return err
 			}
-			params.CloseTime = &t
 		}
 	}
+	if timeStr != "" {
+		t, err := parseLocalTime(timeStr)
+		if err != nil {
+			return err
+		}
+		params.CloseTime = &t
+	}
 
 	// 执行平仓操作
 	pos, err := ops.ClosePosition(selectedPos.PositionID, params)
@@ -174,25 +224,40 @@ func runClose(cmd *cobra.Command, args []string) error {
 	}
 
 	// 显示成功信息
+	fill := pos.Fills[len(pos.Fills)-1]
 	fmt.Println()
-	printSuccess("仓位已平仓")
+	if pos.Status == models.StatusClosed {
+		printSuccess("仓位已平仓")
+	} else {
+		printSuccess("仓位已部分平仓")
+	}
 	printHighlightField("仓位ID", pos.PositionID)
 	printDivider()
-	printField("平仓价格", fmt.Sprintf("%.4f", *pos.ClosePrice))
-	printField("平仓数量", fmt.Sprintf("%.4f", *pos.CloseQuantity))
-
-	// 盈亏（使用颜色）
-	pnlColor := color.New(color.FgRed)
-	pnlSign := ""
-	if *pos.RealizedPnL > 0 {
-		pnlColor = color.New(color.FgGreen, color.Bold)
-		pnlSign = "+"
-	}
-	fmt.Print("  ")
-	colorMuted := color.New(color.FgHiBlack)
-	colorMuted.Printf("%-15s ", "盈亏:")
-	pnlColor.Printf("%s%.2f (%s%.2f%%)\n",
-		pnlSign, *pos.RealizedPnL, pnlSign, *pos.PnLPercentage)
+	printField("平仓价格", fmt.Sprintf("%.4f", fill.ClosePrice))
+	printField("平仓数量", fmt.Sprintf("%.4f", fill.CloseQuantity))
+	if fill.Fees != nil {
+		printField("毛盈亏", fmt.Sprintf("%.2f", fill.GrossPnL))
+		printField("平仓费用", fmt.Sprintf("%.2f", fill.Fees.Total()))
+	}
+	printPnLField("本次盈亏", fill.RealizedPnL, models.CalculatePnLPercentage(fill.RealizedPnL, pos.AccountBalance))
+
+	// 多次成交时显示累计结果
+	if len(pos.Fills) > 1 {
+		printField("成交次数", len(pos.Fills))
+		printField("平均平仓价", fmt.Sprintf("%.4f", *pos.ClosePrice))
+		printField("累计平仓数量", fmt.Sprintf("%.4f", *pos.CloseQuantity))
+		printPnLField("累计盈亏", *pos.RealizedPnL, *pos.PnLPercentage)
+	}
+	// 开仓/加仓费用在首次平仓起计入累计净盈亏
+	if fees := pos.EntryFees(); fees != 0 {
+		printField("开仓费用", fmt.Sprintf("%.2f", fees))
+		if len(pos.Fills) == 1 {
+			printPnLField("净盈亏", *pos.RealizedPnL, *pos.PnLPercentage)
+		}
+	}
+	if pos.Status == models.StatusOpen {
+		printField("剩余数量", fmt.Sprintf("%.4f", pos.Quantity))
+	}
 
 	printField("持仓时长", *pos.HoldingDuration)
 	if pos.CloseNote != "" {
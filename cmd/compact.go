@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"trading-journal-cli/internal/storage"
+)
+
+var (
+	compactDryRun         bool
+	compactArchive        bool
+	compactDiscardHistory bool
+)
+
+var compactCmd = &cobra.Command{
+	Use:   "compact",
+	Short: "压缩交易记录文件",
+	Long: `每次平仓、加仓或调整都会向月份文件追加仓位的完整新版本，文件会不断变大。
+compact 将每个 trades-YYYY-MM.jsonl 重写为每个仓位只保留最新版本的一行：
+
+  - 原文件先备份为 trades-YYYY-MM.jsonl.<时间戳>.backup，多次压缩不会覆盖之前的备份
+  - 新内容写入临时文件后原子替换原文件，中途失败不会损坏原文件
+  - 被取代的旧版本追加到 history-YYYY-MM.jsonl，history、--as-of 和 undo 仍可使用
+  - 使用 --discard-history 时直接丢弃旧版本
+  - 使用 --dry-run 只统计可回收的空间`,
+	RunE: runCompact,
+}
+
+func init() {
+	compactCmd.Flags().BoolVar(&compactDryRun, "dry-run", false, "只统计可回收的空间，不修改文件")
+	compactCmd.Flags().BoolVar(&compactDiscardHistory, "discard-history", false, "丢弃被取代的旧版本，不归档到 history-YYYY-MM.jsonl")
+	compactCmd.Flags().BoolVar(&compactArchive, "archive", false, "将被取代的旧版本归档到 history-YYYY-MM.jsonl")
+	compactCmd.Flags().MarkDeprecated("archive", "旧版本默认已归档，不再需要该参数")
+
+	rootCmd.AddCommand(compactCmd)
+}
+
+// formatBytes 以易读的单位显示字节数
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit && size > -unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value := float64(size)
+	for _, suffix := range []string{"KB", "MB", "GB"} {
+		value /= unit
+		if value < unit && value > -unit {
+			return fmt.Sprintf("%.1f %s", value, suffix)
+		}
+	}
+	return fmt.Sprintf("%.1f TB", value/unit)
+}
+
+func runCompact(cmd *cobra.Command, args []string) error {
+	if storageType == "sqlite" {
+		return fmt.Errorf("compact 只适用于 JSONL 存储，SQLite 数据库无需压缩")
+	}
+	if compactArchive && compactDiscardHistory {
+		return fmt.Errorf("--archive 和 --discard-history 不能同时使用")
+	}
+
+	if compactDryRun {
+		printTitle("🗜️  压缩交易记录（预览）")
+	} else {
+		printTitle("🗜️  压缩交易记录")
+	}
+
+	results, err := storage.NewJSONLStorage(dataDir).Compact(storage.CompactOptions{
+		DryRun:         compactDryRun,
+		DiscardHistory: compactDiscardHistory,
+	})
+	// 出错前已处理的文件仍然输出
+	var totalBefore, totalAfter int64
+	var totalSuperseded int
+	if len(results) > 0 {
+		const (
+			colFile  = 24
+			colLines = 14
+			colSize  = 22
+		)
+		printTableHeader(
+			padRight("文件", colFile),
+			padRight("行数", colLines),
+			padRight("大小", colSize),
+			"回收",
+		)
+		for _, r := range results {
+			totalBefore += r.BytesBefore
+			totalAfter += r.BytesAfter
+			totalSuperseded += r.Superseded
+
+			fmt.Print("  ")
+			fmt.Print(padRight(r.File, colFile))
+			colorMuted.Print(" │ ")
+			fmt.Print(padRight(fmt.Sprintf("%d -> %d", r.LinesBefore, r.LinesAfter), colLines))
+			colorMuted.Print(" │ ")
+			fmt.Print(padRight(fmt.Sprintf("%s -> %s", formatBytes(r.BytesBefore), formatBytes(r.BytesAfter)), colSize))
+			colorMuted.Print(" │ ")
+			if r.Reclaimed() > 0 {
+				colorSuccess.Print(formatBytes(r.Reclaimed()))
+			} else {
+				colorMuted.Print("-")
+			}
+			fmt.Println()
+
+			if r.InvalidLines > 0 {
+				printWarning(fmt.Sprintf("%s 有 %d 行无法解析，已原样保留", r.File, r.InvalidLines))
+			}
+		}
+		fmt.Println()
+	}
+
+	if err != nil {
+		printError(fmt.Sprintf("压缩失败: %v", err))
+		return err
+	}
+
+	if len(results) == 0 {
+		printWarning("没有交易记录文件")
+		return nil
+	}
+
+	printDivider()
+	printField("旧版本", fmt.Sprintf("%d 行", totalSuperseded))
+	if compactDryRun {
+		printHighlightField("可回收空间", formatBytes(totalBefore-totalAfter))
+		fmt.Println()
+		printHint("去掉 --dry-run 执行压缩，旧版本默认归档到 history-YYYY-MM.jsonl")
+	} else {
+		printHighlightField("已回收空间", formatBytes(totalBefore-totalAfter))
+		if totalSuperseded > 0 {
+			if compactDiscardHistory {
+				printWarning("旧版本已丢弃，history、--as-of 和 undo 无法再使用这些版本")
+				printInfo("原文件已备份为带时间戳的 .backup，确认无误后可删除")
+			} else {
+				printInfo("旧版本已归档到 history-YYYY-MM.jsonl，原文件已备份为带时间戳的 .backup")
+			}
+		} else {
+			printSuccess("所有文件均已是最新版本，无需压缩")
+		}
+	}
+	fmt.Println()
+
+	return nil
+}
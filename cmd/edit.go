@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/cobra"
+	"trading-journal-cli/internal/models"
+	"trading-journal-cli/internal/operations"
+)
+
+var (
+	editSymbol      string
+	editMarketType  string
+	editOpenPrice   float64
+	editOpenTime    string
+	editTradeReason string
+	editNote        string
+	editBy          string
+)
+
+var editCmd = &cobra.Command{
+	Use:   "edit <positionID>",
+	Short: "修改仓位的开仓信息",
+	Long: `更正录入错误的品种、市场类型、开仓价格、开仓时间或交易理由。
+表单预先填入当前值，修改后按开仓规则重新验证，并重新计算加权平均开仓价、初始风险、平仓盈亏、盈亏比例和持仓时长。
+修改追加为仓位的新版本，修改人、时间和原因记录在仓位的 edits 中；已平仓仓位的盈亏变化时追加更正流水。`,
+	Args: cobra.ExactArgs(1),
+	RunE: runEdit,
+}
+
+func init() {
+	editCmd.Flags().StringVar(&editSymbol, "symbol", "", "交易品种")
+	editCmd.Flags().StringVar(&editMarketType, "market", "", "市场类型 (crypto, forex, gold, silver, futures, cn_stocks, us_stocks)")
+	editCmd.Flags().Float64Var(&editOpenPrice, "price", 0, "首次开仓的成交价格")
+	editCmd.Flags().StringVar(&editOpenTime, "time", "", "开仓时间 (格式: 2006-01-02 15:04:05)")
+	editCmd.Flags().StringVar(&editTradeReason, "reason", "", "交易理由")
+	editCmd.Flags().StringVar(&editNote, "note", "", "修改原因（必填）")
+	editCmd.Flags().StringVar(&editBy, "by", "", "修改人，默认为当前系统用户")
+	addYesFlag(editCmd)
+
+	rootCmd.AddCommand(editCmd)
+}
+
+func runEdit(cmd *cobra.Command, args []string) error {
+	marketTypeOptions := []string{"crypto", "forex", "gold", "silver", "futures", "cn_stocks", "us_stocks"}
+	if err := checkOption(cmd, "market", editMarketType, marketTypeOptions); err != nil {
+		return err
+	}
+
+	pos, err := ops.GetPosition(args[0])
+	if err != nil {
+		return err
+	}
+
+	printTitle("✏️  修改仓位")
+	printHighlightField("仓位ID", pos.PositionID)
+	printField("账户", pos.AccountName)
+	printField("方向", pos.Direction)
+	printField("状态", pos.Status)
+	printDivider()
+	fmt.Println()
+
+	var params operations.EditParams
+
+	// 交易品种
+	symbol := pos.Symbol
+	if cmd.Flags().Changed("symbol") {
+		symbol = editSymbol
+	}
+	if ask, err := needPrompt(cmd, "symbol", true); err != nil {
+		return err
+	} else if ask {
+		symbolPrompt := &survey.Input{
+			Message: "交易品种:",
+			Default: pos.Symbol,
+		}
+		if err := survey.AskOne(symbolPrompt, &symbol, survey.WithValidator(survey.Required)); err != nil {
+			return err
+		}
+	}
+	params.Symbol = &symbol
+
+	// 市场类型
+	marketTypeStr := string(pos.MarketType)
+	if cmd.Flags().Changed("market") {
+		marketTypeStr = editMarketType
+	}
+	if ask, err := needPrompt(cmd, "market", true); err != nil {
+		return err
+	} else if ask {
+		marketTypePrompt := &survey.Select{
+			Message: "市场类型:",
+			Options: marketTypeOptions,
+			Default: string(pos.MarketType),
+		}
+		if err := survey.AskOne(marketTypePrompt, &marketTypeStr); err != nil {
+			return err
+		}
+	}
+	marketType := models.MarketType(marketTypeStr)
+	params.MarketType = &marketType
+
+	// 开仓价格（首次开仓成交）
+	currentPrice := pos.OpenPrice
+	if len(pos.Entries) > 0 {
+		currentPrice = pos.Entries[0].Price
+	}
+	openPrice := currentPrice
+	if cmd.Flags().Changed("price") {
+		openPrice = editOpenPrice
+	}
+	if ask, err := needPrompt(cmd, "price", true); err != nil {
+		return err
+	} else if ask {
+		message := "开仓价格:"
+		if len(pos.Entries) > 1 {
+			message = "首次开仓价格 (加权平均开仓价将重新计算):"
+		}
+		if openPrice, err = promptFloat(message, strconv.FormatFloat(currentPrice, 'f', -1, 64)); err != nil {
+			return err
+		}
+	}
+	params.OpenPrice = &openPrice
+
+	// 开仓时间（表单只精确到秒，未修改时保持原值）
+	openTimeStr := pos.OpenTime.Local().Format("2006-01-02 15:04:05")
+	if cmd.Flags().Changed("time") {
+		openTimeStr = editOpenTime
+	}
+	if ask, err := needPrompt(cmd, "time", true); err != nil {
+		return err
+	} else if ask {
+		openTimePrompt := &survey.Input{
+			Message: "开仓时间 (格式: 2006-01-02 15:04:05):",
+			Default: openTimeStr,
+		}
+		if err := survey.AskOne(openTimePrompt, &openTimeStr, survey.WithValidator(survey.Required)); err != nil {
+			return err
+		}
+	}
+	openTime, err := parseLocalTime(openTimeStr)
+	if err != nil {
+		return err
+	}
+	if !openTime.Equal(pos.OpenTime.Truncate(time.Second)) {
+		params.OpenTime = &openTime
+	}
+
+	// 交易理由
+	reason := pos.Reason
+	if cmd.Flags().Changed("reason") {
+		reason = editTradeReason
+	}
+	if ask, err := needPrompt(cmd, "reason", true); err != nil {
+		return err
+	} else if ask {
+		reasonPrompt := &survey.Input{
+			Message: "交易理由:",
+			Default: pos.Reason,
+		}
+		if err := survey.AskOne(reasonPrompt, &reason); err != nil {
+			return err
+		}
+	}
+	params.Reason = &reason
+
+	// 修改原因（必填）
+	params.EditReason = editNote
+	if ask, err := needPrompt(cmd, "note", false); err != nil {
+		return err
+	} else if ask {
+		notePrompt := &survey.Input{
+			Message: "修改原因 (必填):",
+		}
+		if err := survey.AskOne(notePrompt, &params.EditReason, survey.WithValidator(survey.Required)); err != nil {
+			return err
+		}
+	}
+
+	params.EditedBy = editBy
+	if params.EditedBy == "" {
+		params.EditedBy = currentUserName()
+	}
+
+	result, err := ops.EditPosition(pos.PositionID, params)
+	if err != nil {
+		printError(fmt.Sprintf("修改失败: %v", err))
+		return err
+	}
+
+	fmt.Println()
+	printSuccess("仓位已修改")
+	printHighlightField("仓位ID", result.Position.PositionID)
+	printDivider()
+	for _, change := range result.Changes {
+		fmt.Print("      ")
+		colorMuted.Printf("%s: ", change.Field)
+		fmt.Printf("%s → ", displayChangeValue(change.Old))
+		colorValue.Printf("%s\n", displayChangeValue(change.New))
+	}
+	for _, entry := range result.Ledger {
+		printInfo(fmt.Sprintf("资金流水 %s: %s", entry.Note, formatSignedPnL(entry.Amount)))
+	}
+	fmt.Println()
+	printHint(fmt.Sprintf("使用 'trading-cli history %s' 查看修改记录，'trading-cli undo' 可撤销本次修改", result.Position.PositionID))
+	fmt.Println()
+
+	return nil
+}
+
+// currentUserName 当前系统用户名，无法获取时为空
+func currentUserName() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return strings.TrimSpace(os.Getenv("USER"))
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"trading-journal-cli/internal/models"
+	"trading-journal-cli/internal/operations"
+)
+
+var (
+	equityFromDate string
+	equityToDate   string
+)
+
+var analyzeEquityCmd = &cobra.Command{
+	Use:   "equity",
+	Short: "资金曲线与最大回撤",
+	Long: `按资金流水计算每个账户（多个账户时另附合并结果）的资金曲线、峰值、最大回撤金额和百分比、
+回撤持续时间和恢复时间。入金和出金同步调整峰值，不计为回撤。
+指定 --account 时同时列出资金曲线的每个点。`,
+	RunE: runAnalyzeEquity,
+}
+
+func init() {
+	analyzeEquityCmd.Flags().StringVar(&equityFromDate, "from", "", "起始日期 (YYYY-MM-DD)，之前的流水计入期初余额")
+	analyzeEquityCmd.Flags().StringVar(&equityToDate, "to", "", "结束日期 (YYYY-MM-DD)")
+
+	analyzeCmd.AddCommand(analyzeEquityCmd)
+}
+
+func runAnalyzeEquity(cmd *cobra.Command, args []string) error {
+	if err := validateAnalyzeFormat(); err != nil {
+		return err
+	}
+
+	// 复用 list 的日期解析
+	var filter operations.FilterParams
+	if err := parseFilterDates(&filter, equityFromDate, equityToDate); err != nil {
+		return err
+	}
+
+	curves, err := ops.EquityCurves(operations.EquityParams{
+		AccountName: analyzeAccountName,
+		Currency:    analyzeCurrency,
+		FromDate:    filter.FromDate,
+		ToDate:      filter.ToDate,
+	})
+	if err != nil {
+		return fmt.Errorf("资金曲线分析失败: %w", err)
+	}
+
+	if analyzeFormat == "json" {
+		return outputReportJSON(curves)
+	}
+
+	printTitle("📉 资金曲线与回撤")
+
+	if len(curves) == 0 {
+		printWarning("暂无账户")
+		printHint("使用 'trading-cli account add' 添加新账户")
+		return nil
+	}
+
+	for i, curve := range curves {
+		if i > 0 {
+			printDivider()
+		}
+		outputEquitySummary(curve)
+	}
+
+	if analyzeAccountName != "" {
+		fmt.Println()
+		printDivider()
+		fmt.Println()
+		outputEquityPoints(curves[0])
+	}
+
+	fmt.Println()
+	printDivider()
+	if analyzeAccountName == "" {
+		printHint("使用 --account 查看单个账户的资金曲线明细，--format json 查看完整数据")
+	} else {
+		printHint("使用 --format json 可查看完整详细信息")
+	}
+	fmt.Println()
+
+	return nil
+}
+
+// outputEquitySummary 输出一条资金曲线的汇总
+func outputEquitySummary(curve *operations.EquityCurve) {
+	name := curve.AccountName
+	if name == "" {
+		name = "所有账户合计"
+	}
+	printHighlightField("账户", name)
+	printField("币种", curve.Currency)
+
+	if len(curve.Points) == 0 {
+		printField("余额", fmt.Sprintf("%.2f", curve.EndBalance))
+		printWarning("所选期间没有资金流水")
+		return
+	}
+
+	printField("余额", fmt.Sprintf("%.2f -> %.2f", curve.StartBalance, curve.EndBalance))
+	printField("净入金", formatSignedPnL(curve.NetDeposits))
+	if curve.Adjustments != 0 {
+		printField("余额调整", formatSignedPnL(curve.Adjustments))
+	}
+	printField("交易盈亏", formatSignedPnL(curve.TradingPnL))
+	printField("峰值", fmt.Sprintf("%.2f (%s)", curve.Peak, curve.PeakTime.Local().Format("2006-01-02 15:04")))
+
+	if curve.MaxDrawdown == 0 {
+		printField("最大回撤", "无")
+		return
+	}
+
+	printField("最大回撤", fmt.Sprintf("%.2f (%.2f%%)", curve.MaxDrawdown, curve.MaxDrawdownPercent))
+	printField("回撤区间", fmt.Sprintf("%s -> %s",
+		curve.MaxDrawdownPeak.Local().Format("2006-01-02 15:04"),
+		curve.MaxDrawdownTrough.Local().Format("2006-01-02 15:04")))
+	if curve.MaxDrawdownRecovery != nil {
+		printField("回撤持续", models.FormatHoldingDuration(curve.DrawdownDuration))
+		printField("恢复用时", fmt.Sprintf("%s (%s 恢复)",
+			models.FormatHoldingDuration(curve.RecoveryDuration),
+			curve.MaxDrawdownRecovery.Local().Format("2006-01-02 15:04")))
+	} else {
+		printField("回撤持续", models.FormatHoldingDuration(curve.DrawdownDuration)+" (尚未恢复)")
+	}
+	if curve.CurrentDrawdown > 0 {
+		printField("当前回撤", fmt.Sprintf("%.2f (%.2f%%)", curve.CurrentDrawdown, curve.CurrentDrawdownPercent))
+	}
+}
+
+// outputEquityPoints 输出资金曲线的每个点
+func outputEquityPoints(curve *operations.EquityCurve) {
+	if len(curve.Points) == 0 {
+		return
+	}
+
+	const (
+		colTime     = 19
+		colType     = 10
+		colAmount   = 14
+		colBalance  = 14
+		colDrawdown = 20
+	)
+
+	printTableHeader(
+		padRight("时间", colTime),
+		padRight("类型", colType),
+		padRight("金额", colAmount),
+		padRight("余额", colBalance),
+		padRight("回撤", colDrawdown),
+		"仓位ID",
+	)
+
+	for _, point := range curve.Points {
+		positionID := point.PositionID
+		if positionID == "" {
+			positionID = "-"
+		}
+
+		fmt.Print("  ")
+		fmt.Print(padRight(point.Time.Local().Format("2006-01-02 15:04:05"), colTime))
+		colorMuted.Print(" │ ")
+		fmt.Print(padRight(ledgerTypeLabel(point.Type), colType))
+		colorMuted.Print(" │ ")
+		printPnLCell(point.Amount, colAmount)
+		colorMuted.Print(" │ ")
+		fmt.Print(padRight(fmt.Sprintf("%.2f", point.Balance), colBalance))
+		colorMuted.Print(" │ ")
+		if point.Drawdown > 0 {
+			colorWarning.Print(padRight(fmt.Sprintf("-%.2f (%.2f%%)", point.Drawdown, point.DrawdownPercent), colDrawdown))
+		} else {
+			colorMuted.Print(padRight("-", colDrawdown))
+		}
+		colorMuted.Print(" │ ")
+		fmt.Print(positionID)
+		fmt.Println()
+	}
+}
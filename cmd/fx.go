@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"trading-journal-cli/internal/models"
+)
+
+var fxDate string
+
+var fxCmd = &cobra.Command{
+	Use:   "fx",
+	Short: "汇率管理",
+	Long: `管理汇率表（数据目录下的 fx-rates.json）。
+list 的合计、analyze risk 和 analyze performance 会将各账户币种的金额按汇率换算为报告币种（--currency）后再汇总。
+换算时使用平仓日期（风险分析为当天）当天或之前最近的汇率，只登记了反向汇率时自动取倒数。`,
+}
+
+var fxListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出所有汇率",
+	RunE:  runFXList,
+}
+
+var fxSetCmd = &cobra.Command{
+	Use:   "set <base> <quote> <rate>",
+	Short: "登记汇率 (1 base = rate quote)",
+	Example: `  trading-cli fx set USD CNY 7.25
+  trading-cli fx set USD CNY 7.10 --date 2025-01-02`,
+	Args: cobra.ExactArgs(3),
+	RunE: runFXSet,
+}
+
+var fxDeleteCmd = &cobra.Command{
+	Use:   "delete <base> <quote> <date>",
+	Short: "删除指定日期的汇率",
+	Args:  cobra.ExactArgs(3),
+	RunE:  runFXDelete,
+}
+
+func init() {
+	fxSetCmd.Flags().StringVar(&fxDate, "date", "", "生效日期 (YYYY-MM-DD)，默认今天")
+
+	fxCmd.AddCommand(fxListCmd)
+	fxCmd.AddCommand(fxSetCmd)
+	fxCmd.AddCommand(fxDeleteCmd)
+	rootCmd.AddCommand(fxCmd)
+}
+
+func getFXTable() *models.FXTable {
+	table := models.NewFXTable(dataDir)
+	if err := table.Load(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	return table
+}
+
+func runFXList(cmd *cobra.Command, args []string) error {
+	rates := getFXTable().ListRates()
+
+	printTitle("💱 汇率")
+
+	if len(rates) == 0 {
+		printWarning("暂无汇率")
+		printHint("使用 'trading-cli fx set USD CNY 7.25' 登记汇率")
+		return nil
+	}
+
+	const (
+		colPair = 12
+		colDate = 12
+		colRate = 14
+	)
+
+	printTableHeader(
+		padRight("货币对", colPair),
+		padRight("日期", colDate),
+		padRight("汇率", colRate),
+	)
+	for _, rate := range rates {
+		fmt.Print("  ")
+		colorHighlight.Print(padRight(rate.Base+"/"+rate.Quote, colPair))
+		colorMuted.Print(" │ ")
+		fmt.Print(padRight(rate.Date, colDate))
+		colorMuted.Print(" │ ")
+		fmt.Print(padRight(fmt.Sprintf("%g", rate.Rate), colRate))
+		fmt.Println()
+	}
+
+	fmt.Println()
+	return nil
+}
+
+func runFXSet(cmd *cobra.Command, args []string) error {
+	var rateValue float64
+	if _, err := fmt.Sscanf(args[2], "%f", &rateValue); err != nil {
+		return fmt.Errorf("无效的汇率格式: %w", err)
+	}
+
+	date := fxDate
+	if date == "" {
+		date = time.Now().Format("2006-01-02")
+	}
+
+	rate := models.FXRate{
+		Date:  date,
+		Base:  strings.ToUpper(args[0]),
+		Quote: strings.ToUpper(args[1]),
+		Rate:  rateValue,
+	}
+	if err := getFXTable().SetRate(rate); err != nil {
+		printError(fmt.Sprintf("登记汇率失败: %v", err))
+		return err
+	}
+
+	fmt.Println()
+	printSuccess("汇率已登记")
+	printField("日期", rate.Date)
+	printHighlightField("汇率", fmt.Sprintf("1 %s = %g %s", rate.Base, rate.Rate, rate.Quote))
+	fmt.Println()
+
+	return nil
+}
+
+func runFXDelete(cmd *cobra.Command, args []string) error {
+	if err := getFXTable().DeleteRate(args[0], args[1], args[2]); err != nil {
+		return fmt.Errorf("删除汇率失败: %w", err)
+	}
+
+	fmt.Printf("\n✓ 汇率已删除: %s/%s %s\n", strings.ToUpper(args[0]), strings.ToUpper(args[1]), args[2])
+	return nil
+}
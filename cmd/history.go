@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+	"trading-journal-cli/internal/models"
+)
+
+var historyFormat string
+
+var historyCmd = &cobra.Command{
+	Use:   "history <positionID>",
+	Short: "查看仓位的变更历史",
+	Long: `按时间线列出仓位从开仓到平仓的每个版本：开仓、加仓、调整止损止盈、部分平仓、全部平仓和修改，
+以及每个版本与上一版本之间的字段变化。edit 修改显示修改人和修改原因。`,
+	Args: cobra.ExactArgs(1),
+	RunE: runHistory,
+}
+
+func init() {
+	historyCmd.Flags().StringVar(&historyFormat, "format", "table", "输出格式 (table, json)")
+
+	rootCmd.AddCommand(historyCmd)
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	if historyFormat != "table" && historyFormat != "json" {
+		return fmt.Errorf("invalid format: %s (must be table or json)", historyFormat)
+	}
+
+	events, err := ops.PositionHistory(args[0])
+	if err != nil {
+		return err
+	}
+
+	if historyFormat == "json" {
+		return outputReportJSON(events)
+	}
+
+	latest := events[len(events)-1].Position
+	printTitle(fmt.Sprintf("🕘 仓位历史 - %s", latest.PositionID))
+	printField("品种", fmt.Sprintf("%s (%s)", latest.Symbol, latest.MarketType))
+	printField("方向", latest.Direction)
+	printField("账户", latest.AccountName)
+	printField("状态", latest.Status)
+	printField("版本数", len(events))
+	fmt.Println()
+	printDivider()
+
+	editCount := 0
+	for _, event := range events {
+		// edit 命令本次新增的修改记录
+		var edit *models.EditRecord
+		if n := len(event.Position.Edits); event.Type == models.EventEdited && n > editCount {
+			edit = &event.Position.Edits[n-1]
+		}
+		editCount = len(event.Position.Edits)
+
+		fmt.Println()
+		fmt.Print("  ")
+		colorTitle.Printf("● %s  %s", event.Time.Local().Format("2006-01-02 15:04:05"), eventTypeLabel(event.Type))
+		colorMuted.Printf("  (操作ID %s)\n", event.ID)
+
+		if event.Type == models.EventOpened {
+			pos := event.Position
+			printHistoryLine(fmt.Sprintf("开仓价 %.4f  数量 %.4f  止损 %.4f  止盈 %.4f  保证金 %.2f",
+				pos.OpenPrice, pos.Quantity, pos.StopLoss, pos.TakeProfit, pos.Margin))
+			if pos.Reason != "" {
+				printHistoryLine("理由: " + truncateValue(pos.Reason))
+			}
+			continue
+		}
+
+		if event.Type == models.EventUndone {
+			undo := event.Position.Undos[len(event.Position.Undos)-1]
+			printHistoryLine(fmt.Sprintf("撤销 %s（%s）", undo.OperationID, eventTypeLabel(undo.Type)))
+		}
+
+		switch event.Type {
+		case models.EventVoided:
+			printHistoryLine("作废原因: " + truncateValue(event.Position.Voided.Reason))
+		case models.EventUnvoided:
+			if unvoid := event.Position.Unvoids[len(event.Position.Unvoids)-1]; unvoid.Reason != "" {
+				printHistoryLine("恢复原因: " + truncateValue(unvoid.Reason))
+			}
+		}
+
+		if edit != nil {
+			printHistoryLine(fmt.Sprintf("修改人 %s  原因: %s", displayChangeValue(edit.By), truncateValue(edit.Reason)))
+		}
+
+		for _, change := range event.Changes {
+			// 撤销和修改记录已在上面单独显示
+			if strings.HasPrefix(change.Field, "undos[") || strings.HasPrefix(change.Field, "edits[") {
+				continue
+			}
+			fmt.Print("      ")
+			colorMuted.Printf("%s: ", change.Field)
+			switch {
+			case change.Old == "":
+				colorHighlight.Printf("+ %s\n", truncateValue(change.New))
+			case change.New == "":
+				colorWarning.Printf("- %s\n", truncateValue(change.Old))
+			default:
+				fmt.Printf("%s → ", truncateValue(change.Old))
+				colorValue.Printf("%s\n", truncateValue(change.New))
+			}
+		}
+	}
+
+	fmt.Println()
+	printHint("使用 --format json 可查看每个版本的完整快照，使用 'trading-cli undo <操作ID>' 撤销操作")
+	fmt.Println()
+	return nil
+}
+
+// printHistoryLine 打印时间线事件下的一行说明
+func printHistoryLine(line string) {
+	fmt.Print("      ")
+	fmt.Println(line)
+}
+
+// eventTypeLabel 仓位事件类型的显示名称
+func eventTypeLabel(t models.EventType) string {
+	switch t {
+	case models.EventOpened:
+		return "开仓"
+	case models.EventAdded:
+		return "加仓"
+	case models.EventPartiallyClosed:
+		return "部分平仓"
+	case models.EventClosed:
+		return "平仓"
+	case models.EventAdjusted:
+		return "调整止损止盈"
+	case models.EventEdited:
+		return "修改"
+	case models.EventUndone:
+		return "撤销"
+	case models.EventVoided:
+		return "作废"
+	case models.EventUnvoided:
+		return "恢复"
+	default:
+		return string(t)
+	}
+}
+
+// truncateValue 截断过长的字段值，避免长文本占满时间线
+func truncateValue(value string) string {
+	const maxRunes = 60
+	if utf8.RuneCountInString(value) <= maxRunes {
+		return value
+	}
+	runes := []rune(value)
+	return string(runes[:maxRunes]) + "…"
+}
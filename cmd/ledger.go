@@ -0,0 +1,289 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/cobra"
+	"trading-journal-cli/internal/models"
+)
+
+var (
+	ledgerAccountName string
+	ledgerAmount      float64
+	ledgerNote        string
+	ledgerTime        string
+)
+
+var accountDepositCmd = &cobra.Command{
+	Use:   "deposit",
+	Short: "入金",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAccountCashFlow(cmd, models.LedgerDeposit)
+	},
+}
+
+var accountWithdrawCmd = &cobra.Command{
+	Use:   "withdraw",
+	Short: "出金",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAccountCashFlow(cmd, models.LedgerWithdrawal)
+	},
+}
+
+var accountLedgerCmd = &cobra.Command{
+	Use:   "ledger",
+	Short: "查看账户资金流水",
+	Long:  `按时间顺序列出账户的入金、出金、平仓盈亏、交易费用和余额调整流水，以及每笔流水后的余额。`,
+	RunE:  runAccountLedger,
+}
+
+var accountRebuildCmd = &cobra.Command{
+	Use:   "rebuild",
+	Short: "按交易记录重建账户余额",
+	Long: `核对账户的平仓盈亏和费用流水与当前交易记录是否一致。
+修改过去的交易后使用：不改写已有流水，为每个不一致的仓位追加一笔差额流水。`,
+	RunE: runAccountRebuild,
+}
+
+func init() {
+	for _, c := range []*cobra.Command{accountDepositCmd, accountWithdrawCmd} {
+		c.Flags().StringVar(&ledgerAccountName, "account", "", "账户名称")
+		c.Flags().Float64Var(&ledgerAmount, "amount", 0, "金额")
+		c.Flags().StringVar(&ledgerNote, "note", "", "备注")
+		c.Flags().StringVar(&ledgerTime, "time", "", "发生时间 (格式: 2006-01-02 15:04:05)，默认当前时间")
+		addYesFlag(c)
+	}
+	accountLedgerCmd.Flags().StringVar(&ledgerAccountName, "account", "", "账户名称")
+	accountRebuildCmd.Flags().StringVar(&ledgerAccountName, "account", "", "账户名称")
+
+	accountCmd.AddCommand(accountDepositCmd)
+	accountCmd.AddCommand(accountWithdrawCmd)
+	accountCmd.AddCommand(accountLedgerCmd)
+	accountCmd.AddCommand(accountRebuildCmd)
+}
+
+// selectAccount 按名称查找账户，未通过 --account 指定时提示选择
+func selectAccount(cmd *cobra.Command, accounts []models.Account, accountName, message string) (*models.Account, error) {
+	if len(accounts) == 0 {
+		return nil, fmt.Errorf("暂无账户，请先使用 'trading-cli account add' 添加账户")
+	}
+
+	if cmd.Flags().Changed("account") {
+		for i := range accounts {
+			if accounts[i].Name == accountName {
+				return &accounts[i], nil
+			}
+		}
+		return nil, fmt.Errorf("account not found: %s", accountName)
+	}
+
+	if _, err := needPrompt(cmd, "account", false); err != nil {
+		return nil, err
+	}
+
+	options := make([]string, len(accounts))
+	for i, acc := range accounts {
+		options[i] = fmt.Sprintf("%s (%.2f %s)", acc.Name, acc.Balance, acc.CurrencyCode())
+	}
+
+	var selectedIndex int
+	selectPrompt := &survey.Select{
+		Message: message,
+		Options: options,
+	}
+	if err := survey.AskOne(selectPrompt, &selectedIndex); err != nil {
+		return nil, err
+	}
+
+	return &accounts[selectedIndex], nil
+}
+
+// ledgerTypeLabel 流水类型的显示名称
+func ledgerTypeLabel(entryType models.LedgerEntryType) string {
+	switch entryType {
+	case models.LedgerDeposit:
+		return "入金"
+	case models.LedgerWithdrawal:
+		return "出金"
+	case models.LedgerRealizedPnL:
+		return "平仓盈亏"
+	case models.LedgerFee:
+		return "交易费用"
+	case models.LedgerAdjustment:
+		return "余额调整"
+	}
+	return string(entryType)
+}
+
+func runAccountCashFlow(cmd *cobra.Command, entryType models.LedgerEntryType) error {
+	label := ledgerTypeLabel(entryType)
+	printTitle(fmt.Sprintf("💰 %s", label))
+
+	account, err := selectAccount(cmd, getAccountManager().ListAccounts(), ledgerAccountName, "选择账户:")
+	if err != nil {
+		return err
+	}
+
+	// 金额
+	amount := ledgerAmount
+	if ask, err := needPrompt(cmd, "amount", false); err != nil {
+		return err
+	} else if ask {
+		var amountStr string
+		amountPrompt := &survey.Input{
+			Message: fmt.Sprintf("%s金额:", label),
+		}
+		if err := survey.AskOne(amountPrompt, &amountStr, survey.WithValidator(survey.Required)); err != nil {
+			return err
+		}
+		if _, err := fmt.Sscanf(amountStr, "%f", &amount); err != nil {
+			return fmt.Errorf("无效的金额格式: %w", err)
+		}
+	}
+
+	// 备注（可选）
+	note := ledgerNote
+	if ask, err := needPrompt(cmd, "note", true); err != nil {
+		return err
+	} else if ask {
+		notePrompt := &survey.Input{
+			Message: "备注 (可选):",
+		}
+		survey.AskOne(notePrompt, &note)
+	}
+
+	// 发生时间（可选，默认当前时间）
+	at := time.Now()
+	if ledgerTime != "" {
+		if at, err = parseLocalTime(ledgerTime); err != nil {
+			return err
+		}
+	}
+
+	var entry *models.LedgerEntry
+	if entryType == models.LedgerDeposit {
+		entry, err = ops.Deposit(account.Name, amount, at, note)
+	} else {
+		entry, err = ops.Withdraw(account.Name, amount, at, note)
+	}
+	if err != nil {
+		printError(fmt.Sprintf("%s失败: %v", label, err))
+		return err
+	}
+
+	// 重新读取流水汇总后的余额
+	updated, err := getAccountManager().GetAccount(account.Name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	printSuccess(fmt.Sprintf("%s已记录", label))
+	printHighlightField("账户", account.Name)
+	printField("金额", fmt.Sprintf("%+.2f %s", entry.Amount, account.CurrencyCode()))
+	printField("余额", fmt.Sprintf("%.2f -> %.2f %s", account.Balance, updated.Balance, account.CurrencyCode()))
+	fmt.Println()
+
+	return nil
+}
+
+func runAccountLedger(cmd *cobra.Command, args []string) error {
+	account, err := selectAccount(cmd, getAccountManager().ListAccounts(), ledgerAccountName, "选择账户:")
+	if err != nil {
+		return err
+	}
+
+	entries, err := ops.AccountLedger(account.Name)
+	if err != nil {
+		return fmt.Errorf("读取资金流水失败: %w", err)
+	}
+
+	printTitle(fmt.Sprintf("📒 资金流水 - %s", account.Name))
+
+	if len(entries) == 0 {
+		printWarning("暂无资金流水")
+		return nil
+	}
+
+	const (
+		colTime    = 19
+		colType    = 10
+		colAmount  = 14
+		colBalance = 14
+		colPosID   = 20
+	)
+
+	printTableHeader(
+		padRight("时间", colTime),
+		padRight("类型", colType),
+		padRight("金额", colAmount),
+		padRight("余额", colBalance),
+		padRight("仓位ID", colPosID),
+		"备注",
+	)
+
+	var balance float64
+	for _, entry := range entries {
+		balance += entry.Amount
+
+		positionID := entry.PositionID
+		if positionID == "" {
+			positionID = "-"
+		}
+
+		fmt.Print("  ")
+		fmt.Print(padRight(entry.Time.Local().Format("2006-01-02 15:04:05"), colTime))
+		colorMuted.Print(" │ ")
+		fmt.Print(padRight(ledgerTypeLabel(entry.Type), colType))
+		colorMuted.Print(" │ ")
+		printPnLCell(entry.Amount, colAmount)
+		colorMuted.Print(" │ ")
+		fmt.Print(padRight(fmt.Sprintf("%.2f", balance), colBalance))
+		colorMuted.Print(" │ ")
+		fmt.Print(padRight(positionID, colPosID))
+		colorMuted.Print(" │ ")
+		fmt.Print(entry.Note)
+		fmt.Println()
+	}
+
+	fmt.Println()
+	printHighlightField("当前余额", fmt.Sprintf("%.2f %s", balance, account.CurrencyCode()))
+	fmt.Println()
+
+	return nil
+}
+
+func runAccountRebuild(cmd *cobra.Command, args []string) error {
+	account, err := selectAccount(cmd, getAccountManager().ListAccounts(), ledgerAccountName, "选择要重建余额的账户:")
+	if err != nil {
+		return err
+	}
+
+	printTitle("🔁 重建账户余额")
+
+	corrections, err := ops.RebuildBalance(account.Name)
+	if err != nil {
+		printError(fmt.Sprintf("重建失败: %v", err))
+		return err
+	}
+
+	updated, err := getAccountManager().GetAccount(account.Name)
+	if err != nil {
+		return err
+	}
+
+	if len(corrections) == 0 {
+		printSuccess("资金流水与交易记录一致，无需更正")
+	} else {
+		printSuccess(fmt.Sprintf("已追加 %d 笔更正流水", len(corrections)))
+		for _, entry := range corrections {
+			printField(entry.PositionID, fmt.Sprintf("%s %+.2f", ledgerTypeLabel(entry.Type), entry.Amount))
+		}
+	}
+	printHighlightField("余额", fmt.Sprintf("%.2f -> %.2f %s", account.Balance, updated.Balance, account.CurrencyCode()))
+	fmt.Println()
+
+	return nil
+}
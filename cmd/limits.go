@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/cobra"
+	"trading-journal-cli/internal/models"
+	"trading-journal-cli/internal/validator"
+)
+
+var (
+	limitsAccountName      string
+	limitsMaxRisk          float64
+	limitsMaxOpenRisk      float64
+	limitsMaxPerSymbol     int
+	limitsMinRiskReward    float64
+	limitsMaxMarginPercent float64
+	limitsClear            bool
+)
+
+var accountLimitsCmd = &cobra.Command{
+	Use:   "limits",
+	Short: "设置账户风控限制",
+	Long: `设置开仓时检查的账户风控限制，值为 0 表示不限制。
+违反限制的开仓会被拒绝，可使用 open --override "理由" 强制开仓，理由会记录在仓位上。`,
+	RunE: runAccountLimits,
+}
+
+func init() {
+	accountLimitsCmd.Flags().StringVar(&limitsAccountName, "account", "", "账户名称")
+	accountLimitsCmd.Flags().Float64Var(&limitsMaxRisk, "max-risk", 0, "单笔风险占余额的最大百分比")
+	accountLimitsCmd.Flags().Float64Var(&limitsMaxOpenRisk, "max-open-risk", 0, "所有未平仓位风险合计占余额的最大百分比")
+	accountLimitsCmd.Flags().IntVar(&limitsMaxPerSymbol, "max-per-symbol", 0, "同一品种最多未平仓位数")
+	accountLimitsCmd.Flags().Float64Var(&limitsMinRiskReward, "min-rr", 0, "最低风险回报比")
+	accountLimitsCmd.Flags().Float64Var(&limitsMaxMarginPercent, "max-margin", 0, "保证金合计占余额的最大百分比")
+	accountLimitsCmd.Flags().BoolVar(&limitsClear, "clear", false, "清除所有限制")
+	addYesFlag(accountLimitsCmd)
+
+	accountCmd.AddCommand(accountLimitsCmd)
+}
+
+// riskLimitLabel 风控限制的显示名称
+func riskLimitLabel(err error) string {
+	switch {
+	case errors.Is(err, validator.ErrRiskPerTradeExceeded):
+		return "单笔风险"
+	case errors.Is(err, validator.ErrOpenRiskExceeded):
+		return "未平仓风险合计"
+	case errors.Is(err, validator.ErrSymbolPositionLimit):
+		return "同品种持仓数"
+	case errors.Is(err, validator.ErrRiskRewardTooLow):
+		return "风险回报比"
+	case errors.Is(err, validator.ErrMarginUsageExceeded):
+		return "保证金占用"
+	}
+	return err.Error()
+}
+
+// formatRiskLimitViolation 格式化单项风控限制违反
+func formatRiskLimitViolation(v validator.RiskLimitViolation) string {
+	switch {
+	case errors.Is(v.Err, validator.ErrSymbolPositionLimit):
+		return fmt.Sprintf("%s %d 超过上限 %d", riskLimitLabel(v.Err), int(v.Actual), int(v.Limit))
+	case errors.Is(v.Err, validator.ErrRiskRewardTooLow):
+		return fmt.Sprintf("%s 1:%.2f 低于下限 1:%.2f", riskLimitLabel(v.Err), v.Actual, v.Limit)
+	}
+	return fmt.Sprintf("%s %.2f%% 超过上限 %.2f%%", riskLimitLabel(v.Err), v.Actual, v.Limit)
+}
+
+// formatRiskLimits 格式化账户风控限制，未设置时返回空字符串
+func formatRiskLimits(limits *models.RiskLimits) string {
+	if limits.IsEmpty() {
+		return ""
+	}
+	var parts []string
+	if limits.MaxRiskPerTradePercent > 0 {
+		parts = append(parts, fmt.Sprintf("单笔风险 ≤ %g%%", limits.MaxRiskPerTradePercent))
+	}
+	if limits.MaxOpenRiskPercent > 0 {
+		parts = append(parts, fmt.Sprintf("总风险 ≤ %g%%", limits.MaxOpenRiskPercent))
+	}
+	if limits.MaxPositionsPerSymbol > 0 {
+		parts = append(parts, fmt.Sprintf("同品种 ≤ %d 笔", limits.MaxPositionsPerSymbol))
+	}
+	if limits.MinRiskReward > 0 {
+		parts = append(parts, fmt.Sprintf("风险回报比 ≥ 1:%g", limits.MinRiskReward))
+	}
+	if limits.MaxMarginUsagePercent > 0 {
+		parts = append(parts, fmt.Sprintf("保证金 ≤ %g%%", limits.MaxMarginUsagePercent))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// confirmRiskOverride 显示违反的风控限制，交互模式下询问是否强制开仓并输入理由
+// 返回空字符串表示不强制开仓
+func confirmRiskOverride(limitErr *validator.RiskLimitError) (string, error) {
+	fmt.Println()
+	printError("开仓违反账户风控限制")
+	for _, v := range limitErr.Violations {
+		printWarning(formatRiskLimitViolation(v))
+	}
+	fmt.Println()
+
+	if assumeYes || !stdinIsTerminal() {
+		printHint("使用 --override \"理由\" 强制开仓")
+		return "", nil
+	}
+
+	var override bool
+	overridePrompt := &survey.Confirm{
+		Message: "是否强制开仓?",
+		Default: false,
+	}
+	if err := survey.AskOne(overridePrompt, &override); err != nil {
+		return "", err
+	}
+	if !override {
+		return "", nil
+	}
+
+	var reason string
+	reasonPrompt := &survey.Input{
+		Message: "强制开仓理由:",
+	}
+	if err := survey.AskOne(reasonPrompt, &reason, survey.WithValidator(survey.Required)); err != nil {
+		return "", err
+	}
+	return reason, nil
+}
+
+func runAccountLimits(cmd *cobra.Command, args []string) error {
+	am := getAccountManager()
+	account, err := selectAccount(cmd, am.ListAccounts(), limitsAccountName, "选择要设置风控限制的账户:")
+	if err != nil {
+		return err
+	}
+
+	printTitle("🛡️  账户风控限制")
+
+	limits := &models.RiskLimits{}
+	if account.RiskLimits != nil {
+		*limits = *account.RiskLimits
+	}
+
+	if !limitsClear {
+		type floatLimit struct {
+			flag    string
+			message string
+			value   *float64
+			flagVal float64
+		}
+		for _, l := range []floatLimit{
+			{"max-risk", "单笔风险上限 (占余额 %，0 为不限制):", &limits.MaxRiskPerTradePercent, limitsMaxRisk},
+			{"max-open-risk", "未平仓风险合计上限 (占余额 %，0 为不限制):", &limits.MaxOpenRiskPercent, limitsMaxOpenRisk},
+			{"min-rr", "最低风险回报比 (0 为不限制):", &limits.MinRiskReward, limitsMinRiskReward},
+			{"max-margin", "保证金占用上限 (占余额 %，0 为不限制):", &limits.MaxMarginUsagePercent, limitsMaxMarginPercent},
+		} {
+			if cmd.Flags().Changed(l.flag) {
+				*l.value = l.flagVal
+			} else if ask, err := needPrompt(cmd, l.flag, true); err != nil {
+				return err
+			} else if ask {
+				if *l.value, err = promptFloat(l.message, fmt.Sprintf("%g", *l.value)); err != nil {
+					return err
+				}
+			}
+		}
+
+		if cmd.Flags().Changed("max-per-symbol") {
+			limits.MaxPositionsPerSymbol = limitsMaxPerSymbol
+		} else if ask, err := needPrompt(cmd, "max-per-symbol", true); err != nil {
+			return err
+		} else if ask {
+			value, err := promptFloat("同一品种最多未平仓位数 (0 为不限制):", fmt.Sprintf("%d", limits.MaxPositionsPerSymbol))
+			if err != nil {
+				return err
+			}
+			limits.MaxPositionsPerSymbol = int(value)
+		}
+
+		if limits.MaxRiskPerTradePercent < 0 || limits.MaxOpenRiskPercent < 0 || limits.MinRiskReward < 0 ||
+			limits.MaxMarginUsagePercent < 0 || limits.MaxPositionsPerSymbol < 0 {
+			return fmt.Errorf("风控限制不能为负数")
+		}
+	} else {
+		limits = nil
+	}
+
+	if err := am.UpdateAccountRiskLimits(account.Name, limits); err != nil {
+		printError(fmt.Sprintf("保存风控限制失败: %v", err))
+		return err
+	}
+
+	printSuccess("风控限制已更新")
+	printHighlightField("账户", account.Name)
+	if summary := formatRiskLimits(limits); summary != "" {
+		printField("限制", summary)
+	} else {
+		printField("限制", "不限制")
+	}
+	fmt.Println()
+
+	return nil
+}
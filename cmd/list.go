@@ -23,6 +23,9 @@ var (
 	listFromDate    string
 	listToDate      string
 	listFormat      string
+	listCurrency    string
+	listAsOf        string
+	listVoided      bool
 )
 
 var listCmd = &cobra.Command{
@@ -40,6 +43,9 @@ func init() {
 	listCmd.Flags().StringVar(&listFromDate, "from", "", "起始日期 (YYYY-MM-DD)")
 	listCmd.Flags().StringVar(&listToDate, "to", "", "结束日期 (YYYY-MM-DD)")
 	listCmd.Flags().StringVar(&listFormat, "format", "table", "输出格式 (table, json)")
+	listCmd.Flags().StringVar(&listCurrency, "currency", "", "合计使用的报告币种，默认为账户币种（多币种时为 USD）")
+	listCmd.Flags().StringVar(&listAsOf, "as-of", "", asOfFlagUsage)
+	listCmd.Flags().BoolVar(&listVoided, "include-voided", false, "包含已作废的仓位（不计入合计）")
 
 	rootCmd.AddCommand(listCmd)
 }
@@ -51,22 +57,15 @@ func runList(cmd *cobra.Command, args []string) error {
 		Symbol:      listSymbol,
 		MarketType:  listMarketType,
 		AccountName: listAccountName,
-	}
 
-	if listFromDate != "" {
-		t, err := time.Parse("2006-01-02", listFromDate)
-		if err != nil {
-			return fmt.Errorf("无效的起始日期格式: %w", err)
-		}
-		filter.FromDate = t
+		IncludeVoided: listVoided,
 	}
 
-	if listToDate != "" {
-		t, err := time.Parse("2006-01-02", listToDate)
-		if err != nil {
-			return fmt.Errorf("无效的结束日期格式: %w", err)
-		}
-		filter.ToDate = t
+	if err := parseFilterDates(&filter, listFromDate, listToDate); err != nil {
+		return err
+	}
+	if err := parseAsOf(&filter.AsOf, listAsOf); err != nil {
+		return err
 	}
 
 	// 查询仓位
@@ -84,7 +83,60 @@ func runList(cmd *cobra.Command, args []string) error {
 	if listFormat == "json" {
 		return outputJSON(positions)
 	}
-	return outputTable(positions)
+	return outputTable(positions, filter.AsOf)
+}
+
+// parseFilterDates 解析 YYYY-MM-DD 格式的日期范围到筛选参数
+func parseFilterDates(filter *operations.FilterParams, from, to string) error {
+	if from != "" {
+		t, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			return fmt.Errorf("无效的起始日期格式: %w", err)
+		}
+		filter.FromDate = t
+	}
+
+	if to != "" {
+		t, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			return fmt.Errorf("无效的结束日期格式: %w", err)
+		}
+		filter.ToDate = t
+	}
+
+	return nil
+}
+
+// asOfFlagUsage --as-of 参数说明
+const asOfFlagUsage = "查看过去某一时刻的状态 (格式: 2006-01-02 15:04:05，只写日期时为当天结束时)"
+
+// parseAsOf 解析 --as-of 时间，为空时保持零值（当前状态）
+// 只写日期时取当天结束时，便于查看某月末的状态
+func parseAsOf(target *time.Time, value string) error {
+	if value == "" {
+		return nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			*target = t
+			return nil
+		}
+	}
+	day, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return fmt.Errorf("无效的 --as-of 时间格式: %s", value)
+	}
+	*target = day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	return nil
+}
+
+// printAsOf 查看过去某一时刻的状态时在报告开头注明该时刻
+func printAsOf(at time.Time) {
+	if at.IsZero() {
+		return
+	}
+	printInfo(fmt.Sprintf("截至 %s 的状态（按版本历史重建）", at.Local().Format("2006-01-02 15:04:05")))
+	fmt.Println()
 }
 
 func outputJSON(positions []*models.Position) error {
@@ -102,7 +154,9 @@ func padRight(s string, width int) string {
 	return s + strings.Repeat(" ", width-w)
 }
 
-// calculateBalanceHistory 计算每个position的平仓后余额（按时间顺序累积）
+// calculateBalanceHistory 计算每个position的平仓后余额
+//
+// 余额取自账户资金曲线（包含入金、出金和费用），账户已不存在时按交易记录从开仓余额累积。
 func calculateBalanceHistory(positions []*models.Position) map[string]float64 {
 	result := make(map[string]float64)
 
@@ -120,8 +174,17 @@ func calculateBalanceHistory(positions []*models.Position) map[string]float64 {
 		accountGroups[pos.AccountName] = append(accountGroups[pos.AccountName], pos)
 	}
 
-	// 对每个账户，按平仓时间排序并累积计算余额
-	for _, accountPositions := range accountGroups {
+	for accountName, accountPositions := range accountGroups {
+		// 同一仓位的多笔流水取最后一笔之后的余额
+		if curve, err := ops.EquityCurve(operations.EquityParams{AccountName: accountName}); err == nil {
+			for _, point := range curve.Points {
+				if point.PositionID != "" {
+					result[point.PositionID] = point.Balance
+				}
+			}
+			continue
+		}
+
 		// 按平仓时间排序
 		sort.Slice(accountPositions, func(i, j int) bool {
 			return accountPositions[i].CloseTime.Before(*accountPositions[j].CloseTime)
@@ -134,7 +197,7 @@ func calculateBalanceHistory(positions []*models.Position) map[string]float64 {
 		}
 
 		for _, pos := range accountPositions {
-			runningBalance += *pos.RealizedPnL
+			runningBalance += pos.TotalRealizedPnL()
 			result[pos.PositionID] = runningBalance
 		}
 	}
@@ -142,8 +205,9 @@ func calculateBalanceHistory(positions []*models.Position) map[string]float64 {
 	return result
 }
 
-func outputTable(positions []*models.Position) error {
+func outputTable(positions []*models.Position, asOf time.Time) error {
 	printTitle("📊 交易记录")
+	printAsOf(asOf)
 
 	// 分离持仓和已平仓的记录
 	var openPositions, closedPositions []*models.Position
@@ -172,17 +236,34 @@ func outputTable(positions []*models.Position) error {
 	// 计算每个position的平仓后余额（按时间顺序累积）
 	balanceAfterClose := calculateBalanceHistory(closedPositions)
 
-	// 统计信息
-	var openCount, closedCount int
+	// 统计信息（盈亏按平仓时的汇率换算为报告币种后汇总，缺少汇率的记录显示 n/a，不计入合计）
+	converter := ops.NewCurrencyConverter(closedPositions, listCurrency)
+	var openCount, closedCount, voidedCount, convertedCount int
 	var totalPnL, totalPnLPercentage float64
+	unconverted := make(map[string]bool)
+	var convertErr error
 	for _, pos := range sortedPositions {
+		// 已作废的仓位只显示，不计入合计
+		if pos.Voided != nil {
+			voidedCount++
+			continue
+		}
 		if pos.Status == models.StatusOpen {
 			openCount++
 		} else {
 			closedCount++
-			if pos.RealizedPnL != nil {
-				totalPnL += *pos.RealizedPnL
+			closeTime := pos.OpenTime
+			if pos.CloseTime != nil {
+				closeTime = *pos.CloseTime
+			}
+			pnl, err := converter.ConvertPosition(pos.TotalRealizedPnL(), pos, closeTime)
+			if err != nil {
+				unconverted[pos.PositionID] = true
+				convertErr = err
+				continue
 			}
+			convertedCount++
+			totalPnL += pnl
 			if pos.PnLPercentage != nil {
 				totalPnLPercentage += *pos.PnLPercentage
 			}
@@ -190,17 +271,25 @@ func outputTable(positions []*models.Position) error {
 	}
 
 	// 显示统计
-	printInfo(fmt.Sprintf("总计: %d 条记录 | 持仓: %d | 已平仓: %d",
-		len(sortedPositions), openCount, closedCount))
-	if closedCount > 0 {
-		avgPnL := totalPnL / float64(closedCount)
-		avgPnLPct := totalPnLPercentage / float64(closedCount)
+	summary := fmt.Sprintf("总计: %d 条记录 | 持仓: %d | 已平仓: %d",
+		len(sortedPositions), openCount, closedCount)
+	if voidedCount > 0 {
+		summary += fmt.Sprintf(" | 已作废: %d", voidedCount)
+	}
+	printInfo(summary)
+	if convertedCount > 0 {
+		avgPnL := totalPnL / float64(convertedCount)
+		avgPnLPct := totalPnLPercentage / float64(convertedCount)
 		pnlSign := ""
 		if totalPnL > 0 {
 			pnlSign = "+"
 		}
-		printInfo(fmt.Sprintf("总盈亏: %s%.2f | 平均: %.2f (%.2f%%)",
-			pnlSign, totalPnL, avgPnL, avgPnLPct))
+		printInfo(fmt.Sprintf("总盈亏: %s%.2f %s | 平均: %.2f (%.2f%%)",
+			pnlSign, totalPnL, converter.Currency(), avgPnL, avgPnLPct))
+	}
+	if convertErr != nil {
+		printWarning(fmt.Sprintf("%d 条已平仓记录无法换算为 %s，未计入合计: %v（使用 'trading-cli fx set' 登记汇率）",
+			len(unconverted), converter.Currency(), convertErr))
 	}
 	fmt.Println()
 	printDivider()
@@ -216,6 +305,7 @@ func outputTable(positions []*models.Position) error {
 		colStatus   = 10
 		colMarket   = 12
 		colPnL      = 22
+		colR        = 8
 		colBalance  = 15
 	)
 
@@ -246,11 +336,13 @@ func outputTable(positions []*models.Position) error {
 	colorMuted.Print(" │ ")
 	colorTitle.Print(padRight("盈亏", colPnL))
 	colorMuted.Print(" │ ")
+	colorTitle.Print(padRight("R", colR))
+	colorMuted.Print(" │ ")
 	colorTitle.Print(padRight("平仓后余额", colBalance))
 	fmt.Println()
 
 	fmt.Print("  ")
-	colorMuted.Println(strings.Repeat("─", colPosID+colSymbol+colDir+colPrice+colQty+colStatus+colMarket+colPnL+colBalance+24))
+	colorMuted.Println(strings.Repeat("─", colPosID+colSymbol+colDir+colPrice+colQty+colStatus+colMarket+colPnL+colR+colBalance+27))
 
 	// 数据行
 	for _, pos := range sortedPositions {
@@ -299,7 +391,10 @@ func outputTable(positions []*models.Position) error {
 
 		// 状态（使用颜色）
 		statusText := "持仓中"
-		if pos.Status == models.StatusOpen {
+		if pos.Voided != nil {
+			statusText = "已作废"
+			colorMuted.Print(padRight(statusText, colStatus))
+		} else if pos.Status == models.StatusOpen {
 			colorYellow.Print(padRight(statusText, colStatus))
 		} else {
 			statusText = "已平仓"
@@ -319,16 +414,22 @@ func outputTable(positions []*models.Position) error {
 		}
 		colorMuted.Print(" │ ")
 
-		// 盈亏
-		if pos.Status == models.StatusClosed && pos.RealizedPnL != nil && pos.PnLPercentage != nil {
+		// 盈亏（累计所有成交，部分平仓的持仓也显示已实现部分）
+		if unconverted[pos.PositionID] {
+			colorYellow.Print(padRight("n/a", colPnL))
+		} else if len(pos.Fills) > 0 && pos.PnLPercentage != nil {
+			realizedPnL := pos.TotalRealizedPnL()
 			pnlSign := ""
-			if *pos.RealizedPnL > 0 {
+			if realizedPnL > 0 {
 				pnlSign = "+"
 			}
 			pnlStr := fmt.Sprintf("%s%.2f (%s%.2f%%)",
-				pnlSign, *pos.RealizedPnL, pnlSign, *pos.PnLPercentage)
+				pnlSign, realizedPnL, pnlSign, *pos.PnLPercentage)
+			if pos.Status == models.StatusOpen {
+				pnlStr = fmt.Sprintf("%s%.2f (部分)", pnlSign, realizedPnL)
+			}
 
-			if *pos.RealizedPnL > 0 {
+			if realizedPnL > 0 {
 				colorGreenBold.Print(padRight(pnlStr, colPnL))
 			} else {
 				colorRed.Print(padRight(pnlStr, colPnL))
@@ -338,8 +439,21 @@ func outputTable(positions []*models.Position) error {
 		}
 		colorMuted.Print(" │ ")
 
-		// 平仓后余额
-		if pos.Status == models.StatusClosed && pos.RealizedPnL != nil {
+		// R 倍数（净盈亏 / 初始风险）
+		if r, ok := ops.RMultiple(pos); ok && pos.Status == models.StatusClosed {
+			rStr := fmt.Sprintf("%+.2fR", r)
+			if r > 0 {
+				colorGreenBold.Print(padRight(rStr, colR))
+			} else {
+				colorRed.Print(padRight(rStr, colR))
+			}
+		} else {
+			colorMuted.Print(padRight("-", colR))
+		}
+		colorMuted.Print(" │ ")
+
+		// 平仓后余额（已作废的仓位盈亏已冲回，不显示）
+		if pos.Status == models.StatusClosed && pos.RealizedPnL != nil && pos.Voided == nil {
 			if balance, ok := balanceAfterClose[pos.PositionID]; ok {
 				balanceStr := fmt.Sprintf("%.2f", balance)
 				colorBlue.Print(padRight(balanceStr, colBalance))
@@ -1,26 +1,79 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
-	"time"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/spf13/cobra"
 	"trading-journal-cli/internal/models"
 	"trading-journal-cli/internal/operations"
+	"trading-journal-cli/internal/validator"
+)
+
+var (
+	openAccountName    string
+	openSymbol         string
+	openMarketType     string
+	openDirection      string
+	openPrice          float64
+	openQuantity       float64
+	openStopLoss       float64
+	openTakeProfit     float64
+	openMargin         float64
+	openReason         string
+	openTime           string
+	openMarketContext  string
+	openEMA20Broken    bool
+	openVolumeDecrease bool
+	openLowBreak       bool
+	openFees           models.Fees
+	openRiskPercent    float64
+	openRiskAmount     float64
+	openLeverage       float64
+	openStep           float64
+	openOverride       string
 )
 
 var openCmd = &cobra.Command{
 	Use:   "open",
 	Short: "开仓记录",
-	Long:  `通过交互式提示记录新的开仓信息`,
-	RunE:  runOpen,
+	Long: `通过交互式提示记录新的开仓信息。
+所有提示都有对应的参数，只有缺少的值才会提示输入；使用 --yes 可完全以非交互方式运行。`,
+	RunE: runOpen,
 }
 
 func init() {
+	openCmd.Flags().StringVar(&openAccountName, "account", "", "账户名称")
+	openCmd.Flags().StringVar(&openSymbol, "symbol", "", "交易品种")
+	openCmd.Flags().StringVar(&openMarketType, "market", "", "市场类型 (crypto, forex, gold, silver, futures, cn_stocks, us_stocks)")
+	openCmd.Flags().StringVar(&openDirection, "direction", "", "方向 (long, short)")
+	openCmd.Flags().Float64Var(&openPrice, "price", 0, "开仓价格")
+	openCmd.Flags().Float64Var(&openQuantity, "qty", 0, "仓位大小")
+	openCmd.Flags().Float64Var(&openStopLoss, "sl", 0, "止损价格")
+	openCmd.Flags().Float64Var(&openTakeProfit, "tp", 0, "止盈价格")
+	openCmd.Flags().Float64Var(&openMargin, "margin", 0, "保证金/成本")
+	openCmd.Flags().StringVar(&openReason, "reason", "", "交易理由")
+	openCmd.Flags().StringVar(&openTime, "time", "", "开仓时间 (格式: 2006-01-02 15:04:05)，默认当前时间")
+	openCmd.Flags().StringVar(&openMarketContext, "context", "", "市场背景 (bull, bear, range)")
+	openCmd.Flags().BoolVar(&openEMA20Broken, "ema20-broken", false, "牛市信号：日线跌破 EMA20 并反抽失败")
+	openCmd.Flags().BoolVar(&openVolumeDecrease, "volume-decrease", false, "牛市信号：创新高但成交量明显低于前高")
+	openCmd.Flags().BoolVar(&openLowBreak, "low-break", false, "牛市信号：连续两次回调都打穿前低")
+	addFeeFlags(openCmd, &openFees, false)
+	addSizingFlags(openCmd, &openRiskPercent, &openRiskAmount, &openLeverage, &openStep)
+	openCmd.Flags().StringVar(&openOverride, "override", "", "违反账户风控限制时强制开仓，并记录理由")
+	addYesFlag(openCmd)
+
 	rootCmd.AddCommand(openCmd)
 }
 
+// 市场背景参数取值与提示选项的对应关系
+var marketContextOptions = map[string]string{
+	"bull":  "牛市",
+	"bear":  "熊市",
+	"range": "震荡",
+}
+
 func runOpen(cmd *cobra.Command, args []string) error {
 	printTitle("📈 开仓记录")
 
@@ -36,22 +89,49 @@ func runOpen(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no accounts configured")
 	}
 
-	var selectedAccountIndex int
-	accountOptions := make([]string, len(accounts))
-	for i, acc := range accounts {
-		currency := acc.Currency
-		if currency == "" {
-			currency = "USD"
-		}
-		accountOptions[i] = fmt.Sprintf("%s (%.2f %s)", acc.Name, acc.Balance, currency)
+	// 校验选项类参数
+	marketTypeOptions := []string{"crypto", "forex", "gold", "silver", "futures", "cn_stocks", "us_stocks"}
+	directionOptions := []string{"long", "short"}
+	if err := checkOption(cmd, "market", openMarketType, marketTypeOptions); err != nil {
+		return err
 	}
-
-	accountPrompt := &survey.Select{
-		Message: "选择账户:",
-		Options: accountOptions,
+	if err := checkOption(cmd, "direction", openDirection, directionOptions); err != nil {
+		return err
 	}
-	if err := survey.AskOne(accountPrompt, &selectedAccountIndex); err != nil {
+	if err := checkOption(cmd, "context", openMarketContext, []string{"bull", "bear", "range"}); err != nil {
+		return err
+	}
+
+	selectedAccountIndex := -1
+	if cmd.Flags().Changed("account") {
+		for i, acc := range accounts {
+			if acc.Name == openAccountName {
+				selectedAccountIndex = i
+				break
+			}
+		}
+		if selectedAccountIndex < 0 {
+			return fmt.Errorf("account not found: %s", openAccountName)
+		}
+	} else if ask, err := needPrompt(cmd, "account", false); err != nil {
 		return err
+	} else if ask {
+		accountOptions := make([]string, len(accounts))
+		for i, acc := range accounts {
+			currency := acc.Currency
+			if currency == "" {
+				currency = "USD"
+			}
+			accountOptions[i] = fmt.Sprintf("%s (%.2f %s)", acc.Name, acc.Balance, currency)
+		}
+
+		accountPrompt := &survey.Select{
+			Message: "选择账户:",
+			Options: accountOptions,
+		}
+		if err := survey.AskOne(accountPrompt, &selectedAccountIndex); err != nil {
+			return err
+		}
 	}
 
 	selectedAccount := accounts[selectedAccountIndex]
@@ -74,150 +154,276 @@ func runOpen(cmd *cobra.Command, args []string) error {
 	if selectedAccount.Template != nil && selectedAccount.Template.DefaultSymbol != "" {
 		symbolDefault = selectedAccount.Template.DefaultSymbol
 	}
-	symbolPrompt := &survey.Input{
-		Message: "交易品种 (如 BTC/USDT):",
-		Default: symbolDefault,
+	params.Symbol = openSymbol
+	if !cmd.Flags().Changed("symbol") {
+		params.Symbol = symbolDefault
 	}
-	if err := survey.AskOne(symbolPrompt, &params.Symbol, survey.WithValidator(survey.Required)); err != nil {
+	if ask, err := needPrompt(cmd, "symbol", symbolDefault != ""); err != nil {
 		return err
+	} else if ask {
+		symbolPrompt := &survey.Input{
+			Message: "交易品种 (如 BTC/USDT):",
+			Default: symbolDefault,
+		}
+		if err := survey.AskOne(symbolPrompt, &params.Symbol, survey.WithValidator(survey.Required)); err != nil {
+			return err
+		}
 	}
 
-	// 市场类型
-	var marketTypeStr string
-	marketTypeOptions := []string{"crypto", "forex", "gold", "silver", "futures", "cn_stocks", "us_stocks"}
+	// 品种注册表中的配置（未登记时为 nil）
+	instrument, _ := getInstrumentRegistry().GetInstrument(params.Symbol)
+	if instrument != nil && instrument.Multiplier != 1 {
+		printInfo(fmt.Sprintf("%s 合约乘数: %g", instrument.Symbol, instrument.Multiplier))
+	}
+
+	// 市场类型（品种已登记时优先使用登记的市场类型，其次使用账户模板）
+	marketTypeStr := openMarketType
 	marketTypeDefault := 0
-	if selectedAccount.Template != nil && selectedAccount.Template.DefaultMarketType != "" {
+	hasMarketTypeDefault := false
+	defaultMarketType := ""
+	if instrument != nil && instrument.MarketType != "" {
+		defaultMarketType = string(instrument.MarketType)
+	} else if selectedAccount.Template != nil && selectedAccount.Template.DefaultMarketType != "" {
+		defaultMarketType = string(selectedAccount.Template.DefaultMarketType)
+	}
+	if defaultMarketType != "" {
 		// 找到默认值的索引
 		for i, opt := range marketTypeOptions {
-			if opt == string(selectedAccount.Template.DefaultMarketType) {
+			if opt == defaultMarketType {
 				marketTypeDefault = i
+				hasMarketTypeDefault = true
 				break
 			}
 		}
 	}
-	marketTypePrompt := &survey.Select{
-		Message: "市场类型:",
-		Options: marketTypeOptions,
-		Default: marketTypeDefault,
+	if !cmd.Flags().Changed("market") {
+		marketTypeStr = marketTypeOptions[marketTypeDefault]
 	}
-	if err := survey.AskOne(marketTypePrompt, &marketTypeStr); err != nil {
+	if ask, err := needPrompt(cmd, "market", hasMarketTypeDefault); err != nil {
 		return err
+	} else if ask {
+		marketTypePrompt := &survey.Select{
+			Message: "市场类型:",
+			Options: marketTypeOptions,
+			Default: marketTypeDefault,
+		}
+		if err := survey.AskOne(marketTypePrompt, &marketTypeStr); err != nil {
+			return err
+		}
 	}
 	params.MarketType = models.MarketType(marketTypeStr)
 
 	// 方向
-	var directionStr string
-	directionOptions := []string{"long", "short"}
+	directionStr := openDirection
 	directionDefault := 0
+	hasDirectionDefault := false
 	if selectedAccount.Template != nil && selectedAccount.Template.DefaultDirection != "" {
 		// 找到默认值的索引
 		for i, opt := range directionOptions {
 			if opt == string(selectedAccount.Template.DefaultDirection) {
 				directionDefault = i
+				hasDirectionDefault = true
 				break
 			}
 		}
 	}
-	directionPrompt := &survey.Select{
-		Message: "方向:",
-		Options: directionOptions,
-		Default: directionDefault,
+	if !cmd.Flags().Changed("direction") {
+		directionStr = directionOptions[directionDefault]
 	}
-	if err := survey.AskOne(directionPrompt, &directionStr); err != nil {
+	if ask, err := needPrompt(cmd, "direction", hasDirectionDefault); err != nil {
 		return err
+	} else if ask {
+		directionPrompt := &survey.Select{
+			Message: "方向:",
+			Options: directionOptions,
+			Default: directionDefault,
+		}
+		if err := survey.AskOne(directionPrompt, &directionStr); err != nil {
+			return err
+		}
 	}
 	params.Direction = models.Direction(directionStr)
 
 	// 开仓价格
-	var openPriceStr string
-	openPricePrompt := &survey.Input{
-		Message: "开仓价格:",
-	}
-	if err := survey.AskOne(openPricePrompt, &openPriceStr, survey.WithValidator(survey.Required)); err != nil {
-		return err
-	}
-	if _, err := fmt.Sscanf(openPriceStr, "%f", &params.OpenPrice); err != nil {
-		return fmt.Errorf("无效的价格格式: %w", err)
-	}
-
-	// 仓位大小
-	var quantityStr string
-	quantityPrompt := &survey.Input{
-		Message: "仓位大小:",
-	}
-	if err := survey.AskOne(quantityPrompt, &quantityStr, survey.WithValidator(survey.Required)); err != nil {
+	params.OpenPrice = openPrice
+	if ask, err := needPrompt(cmd, "price", false); err != nil {
 		return err
-	}
-	if _, err := fmt.Sscanf(quantityStr, "%f", &params.Quantity); err != nil {
-		return fmt.Errorf("无效的数量格式: %w", err)
+	} else if ask {
+		var openPriceStr string
+		openPricePrompt := &survey.Input{
+			Message: "开仓价格:",
+		}
+		if err := survey.AskOne(openPricePrompt, &openPriceStr, survey.WithValidator(survey.Required)); err != nil {
+			return err
+		}
+		if _, err := fmt.Sscanf(openPriceStr, "%f", &params.OpenPrice); err != nil {
+			return fmt.Errorf("无效的价格格式: %w", err)
+		}
 	}
 
 	// 止损价格
-	var stopLossStr string
-	stopLossPrompt := &survey.Input{
-		Message: "止损价格 (必填):",
-	}
-	if err := survey.AskOne(stopLossPrompt, &stopLossStr, survey.WithValidator(survey.Required)); err != nil {
+	params.StopLoss = openStopLoss
+	if ask, err := needPrompt(cmd, "sl", false); err != nil {
 		return err
-	}
-	if _, err := fmt.Sscanf(stopLossStr, "%f", &params.StopLoss); err != nil {
-		return fmt.Errorf("无效的止损价格格式: %w", err)
+	} else if ask {
+		var stopLossStr string
+		stopLossPrompt := &survey.Input{
+			Message: "止损价格 (必填):",
+		}
+		if err := survey.AskOne(stopLossPrompt, &stopLossStr, survey.WithValidator(survey.Required)); err != nil {
+			return err
+		}
+		if _, err := fmt.Sscanf(stopLossStr, "%f", &params.StopLoss); err != nil {
+			return fmt.Errorf("无效的止损价格格式: %w", err)
+		}
 	}
 
 	// 止盈价格
-	var takeProfitStr string
-	takeProfitPrompt := &survey.Input{
-		Message: "止盈价格 (必填):",
-	}
-	if err := survey.AskOne(takeProfitPrompt, &takeProfitStr, survey.WithValidator(survey.Required)); err != nil {
+	params.TakeProfit = openTakeProfit
+	if ask, err := needPrompt(cmd, "tp", false); err != nil {
 		return err
+	} else if ask {
+		var takeProfitStr string
+		takeProfitPrompt := &survey.Input{
+			Message: "止盈价格 (必填):",
+		}
+		if err := survey.AskOne(takeProfitPrompt, &takeProfitStr, survey.WithValidator(survey.Required)); err != nil {
+			return err
+		}
+		if _, err := fmt.Sscanf(takeProfitStr, "%f", &params.TakeProfit); err != nil {
+			return fmt.Errorf("无效的止盈价格格式: %w", err)
+		}
+	}
+
+	// 仓位大小（止损确定后才能按风险计算）
+	params.Quantity = openQuantity
+	var sized *operations.SizeResult
+	useSizing := !cmd.Flags().Changed("qty") && (cmd.Flags().Changed("risk") || cmd.Flags().Changed("risk-amount"))
+	if !useSizing {
+		if ask, err := needPrompt(cmd, "qty", false); err != nil {
+			return err
+		} else if ask {
+			sizingPrompt := &survey.Confirm{
+				Message: "按风险比例计算仓位大小?",
+				Default: false,
+			}
+			if err := survey.AskOne(sizingPrompt, &useSizing); err != nil {
+				return err
+			}
+			if !useSizing {
+				if params.Quantity, err = promptFloat("仓位大小:", ""); err != nil {
+					return err
+				}
+			}
+		}
 	}
-	if _, err := fmt.Sscanf(takeProfitStr, "%f", &params.TakeProfit); err != nil {
-		return fmt.Errorf("无效的止盈价格格式: %w", err)
+	if useSizing {
+		if err := promptRiskPercent(cmd, &openRiskPercent); err != nil {
+			return err
+		}
+		result, err := ops.CalculatePositionSize(operations.SizeParams{
+			AccountName:  params.AccountName,
+			Symbol:       params.Symbol,
+			OpenPrice:    params.OpenPrice,
+			StopLoss:     params.StopLoss,
+			TakeProfit:   params.TakeProfit,
+			RiskPercent:  openRiskPercent,
+			RiskAmount:   openRiskAmount,
+			Leverage:     openLeverage,
+			QuantityStep: openStep,
+		})
+		if err != nil {
+			return fmt.Errorf("仓位计算失败: %w", err)
+		}
+		sized = result
+		params.Quantity = sized.Quantity
+		printInfo(fmt.Sprintf("仓位大小 %g（风险 %.2f %s / %.2f%%）", sized.Quantity, sized.RiskAmount, sized.Currency, sized.RiskPercent))
+	}
+
+	// 价格不符合最小变动单位时提示（不阻止记录）
+	if instrument != nil {
+		for _, level := range []struct {
+			name  string
+			price float64
+		}{{"开仓价格", params.OpenPrice}, {"止损价格", params.StopLoss}, {"止盈价格", params.TakeProfit}} {
+			if !instrument.IsOnTick(level.price) {
+				printWarning(fmt.Sprintf("%s %.4f 不是最小变动单位 %g 的整数倍", level.name, level.price, instrument.TickSize))
+			}
+		}
 	}
 
-	// 保证金/成本
-	var marginStr string
-	marginPrompt := &survey.Input{
-		Message: "保证金/成本:",
+	// 保证金/成本（按风险计算仓位且指定了杠杆时默认为计算出的保证金）
+	params.Margin = openMargin
+	marginDefault := ""
+	if sized != nil && cmd.Flags().Changed("leverage") {
+		marginDefault = fmt.Sprintf("%.2f", sized.Margin)
+		if !cmd.Flags().Changed("margin") {
+			params.Margin = sized.Margin
+		}
 	}
-	if err := survey.AskOne(marginPrompt, &marginStr, survey.WithValidator(survey.Required)); err != nil {
+	if ask, err := needPrompt(cmd, "margin", marginDefault != ""); err != nil {
 		return err
+	} else if ask {
+		var marginStr string
+		marginPrompt := &survey.Input{
+			Message: "保证金/成本:",
+			Default: marginDefault,
+		}
+		if err := survey.AskOne(marginPrompt, &marginStr, survey.WithValidator(survey.Required)); err != nil {
+			return err
+		}
+		if _, err := fmt.Sscanf(marginStr, "%f", &params.Margin); err != nil {
+			return fmt.Errorf("无效的保证金格式: %w", err)
+		}
 	}
-	if _, err := fmt.Sscanf(marginStr, "%f", &params.Margin); err != nil {
-		return fmt.Errorf("无效的保证金格式: %w", err)
+
+	// 开仓费用（可选）
+	if err := promptFees(cmd, &openFees, false); err != nil {
+		return err
 	}
+	params.Fees = openFees
 
 	// 交易理由（可选）
-	reasonPrompt := &survey.Input{
-		Message: "交易理由 (可选):",
+	params.Reason = openReason
+	if ask, err := needPrompt(cmd, "reason", true); err != nil {
+		return err
+	} else if ask {
+		reasonPrompt := &survey.Input{
+			Message: "交易理由 (可选):",
+		}
+		survey.AskOne(reasonPrompt, &params.Reason)
 	}
-	survey.AskOne(reasonPrompt, &params.Reason)
 
 	fmt.Println()
 	printDivider()
 	fmt.Println()
 
 	// 市场背景判断
-	printInfo("市场背景判断（可选）")
-	var judgeMarket bool
-	judgeMarketPrompt := &survey.Confirm{
-		Message: "是否需要判断市场背景?",
-		Default: false,
-	}
-	if err := survey.AskOne(judgeMarketPrompt, &judgeMarket); err != nil {
+	judgeMarket := cmd.Flags().Changed("context")
+	if ask, err := needPrompt(cmd, "context", true); err != nil {
 		return err
+	} else if ask {
+		printInfo("市场背景判断（可选）")
+		judgeMarketPrompt := &survey.Confirm{
+			Message: "是否需要判断市场背景?",
+			Default: false,
+		}
+		if err := survey.AskOne(judgeMarketPrompt, &judgeMarket); err != nil {
+			return err
+		}
 	}
 
 	if judgeMarket {
 		// 询问市场类型
-		var marketContextStr string
-		marketContextPrompt := &survey.Select{
-			Message: "当前市场背景:",
-			Options: []string{"牛市", "熊市", "震荡"},
-		}
-		if err := survey.AskOne(marketContextPrompt, &marketContextStr); err != nil {
-			return err
+		marketContextStr := marketContextOptions[openMarketContext]
+		if !cmd.Flags().Changed("context") {
+			marketContextPrompt := &survey.Select{
+				Message: "当前市场背景:",
+				Options: []string{"牛市", "熊市", "震荡"},
+			}
+			if err := survey.AskOne(marketContextPrompt, &marketContextStr); err != nil {
+				return err
+			}
 		}
 
 		// 只有牛市才进行进一步判断
@@ -229,30 +435,42 @@ func runOpen(cmd *cobra.Command, args []string) error {
 			fmt.Println()
 
 			// ① 日线是否跌破 EMA20，并反抽失败
-			var ema20Broken bool
-			ema20Prompt := &survey.Confirm{
-				Message: "① 日线是否跌破 EMA20，并反抽失败?",
-				Default: false,
+			ema20Broken := openEMA20Broken
+			if ask, err := needPrompt(cmd, "ema20-broken", true); err != nil {
+				return err
+			} else if ask {
+				ema20Prompt := &survey.Confirm{
+					Message: "① 日线是否跌破 EMA20，并反抽失败?",
+					Default: false,
+				}
+				survey.AskOne(ema20Prompt, &ema20Broken)
 			}
-			survey.AskOne(ema20Prompt, &ema20Broken)
 			params.EMA20Broken = ema20Broken
 
 			// ② 创新高但成交量明显低于前高
-			var volumeDecrease bool
-			volumePrompt := &survey.Confirm{
-				Message: "② 创新高但成交量明显低于前高?",
-				Default: false,
+			volumeDecrease := openVolumeDecrease
+			if ask, err := needPrompt(cmd, "volume-decrease", true); err != nil {
+				return err
+			} else if ask {
+				volumePrompt := &survey.Confirm{
+					Message: "② 创新高但成交量明显低于前高?",
+					Default: false,
+				}
+				survey.AskOne(volumePrompt, &volumeDecrease)
 			}
-			survey.AskOne(volumePrompt, &volumeDecrease)
 			params.VolumeDecrease = volumeDecrease
 
 			// ③ 连续两次回调都打穿前低
-			var consecutiveLowBreak bool
-			lowBreakPrompt := &survey.Confirm{
-				Message: "③ 连续两次回调都打穿前低?",
-				Default: false,
+			consecutiveLowBreak := openLowBreak
+			if ask, err := needPrompt(cmd, "low-break", true); err != nil {
+				return err
+			} else if ask {
+				lowBreakPrompt := &survey.Confirm{
+					Message: "③ 连续两次回调都打穿前低?",
+					Default: false,
+				}
+				survey.AskOne(lowBreakPrompt, &consecutiveLowBreak)
 			}
-			survey.AskOne(lowBreakPrompt, &consecutiveLowBreak)
 			params.ConsecutiveLowBreak = consecutiveLowBreak
 
 			// 计算满足的条件数量
@@ -310,37 +528,57 @@ func runOpen(cmd *cobra.Command, args []string) error {
 	fmt.Println()
 
 	// 开仓时间（可选）
-	var useCurrentTime bool
-	timePrompt := &survey.Confirm{
-		Message: "使用当前时间?",
-		Default: true,
-	}
-	if err := survey.AskOne(timePrompt, &useCurrentTime); err != nil {
+	timeStr := openTime
+	if ask, err := needPrompt(cmd, "time", true); err != nil {
 		return err
-	}
-
-	if !useCurrentTime {
-		var timeStr string
-		customTimePrompt := &survey.Input{
-			Message: "开仓时间 (格式: 2006-01-02 15:04:05):",
+	} else if ask {
+		useCurrentTime := true
+		timePrompt := &survey.Confirm{
+			Message: "使用当前时间?",
+			Default: true,
 		}
-		if err := survey.AskOne(customTimePrompt, &timeStr); err != nil {
+		if err := survey.AskOne(timePrompt, &useCurrentTime); err != nil {
 			return err
 		}
-		if timeStr != "" {
-			// 使用 ParseInLocation 确保时间使用本地时区
-			t, err := time.ParseInLocation("2006-01-02 15:04:05", timeStr, time.Local)
-			if err != nil {
-				return fmt.Errorf("无效的时间格式: %w", err)
+
+		if !useCurrentTime {
+			customTimePrompt := &survey.Input{
+				Message: "开仓时间 (格式: 2006-01-02 15:04:05):",
+			}
+			if err := survey.AskOne(customTimePrompt, &timeStr); err != nil {
+				return err
 			}
-			params.OpenTime = &t
 		}
 	}
+	if timeStr != "" {
+		t, err := parseLocalTime(timeStr)
+		if err != nil {
+			return err
+		}
+		params.OpenTime = &t
+	}
 
 	// 执行开仓操作
+	params.RiskOverride = openOverride
 	pos, err := ops.OpenPosition(params)
+
+	// 违反账户风控限制：交互模式下可确认强制开仓并记录理由
+	var limitErr *validator.RiskLimitError
+	if errors.As(err, &limitErr) && params.RiskOverride == "" {
+		reason, promptErr := confirmRiskOverride(limitErr)
+		if promptErr != nil {
+			return promptErr
+		}
+		if reason != "" {
+			params.RiskOverride = reason
+			pos, err = ops.OpenPosition(params)
+		}
+	}
 	if err != nil {
 		printError(fmt.Sprintf("开仓失败: %v", err))
+		if errors.Is(err, validator.ErrAccountLocked) {
+			printHint("账户已熔断锁定，可使用 'trading-cli account unlock' 提前解锁（需填写理由）")
+		}
 		return err
 	}
 
@@ -356,9 +594,16 @@ func runOpen(cmd *cobra.Command, args []string) error {
 	printField("止损", fmt.Sprintf("%.4f", pos.StopLoss))
 	printField("止盈", fmt.Sprintf("%.4f", pos.TakeProfit))
 	printField("保证金", fmt.Sprintf("%.2f", pos.Margin))
+	if fees := pos.EntryFees(); fees != 0 {
+		printField("开仓费用", fmt.Sprintf("%.2f", fees))
+	}
 	if pos.Reason != "" {
 		printField("理由", pos.Reason)
 	}
+	if pos.RiskOverride != nil {
+		printWarning(fmt.Sprintf("已强制开仓（违反 %d 项风控限制）: %s",
+			len(pos.RiskOverride.Violations), pos.RiskOverride.Reason))
+	}
 
 	// 显示市场背景信息
 	if pos.MarketContext != "" && pos.MarketContext != models.MarketContextNone {
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+	"trading-journal-cli/internal/models"
+)
+
+// assumeYes 非交互模式：不显示任何提示，有默认值的参数直接使用默认值
+var assumeYes bool
+
+// addYesFlag 为命令添加 --yes 标志
+func addYesFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "不进行交互式提示：可选参数使用默认值，缺少必填参数时直接报错")
+}
+
+// stdinIsTerminal 标准输入是否为终端
+func stdinIsTerminal() bool {
+	fd := os.Stdin.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+// needPrompt 判断参数是否需要交互式输入
+//
+// 已通过 flag 设置时不提示；hasDefault 为 true 的可选参数在 --yes 模式或标准输入不是终端时直接使用默认值；
+// 必填参数在这两种情况下无法提示，返回错误
+func needPrompt(cmd *cobra.Command, flagName string, hasDefault bool) (bool, error) {
+	if cmd.Flags().Changed(flagName) {
+		return false, nil
+	}
+	if !assumeYes && stdinIsTerminal() {
+		return true, nil
+	}
+	if hasDefault {
+		return false, nil
+	}
+	return false, fmt.Errorf("缺少参数 --%s", flagName)
+}
+
+// checkOption 检查 flag 取值是否在可选范围内
+func checkOption(cmd *cobra.Command, flagName, value string, options []string) error {
+	if !cmd.Flags().Changed(flagName) {
+		return nil
+	}
+	for _, opt := range options {
+		if opt == value {
+			return nil
+		}
+	}
+	return fmt.Errorf("无效的 --%s 取值: %s（可选: %s）", flagName, value, strings.Join(options, ", "))
+}
+
+// parseLocalTime 解析本地时区的时间字符串 (格式: 2006-01-02 15:04:05)
+func parseLocalTime(timeStr string) (time.Time, error) {
+	// 使用 ParseInLocation 确保时间使用本地时区
+	t, err := time.ParseInLocation("2006-01-02 15:04:05", timeStr, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("无效的时间格式: %w", err)
+	}
+	return t, nil
+}
+
+// selectOpenPosition 按ID查找未平仓位，未指定ID时提示选择
+func selectOpenPosition(openPositions []*models.Position, positionID, message string) (*models.Position, error) {
+	if positionID != "" {
+		for _, pos := range openPositions {
+			if pos.PositionID == positionID {
+				return pos, nil
+			}
+		}
+		return nil, fmt.Errorf("未找到未平仓位: %s", positionID)
+	}
+
+	if assumeYes || !stdinIsTerminal() {
+		return nil, fmt.Errorf("缺少仓位ID")
+	}
+
+	options := make([]string, len(openPositions))
+	for i, pos := range openPositions {
+		options[i] = fmt.Sprintf("[%s] %s (%s) @ %.4f x %.4f",
+			pos.PositionID[:13]+"...", pos.Symbol, pos.Direction, pos.OpenPrice, pos.Quantity)
+	}
+
+	var selectedIndex int
+	selectPrompt := &survey.Select{
+		Message: message,
+		Options: options,
+	}
+	if err := survey.AskOne(selectPrompt, &selectedIndex); err != nil {
+		return nil, err
+	}
+
+	return openPositions[selectedIndex], nil
+}
+
+// addFeeFlags 为命令添加费用参数
+func addFeeFlags(cmd *cobra.Command, fees *models.Fees, withFunding bool) {
+	cmd.Flags().Float64Var(&fees.Commission, "commission", 0, "佣金")
+	cmd.Flags().Float64Var(&fees.ExchangeFee, "exchange-fee", 0, "交易所/监管费用")
+	if withFunding {
+		cmd.Flags().Float64Var(&fees.Funding, "funding", 0, "持仓期间累计资金费/隔夜利息（收入为负数）")
+	}
+}
+
+// promptFees 未通过参数提供费用时，询问是否录入费用
+func promptFees(cmd *cobra.Command, fees *models.Fees, withFunding bool) error {
+	for _, flagName := range []string{"commission", "exchange-fee", "funding"} {
+		if cmd.Flags().Changed(flagName) {
+			return nil
+		}
+	}
+
+	ask, err := needPrompt(cmd, "commission", true)
+	if err != nil || !ask {
+		return err
+	}
+
+	var hasFees bool
+	feesPrompt := &survey.Confirm{
+		Message: "是否录入手续费/资金费?",
+		Default: false,
+	}
+	if err := survey.AskOne(feesPrompt, &hasFees); err != nil {
+		return err
+	}
+	if !hasFees {
+		return nil
+	}
+
+	inputs := []struct {
+		message string
+		target  *float64
+	}{
+		{"佣金:", &fees.Commission},
+		{"交易所/监管费用:", &fees.ExchangeFee},
+	}
+	if withFunding {
+		inputs = append(inputs, struct {
+			message string
+			target  *float64
+		}{"累计资金费/隔夜利息 (收入为负数):", &fees.Funding})
+	}
+
+	for _, input := range inputs {
+		var valueStr string
+		prompt := &survey.Input{
+			Message: input.message,
+			Default: "0",
+		}
+		if err := survey.AskOne(prompt, &valueStr, survey.WithValidator(survey.Required)); err != nil {
+			return err
+		}
+		if _, err := fmt.Sscanf(valueStr, "%f", input.target); err != nil {
+			return fmt.Errorf("无效的费用格式: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// confirmAction 执行不可轻易恢复的操作前请求确认
+// --yes 模式直接确认；标准输入不是终端时无法确认，返回错误
+func confirmAction(message string) (bool, error) {
+	if assumeYes {
+		return true, nil
+	}
+	if !stdinIsTerminal() {
+		return false, fmt.Errorf("需要确认，非交互模式请使用 --yes")
+	}
+
+	var confirm bool
+	confirmPrompt := &survey.Confirm{
+		Message: message,
+		Default: false,
+	}
+	if err := survey.AskOne(confirmPrompt, &confirm); err != nil {
+		return false, err
+	}
+	return confirm, nil
+}
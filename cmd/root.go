@@ -12,8 +12,9 @@ import (
 )
 
 var (
-	dataDir string
-	ops     *operations.Operations
+	dataDir     string
+	storageType string
+	ops         *operations.Operations
 )
 
 // rootCmd 根命令
@@ -34,14 +35,32 @@ func Execute() {
 func init() {
 	// 全局标志
 	rootCmd.PersistentFlags().StringVarP(&dataDir, "data-dir", "d", "./trading-data", "数据目录")
+	rootCmd.PersistentFlags().StringVar(&storageType, "storage", "jsonl", "交易记录存储方式 (jsonl, sqlite)")
 
 	// 初始化操作实例
 	cobra.OnInitialize(initOperations)
 }
 
 func initOperations() {
-	store := storage.NewJSONLStorage(dataDir)
+	store, err := openStorage()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 	valid := validator.NewPositionValidator()
 	accountMgr := models.NewAccountManager(dataDir)
-	ops = operations.NewOperations(store, valid, accountMgr)
+	instruments := getInstrumentRegistry()
+	fx := getFXTable()
+	ops = operations.NewOperations(store, valid, accountMgr, instruments, fx)
+}
+
+// openStorage 按 --storage 打开交易记录存储
+func openStorage() (storage.Storage, error) {
+	switch storageType {
+	case "jsonl", "":
+		return storage.NewJSONLStorage(dataDir), nil
+	case "sqlite":
+		return storage.NewSQLiteStorage(dataDir)
+	}
+	return nil, fmt.Errorf("unsupported storage type: %s (expected jsonl or sqlite)", storageType)
 }
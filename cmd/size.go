@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/cobra"
+	"trading-journal-cli/internal/operations"
+)
+
+var (
+	sizeAccountName string
+	sizeSymbol      string
+	sizePrice       float64
+	sizeStopLoss    float64
+	sizeTakeProfit  float64
+	sizeRiskPercent float64
+	sizeRiskAmount  float64
+	sizeLeverage    float64
+	sizeStep        float64
+)
+
+var sizeCmd = &cobra.Command{
+	Use:   "size",
+	Short: "按风险计算仓位大小",
+	Long: `根据账户余额、开仓价和止损距离，按风险比例（--risk）或固定风险金额（--risk-amount）计算仓位数量。
+品种已在注册表中登记时按合约乘数换算。同时给出指定杠杆下所需的保证金，以及相对止盈的风险回报比。`,
+	RunE: runSize,
+}
+
+func init() {
+	sizeCmd.Flags().StringVar(&sizeAccountName, "account", "", "账户名称")
+	sizeCmd.Flags().StringVar(&sizeSymbol, "symbol", "", "交易品种（用于查询合约乘数，可选）")
+	sizeCmd.Flags().Float64Var(&sizePrice, "price", 0, "开仓价格")
+	sizeCmd.Flags().Float64Var(&sizeStopLoss, "sl", 0, "止损价格")
+	sizeCmd.Flags().Float64Var(&sizeTakeProfit, "tp", 0, "止盈价格（可选）")
+	addSizingFlags(sizeCmd, &sizeRiskPercent, &sizeRiskAmount, &sizeLeverage, &sizeStep)
+	addYesFlag(sizeCmd)
+
+	rootCmd.AddCommand(sizeCmd)
+}
+
+// addSizingFlags 添加仓位计算参数
+func addSizingFlags(cmd *cobra.Command, riskPercent, riskAmount, leverage, step *float64) {
+	cmd.Flags().Float64Var(riskPercent, "risk", 0, "单笔风险占账户余额的百分比")
+	cmd.Flags().Float64Var(riskAmount, "risk-amount", 0, "单笔固定风险金额（与 --risk 二选一）")
+	cmd.Flags().Float64Var(leverage, "leverage", 0, "杠杆倍数，用于计算保证金，默认 1")
+	cmd.Flags().Float64Var(step, "step", 0, "数量最小单位，计算结果向下取整（如 0.001）")
+}
+
+// promptFloat 提示输入一个数字
+func promptFloat(message, defaultValue string) (float64, error) {
+	var valueStr string
+	prompt := &survey.Input{
+		Message: message,
+		Default: defaultValue,
+	}
+	if err := survey.AskOne(prompt, &valueStr, survey.WithValidator(survey.Required)); err != nil {
+		return 0, err
+	}
+	var value float64
+	if _, err := fmt.Sscanf(valueStr, "%f", &value); err != nil {
+		return 0, fmt.Errorf("无效的数字格式: %w", err)
+	}
+	return value, nil
+}
+
+// promptRiskPercent 未通过 --risk 或 --risk-amount 指定风险时提示输入风险比例
+func promptRiskPercent(cmd *cobra.Command, riskPercent *float64) error {
+	if cmd.Flags().Changed("risk-amount") {
+		return nil
+	}
+	if ask, err := needPrompt(cmd, "risk", false); err != nil {
+		return err
+	} else if ask {
+		value, err := promptFloat("单笔风险 (占账户余额 %):", "1")
+		if err != nil {
+			return err
+		}
+		*riskPercent = value
+	}
+	return nil
+}
+
+func runSize(cmd *cobra.Command, args []string) error {
+	printTitle("📐 仓位计算")
+
+	account, err := selectAccount(cmd, getAccountManager().ListAccounts(), sizeAccountName, "选择账户:")
+	if err != nil {
+		return err
+	}
+
+	params := operations.SizeParams{
+		AccountName:  account.Name,
+		Symbol:       sizeSymbol,
+		OpenPrice:    sizePrice,
+		StopLoss:     sizeStopLoss,
+		TakeProfit:   sizeTakeProfit,
+		RiskPercent:  sizeRiskPercent,
+		RiskAmount:   sizeRiskAmount,
+		Leverage:     sizeLeverage,
+		QuantityStep: sizeStep,
+	}
+
+	// 品种（可选，仅用于查询合约乘数）
+	if ask, err := needPrompt(cmd, "symbol", true); err != nil {
+		return err
+	} else if ask {
+		symbolPrompt := &survey.Input{
+			Message: "交易品种 (可选，用于合约乘数):",
+		}
+		survey.AskOne(symbolPrompt, &params.Symbol)
+	}
+
+	if ask, err := needPrompt(cmd, "price", false); err != nil {
+		return err
+	} else if ask {
+		if params.OpenPrice, err = promptFloat("开仓价格:", ""); err != nil {
+			return err
+		}
+	}
+
+	if ask, err := needPrompt(cmd, "sl", false); err != nil {
+		return err
+	} else if ask {
+		if params.StopLoss, err = promptFloat("止损价格:", ""); err != nil {
+			return err
+		}
+	}
+
+	if ask, err := needPrompt(cmd, "tp", true); err != nil {
+		return err
+	} else if ask {
+		if params.TakeProfit, err = promptFloat("止盈价格 (0 表示不设置):", "0"); err != nil {
+			return err
+		}
+	}
+
+	if err := promptRiskPercent(cmd, &params.RiskPercent); err != nil {
+		return err
+	}
+
+	result, err := ops.CalculatePositionSize(params)
+	if err != nil {
+		printError(fmt.Sprintf("计算失败: %v", err))
+		return err
+	}
+
+	fmt.Println()
+	outputSizeResult(result)
+	fmt.Println()
+	printDivider()
+	printHint(fmt.Sprintf("开仓时可使用 --qty %g，或在 open 命令中直接使用 --risk / --risk-amount", result.Quantity))
+	fmt.Println()
+
+	return nil
+}
+
+// outputSizeResult 输出仓位计算结果
+func outputSizeResult(result *operations.SizeResult) {
+	printField("账户", fmt.Sprintf("%s (%.2f %s)", result.AccountName, result.Balance, result.Currency))
+	printField("方向", string(result.Direction))
+	printField("止损距离", fmt.Sprintf("%g", result.StopDistance))
+	if result.PointValue != 1 {
+		printField("合约乘数", fmt.Sprintf("%g", result.PointValue))
+	}
+	printField("风险预算", fmt.Sprintf("%.2f %s", result.RiskBudget, result.Currency))
+	printHighlightField("仓位数量", fmt.Sprintf("%g", result.Quantity))
+	printField("实际风险", fmt.Sprintf("%.2f %s (%.2f%%)", result.RiskAmount, result.Currency, result.RiskPercent))
+	printField("名义价值", fmt.Sprintf("%.2f", result.Notional))
+	printField("所需保证金", fmt.Sprintf("%.2f (杠杆 %gx)", result.Margin, result.Leverage))
+	if result.RewardAmount > 0 {
+		printField("潜在盈利", fmt.Sprintf("%.2f %s", result.RewardAmount, result.Currency))
+		printField("风险回报比", fmt.Sprintf("1:%.2f", result.RiskRewardRatio))
+	}
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"trading-journal-cli/internal/storage"
+)
+
+var (
+	importReplace   bool
+	exportOutDir    string
+	exportOverwrite bool
+)
+
+var importJSONLCmd = &cobra.Command{
+	Use:   "import-jsonl",
+	Short: "将 JSONL 交易记录导入 SQLite",
+	Long: `将数据目录下所有 trades-YYYY-MM.jsonl 中的每个版本按原有顺序导入 SQLite 数据库 (trades.db)，
+compact 归档的旧版本 (history-YYYY-MM.jsonl) 在同月交易文件之前导入，保留完整的追加历史。导入后使用 --storage sqlite 运行其他命令。
+数据库已有数据时需要 --replace 清空后重新导入。JSONL 文件不会被修改。`,
+	RunE: runImportJSONL,
+}
+
+var exportJSONLCmd = &cobra.Command{
+	Use:   "export-jsonl",
+	Short: "将 SQLite 交易记录导出为 JSONL",
+	Long: `将 SQLite 数据库 (trades.db) 中的所有版本按写入顺序导出为 trades-YYYY-MM.jsonl（按开仓月份分文件）。
+默认导出到数据目录，目标目录已有交易记录文件时需要 --overwrite，被覆盖的交易文件和归档文件备份为带时间戳的 .backup。`,
+	RunE: runExportJSONL,
+}
+
+func init() {
+	importJSONLCmd.Flags().BoolVar(&importReplace, "replace", false, "清空数据库中已有的交易记录后再导入")
+
+	exportJSONLCmd.Flags().StringVar(&exportOutDir, "out", "", "导出目录，默认为数据目录")
+	exportJSONLCmd.Flags().BoolVar(&exportOverwrite, "overwrite", false, "覆盖目标目录中已有的交易记录文件")
+
+	rootCmd.AddCommand(importJSONLCmd)
+	rootCmd.AddCommand(exportJSONLCmd)
+}
+
+// outputTransferResult 输出导入导出统计
+func outputTransferResult(result *storage.TransferResult) {
+	printField("文件", fmt.Sprintf("%d 个", len(result.Files)))
+	printField("仓位", fmt.Sprintf("%d 笔", result.Positions))
+	printField("版本", fmt.Sprintf("%d 条", result.Versions))
+	if result.Skipped > 0 {
+		printWarning(fmt.Sprintf("跳过 %d 行无法解析的记录", result.Skipped))
+	}
+}
+
+func runImportJSONL(cmd *cobra.Command, args []string) error {
+	printTitle("📥 导入 JSONL 到 SQLite")
+
+	db, err := storage.NewSQLiteStorage(dataDir)
+	if err != nil {
+		printError(fmt.Sprintf("打开数据库失败: %v", err))
+		return err
+	}
+	defer db.Close()
+
+	result, err := db.ImportJSONL(dataDir, importReplace)
+	if err != nil {
+		printError(fmt.Sprintf("导入失败: %v", err))
+		if !importReplace {
+			printHint("使用 --replace 清空数据库后重新导入")
+		}
+		return err
+	}
+
+	printSuccess("导入完成")
+	printHighlightField("数据库", db.Path())
+	outputTransferResult(result)
+	fmt.Println()
+	printHint("使用 --storage sqlite 运行其他命令")
+	fmt.Println()
+
+	return nil
+}
+
+func runExportJSONL(cmd *cobra.Command, args []string) error {
+	printTitle("📤 导出 SQLite 到 JSONL")
+
+	outDir := exportOutDir
+	if outDir == "" {
+		outDir = dataDir
+	}
+
+	db, err := storage.NewSQLiteStorage(dataDir)
+	if err != nil {
+		printError(fmt.Sprintf("打开数据库失败: %v", err))
+		return err
+	}
+	defer db.Close()
+
+	result, err := db.ExportJSONL(outDir, exportOverwrite)
+	if err != nil {
+		printError(fmt.Sprintf("导出失败: %v", err))
+		if !exportOverwrite {
+			printHint("使用 --overwrite 覆盖已有文件，或使用 --out 指定其他目录")
+		}
+		return err
+	}
+
+	printSuccess("导出完成")
+	printHighlightField("目录", outDir)
+	outputTransferResult(result)
+	fmt.Println()
+
+	return nil
+}
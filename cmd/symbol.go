@@ -0,0 +1,258 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/cobra"
+	"trading-journal-cli/internal/models"
+)
+
+var (
+	symbolMarketType    string
+	symbolMultiplier    float64
+	symbolTickSize      float64
+	symbolQuoteCurrency string
+)
+
+var symbolCmd = &cobra.Command{
+	Use:   "symbol",
+	Short: "品种配置",
+	Long: `管理品种注册表（数据目录下的 instruments.json）。
+登记合约乘数/每点价值后，平仓盈亏和风险分析中的最大可能损失会按乘数换算，
+例如 ES 期货每点 50 美元、外汇标准手 100000、美股期权每张 100 股。未登记的品种按乘数 1 计算。`,
+}
+
+var symbolListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出已登记的品种",
+	RunE:  runSymbolList,
+}
+
+var symbolSetCmd = &cobra.Command{
+	Use:   "set <symbol>",
+	Short: "添加或更新品种配置",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runSymbolSet,
+}
+
+var symbolDeleteCmd = &cobra.Command{
+	Use:   "delete <symbol>",
+	Short: "删除品种配置",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runSymbolDelete,
+}
+
+func init() {
+	symbolSetCmd.Flags().StringVar(&symbolMarketType, "market", "", "市场类型 (crypto, forex, gold, silver, futures, cn_stocks, us_stocks)")
+	symbolSetCmd.Flags().Float64Var(&symbolMultiplier, "multiplier", 0, "合约乘数/每点价值")
+	symbolSetCmd.Flags().Float64Var(&symbolTickSize, "tick", 0, "最小价格变动单位（可选）")
+	symbolSetCmd.Flags().StringVar(&symbolQuoteCurrency, "currency", "", "报价币种（可选）")
+	addYesFlag(symbolSetCmd)
+
+	symbolCmd.AddCommand(symbolListCmd)
+	symbolCmd.AddCommand(symbolSetCmd)
+	symbolCmd.AddCommand(symbolDeleteCmd)
+	rootCmd.AddCommand(symbolCmd)
+}
+
+func getInstrumentRegistry() *models.InstrumentRegistry {
+	registry := models.NewInstrumentRegistry(dataDir)
+	if err := registry.Load(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	return registry
+}
+
+func runSymbolList(cmd *cobra.Command, args []string) error {
+	registry := getInstrumentRegistry()
+	instruments := registry.ListInstruments()
+
+	printTitle("📐 品种配置")
+
+	if len(instruments) == 0 {
+		printWarning("暂无品种配置，所有品种按乘数 1 计算")
+		printHint("使用 'trading-cli symbol set <symbol>' 登记品种")
+		return nil
+	}
+
+	const (
+		colSymbol     = 14
+		colMarket     = 12
+		colMultiplier = 14
+		colTick       = 12
+		colCurrency   = 8
+	)
+
+	printTableHeader(
+		padRight("品种", colSymbol),
+		padRight("市场类型", colMarket),
+		padRight("合约乘数", colMultiplier),
+		padRight("最小变动", colTick),
+		padRight("报价币种", colCurrency),
+	)
+
+	for _, inst := range instruments {
+		tick := "-"
+		if inst.TickSize > 0 {
+			tick = fmt.Sprintf("%g", inst.TickSize)
+		}
+		market := "-"
+		if inst.MarketType != "" {
+			market = string(inst.MarketType)
+		}
+		currency := "-"
+		if inst.QuoteCurrency != "" {
+			currency = inst.QuoteCurrency
+		}
+
+		fmt.Print("  ")
+		colorHighlight.Print(padRight(inst.Symbol, colSymbol))
+		colorMuted.Print(" │ ")
+		fmt.Print(padRight(market, colMarket))
+		colorMuted.Print(" │ ")
+		fmt.Print(padRight(fmt.Sprintf("%g", inst.Multiplier), colMultiplier))
+		colorMuted.Print(" │ ")
+		fmt.Print(padRight(tick, colTick))
+		colorMuted.Print(" │ ")
+		fmt.Print(padRight(currency, colCurrency))
+		fmt.Println()
+	}
+
+	fmt.Println()
+	return nil
+}
+
+func runSymbolSet(cmd *cobra.Command, args []string) error {
+	registry := getInstrumentRegistry()
+	symbol := args[0]
+
+	printTitle("📐 品种配置")
+
+	// 已登记的品种以当前配置为默认值
+	instrument := models.Instrument{Symbol: symbol, Multiplier: 1}
+	existing, err := registry.GetInstrument(symbol)
+	if err == nil {
+		instrument = *existing
+		printInfo(fmt.Sprintf("更新已登记的品种 %s", symbol))
+		fmt.Println()
+	}
+
+	// 市场类型
+	marketTypeOptions := []string{"crypto", "forex", "gold", "silver", "futures", "cn_stocks", "us_stocks"}
+	if err := checkOption(cmd, "market", symbolMarketType, marketTypeOptions); err != nil {
+		return err
+	}
+	if cmd.Flags().Changed("market") {
+		instrument.MarketType = models.MarketType(symbolMarketType)
+	}
+	if ask, err := needPrompt(cmd, "market", true); err != nil {
+		return err
+	} else if ask {
+		marketTypeStr := string(instrument.MarketType)
+		if marketTypeStr == "" {
+			marketTypeStr = marketTypeOptions[0]
+		}
+		marketTypePrompt := &survey.Select{
+			Message: "市场类型:",
+			Options: marketTypeOptions,
+			Default: marketTypeStr,
+		}
+		if err := survey.AskOne(marketTypePrompt, &marketTypeStr); err != nil {
+			return err
+		}
+		instrument.MarketType = models.MarketType(marketTypeStr)
+	}
+
+	// 合约乘数
+	if cmd.Flags().Changed("multiplier") {
+		instrument.Multiplier = symbolMultiplier
+	}
+	if ask, err := needPrompt(cmd, "multiplier", existing != nil); err != nil {
+		return err
+	} else if ask {
+		var multiplierStr string
+		multiplierPrompt := &survey.Input{
+			Message: "合约乘数/每点价值 (如 ES 为 50，外汇标准手为 100000):",
+			Default: fmt.Sprintf("%g", instrument.Multiplier),
+		}
+		if err := survey.AskOne(multiplierPrompt, &multiplierStr, survey.WithValidator(survey.Required)); err != nil {
+			return err
+		}
+		if _, err := fmt.Sscanf(multiplierStr, "%f", &instrument.Multiplier); err != nil {
+			return fmt.Errorf("无效的乘数格式: %w", err)
+		}
+	}
+
+	// 最小价格变动单位（可选）
+	if cmd.Flags().Changed("tick") {
+		instrument.TickSize = symbolTickSize
+	}
+	if ask, err := needPrompt(cmd, "tick", true); err != nil {
+		return err
+	} else if ask {
+		var tickStr string
+		tickPrompt := &survey.Input{
+			Message: "最小价格变动单位 (可选，0 表示不限制):",
+			Default: fmt.Sprintf("%g", instrument.TickSize),
+		}
+		if err := survey.AskOne(tickPrompt, &tickStr); err != nil {
+			return err
+		}
+		if tickStr != "" {
+			if _, err := fmt.Sscanf(tickStr, "%f", &instrument.TickSize); err != nil {
+				return fmt.Errorf("无效的最小变动单位格式: %w", err)
+			}
+		}
+	}
+
+	// 报价币种（可选）
+	if cmd.Flags().Changed("currency") {
+		instrument.QuoteCurrency = symbolQuoteCurrency
+	}
+	if ask, err := needPrompt(cmd, "currency", true); err != nil {
+		return err
+	} else if ask {
+		currencyPrompt := &survey.Input{
+			Message: "报价币种 (可选，如 USD):",
+			Default: instrument.QuoteCurrency,
+		}
+		survey.AskOne(currencyPrompt, &instrument.QuoteCurrency)
+	}
+
+	if err := registry.SetInstrument(instrument); err != nil {
+		printError(fmt.Sprintf("保存品种配置失败: %v", err))
+		return err
+	}
+
+	fmt.Println()
+	printSuccess("品种配置已保存")
+	printHighlightField("品种", instrument.Symbol)
+	if instrument.MarketType != "" {
+		printField("市场类型", instrument.MarketType)
+	}
+	printField("合约乘数", fmt.Sprintf("%g", instrument.Multiplier))
+	if instrument.TickSize > 0 {
+		printField("最小变动", fmt.Sprintf("%g", instrument.TickSize))
+	}
+	if instrument.QuoteCurrency != "" {
+		printField("报价币种", instrument.QuoteCurrency)
+	}
+	fmt.Println()
+	printHint("新开仓位会记录当前乘数；修改乘数不影响已记录乘数的仓位")
+	fmt.Println()
+
+	return nil
+}
+
+func runSymbolDelete(cmd *cobra.Command, args []string) error {
+	registry := getInstrumentRegistry()
+
+	if err := registry.DeleteInstrument(args[0]); err != nil {
+		return fmt.Errorf("删除品种配置失败: %w", err)
+	}
+
+	fmt.Printf("\n✓ 品种配置已删除: %s\n", args[0])
+	return nil
+}
@@ -75,6 +75,19 @@ func printHighlightField(label string, value interface{}) {
 	colorHighlight.Printf("%v\n", value)
 }
 
+// 打印盈亏字段（盈利绿色，亏损红色）
+func printPnLField(label string, pnl, pnlPercentage float64) {
+	pnlColor := color.New(color.FgRed)
+	pnlSign := ""
+	if pnl > 0 {
+		pnlColor = color.New(color.FgGreen, color.Bold)
+		pnlSign = "+"
+	}
+	fmt.Print("  ")
+	colorMuted.Printf("%-15s ", label+":")
+	pnlColor.Printf("%s%.2f (%s%.2f%%)\n", pnlSign, pnl, pnlSign, pnlPercentage)
+}
+
 // 打印表格头部
 func printTableHeader(headers ...string) {
 	fmt.Print("  ")
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"trading-journal-cli/internal/models"
+)
+
+var undoCmd = &cobra.Command{
+	Use:   "undo [operationID]",
+	Short: "撤销最近一次操作",
+	Long: `撤销最近一次开仓、加仓、调整止损止盈、平仓、修改、作废或恢复，也可以指定操作ID（通过 history 命令查看）。
+撤销不会改写已有记录：追加一个恢复到操作之前状态的新版本，撤销平仓时冲回该次成交已记的盈亏和费用流水，撤销开仓时仓位被作废。
+撤销作废即恢复仓位，撤销恢复即重新作废，已平仓仓位的盈亏和费用流水随之重新记入或冲回。
+同一仓位在该操作之后还有其他操作时拒绝撤销，需要先撤销后面的操作。`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runUndo,
+}
+
+func init() {
+	addYesFlag(undoCmd)
+
+	rootCmd.AddCommand(undoCmd)
+}
+
+func runUndo(cmd *cobra.Command, args []string) error {
+	operationID := ""
+	if len(args) == 1 {
+		operationID = args[0]
+	}
+
+	plan, err := ops.PlanUndo(operationID)
+	if err != nil {
+		return err
+	}
+
+	printTitle("↩️  撤销操作")
+	printHighlightField("操作ID", plan.Operation.ID)
+	printField("操作", eventTypeLabel(plan.Operation.Type))
+	printField("时间", plan.Operation.Time.Local().Format("2006-01-02 15:04:05"))
+	printField("仓位", fmt.Sprintf("%s %s (%s)", plan.Current.Symbol, plan.Current.Direction, plan.Current.AccountName))
+	fmt.Println()
+
+	if len(plan.Dependent) > 0 {
+		printError("该操作之后同一仓位还有其他操作，不能撤销:")
+		for _, event := range plan.Dependent {
+			printWarning(fmt.Sprintf("%s  %s  %s", event.ID, event.Time.Local().Format("2006-01-02 15:04:05"), eventTypeLabel(event.Type)))
+		}
+		fmt.Println()
+		printHint("请先按从后往前的顺序撤销这些操作")
+		return errors.New("later operations depend on this operation")
+	}
+
+	if plan.Restored.Voided != nil && plan.Operation.Type == models.EventOpened {
+		printWarning("撤销开仓后该仓位将被作废，不再出现在查询和统计中")
+	} else if plan.Restored.Voided != nil {
+		printWarning("撤销恢复后该仓位重新作废，不再出现在查询和统计中")
+	} else {
+		printInfo("撤销后仓位恢复为:")
+		for _, change := range plan.Changes {
+			// 撤销记录本身不需要展示
+			if strings.HasPrefix(change.Field, "undos[") {
+				continue
+			}
+			fmt.Print("      ")
+			colorMuted.Printf("%s: ", change.Field)
+			fmt.Printf("%s → ", displayChangeValue(change.Old))
+			colorValue.Printf("%s\n", displayChangeValue(change.New))
+		}
+	}
+	for _, entry := range plan.Ledger {
+		printWarning(fmt.Sprintf("冲回资金流水 %s: %s", entry.Note, formatSignedPnL(entry.Amount)))
+	}
+	fmt.Println()
+
+	confirmed, err := confirmAction("确认撤销该操作?")
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		fmt.Println("已取消")
+		return nil
+	}
+
+	result, err := ops.Undo(plan)
+	if err != nil {
+		return fmt.Errorf("撤销失败: %w", err)
+	}
+
+	printSuccess(fmt.Sprintf("已撤销 %s（%s）", result.Operation.ID, eventTypeLabel(result.Operation.Type)))
+	if len(result.Ledger) > 0 {
+		printInfo("账户余额已按冲回的流水更新")
+	}
+	fmt.Println()
+	return nil
+}
+
+// displayChangeValue 显示字段变化的值，字段不存在时显示 -
+func displayChangeValue(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return truncateValue(value)
+}
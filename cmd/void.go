@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/cobra"
+	"trading-journal-cli/internal/models"
+	"trading-journal-cli/internal/operations"
+)
+
+var (
+	voidReason   string
+	unvoidReason string
+)
+
+var voidCmd = &cobra.Command{
+	Use:   "void <positionID>",
+	Short: "作废仓位（重复或测试记录）",
+	Long: `作废重复或测试录入的仓位。作废不会删除记录：追加一个带作废原因的新版本，之后的查询和统计默认不包含该仓位，
+history 中仍可查看完整历史。已有平仓成交的仓位会冲回已记的平仓盈亏和费用流水。
+使用 'list --include-voided' 查看已作废的仓位，'unvoid' 恢复。`,
+	Args: cobra.ExactArgs(1),
+	RunE: runVoid,
+}
+
+var unvoidCmd = &cobra.Command{
+	Use:   "unvoid <positionID>",
+	Short: "恢复已作废的仓位",
+	Long:  `恢复已作废的仓位，已有平仓成交的仓位重新记入平仓盈亏和费用流水。`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runUnvoid,
+}
+
+func init() {
+	voidCmd.Flags().StringVar(&voidReason, "reason", "", "作废原因（必填）")
+	addYesFlag(voidCmd)
+
+	unvoidCmd.Flags().StringVar(&unvoidReason, "reason", "", "恢复原因")
+
+	rootCmd.AddCommand(voidCmd)
+	rootCmd.AddCommand(unvoidCmd)
+}
+
+func runVoid(cmd *cobra.Command, args []string) error {
+	pos, err := ops.GetPosition(args[0])
+	if err != nil {
+		return err
+	}
+
+	printTitle("🗑️  作废仓位")
+	printPositionSummary(pos)
+
+	// 作废原因（必填）
+	reason := voidReason
+	if ask, err := needPrompt(cmd, "reason", false); err != nil {
+		return err
+	} else if ask {
+		reasonPrompt := &survey.Input{
+			Message: "作废原因 (必填，如 \"重复录入\"):",
+		}
+		if err := survey.AskOne(reasonPrompt, &reason, survey.WithValidator(survey.Required)); err != nil {
+			return err
+		}
+	}
+
+	if len(pos.Fills) > 0 {
+		printWarning("该仓位已有平仓成交，作废后将冲回已记的平仓盈亏和费用流水")
+	}
+	confirmed, err := confirmAction("确认作废该仓位?")
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		fmt.Println("已取消")
+		return nil
+	}
+
+	result, err := ops.VoidPosition(pos.PositionID, reason)
+	if err != nil {
+		printError(fmt.Sprintf("作废失败: %v", err))
+		return err
+	}
+
+	printSuccess(fmt.Sprintf("仓位 %s 已作废", result.Position.PositionID))
+	printVoidLedger(result)
+	printHint(fmt.Sprintf("使用 'trading-cli unvoid %s' 恢复", result.Position.PositionID))
+	fmt.Println()
+	return nil
+}
+
+func runUnvoid(cmd *cobra.Command, args []string) error {
+	result, err := ops.UnvoidPosition(args[0], unvoidReason)
+	if err != nil {
+		return err
+	}
+
+	printTitle("♻️  恢复仓位")
+	printPositionSummary(result.Position)
+	printSuccess(fmt.Sprintf("仓位 %s 已恢复", result.Position.PositionID))
+	printVoidLedger(result)
+	fmt.Println()
+	return nil
+}
+
+// printPositionSummary 显示仓位的基本信息
+func printPositionSummary(pos *models.Position) {
+	printHighlightField("仓位ID", pos.PositionID)
+	printField("品种", fmt.Sprintf("%s (%s)", pos.Symbol, pos.MarketType))
+	printField("方向", pos.Direction)
+	printField("账户", pos.AccountName)
+	printField("开仓时间", pos.OpenTime.Local().Format("2006-01-02 15:04:05"))
+	printField("状态", pos.Status)
+	if len(pos.Fills) > 0 {
+		printField("已实现盈亏", formatSignedPnL(pos.TotalRealizedPnL()))
+	}
+	printDivider()
+	fmt.Println()
+}
+
+// printVoidLedger 显示作废或恢复时追加的资金流水
+func printVoidLedger(result *operations.VoidResult) {
+	for _, entry := range result.Ledger {
+		printInfo(fmt.Sprintf("资金流水 %s: %s", entry.Note, formatSignedPnL(entry.Amount)))
+	}
+	if len(result.Ledger) > 0 {
+		printInfo("账户余额已更新")
+	}
+}
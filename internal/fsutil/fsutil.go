@@ -0,0 +1,341 @@
+// Package fsutil 数据目录的文件锁和防崩溃写入
+package fsutil
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// LockFileName 数据目录下的锁文件名
+const LockFileName = ".lock"
+
+// LockTimeout 等待其他进程释放锁的最长时间
+var LockTimeout = 10 * time.Second
+
+// heldLock 当前进程持有的目录锁
+type heldLock struct {
+	file  *os.File
+	count int
+}
+
+var (
+	heldMu sync.Mutex
+	held   = make(map[string]*heldLock)
+)
+
+// Lock 数据目录的排他锁
+type Lock struct {
+	path     string
+	released bool
+}
+
+// LockDir 获取数据目录的排他建议锁，其他进程在释放前会等待
+//
+// 同一进程内可重入：已持有锁时只增加计数，最后一次 Unlock 时才真正释放。
+func LockDir(dir string) (*Lock, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+	path, err := filepath.Abs(filepath.Join(dir, LockFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	heldMu.Lock()
+	defer heldMu.Unlock()
+
+	if h, ok := held[path]; ok {
+		h.count++
+		return &Lock{path: path}, nil
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	deadline := time.Now().Add(LockTimeout)
+	for {
+		locked, err := tryLock(file)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to lock data directory: %w", err)
+		}
+		if locked {
+			break
+		}
+		if time.Now().After(deadline) {
+			file.Close()
+			return nil, fmt.Errorf("data directory %s is locked by another process", dir)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	held[path] = &heldLock{file: file, count: 1}
+	return &Lock{path: path}, nil
+}
+
+// Unlock 释放锁，重复调用无效果
+func (l *Lock) Unlock() error {
+	if l == nil || l.released {
+		return nil
+	}
+	l.released = true
+
+	heldMu.Lock()
+	defer heldMu.Unlock()
+
+	h, ok := held[l.path]
+	if !ok {
+		return nil
+	}
+	h.count--
+	if h.count > 0 {
+		return nil
+	}
+	delete(held, l.path)
+
+	err := unlock(h.file)
+	if closeErr := h.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// WriteFileAtomic 写入同目录下的临时文件并同步到磁盘后重命名替换目标文件
+// 崩溃时目标文件要么是旧内容，要么是完整的新内容
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return fmt.Errorf("failed to set file mode: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", filepath.Base(path), err)
+	}
+
+	syncDir(dir)
+	return nil
+}
+
+// AppendLines 在目录锁内追加多行到文件末尾并同步到磁盘
+// 追加前先修复上次崩溃留下的不完整末行，避免新记录与残缺内容拼接在同一行
+func AppendLines(path string, lines ...[]byte) error {
+	if len(lines) == 0 {
+		return nil
+	}
+
+	lock, err := LockDir(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	if _, err := RepairTornTail(path); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	var buf bytes.Buffer
+	for _, line := range lines {
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync file: %w", err)
+	}
+	return nil
+}
+
+// ReadLines 逐行读取文件并调用 fn，行长度不受限制，空行跳过
+// 文件不存在时不调用 fn 并返回 nil；fn 返回错误时停止读取并返回该错误
+func ReadLines(path string, fn func(lineNum int, line []byte) error) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReaderSize(file, 64*1024)
+	lineNum := 0
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			lineNum++
+			if line = bytes.TrimSpace(line); len(line) > 0 {
+				if err := fn(lineNum, line); err != nil {
+					return err
+				}
+			}
+		}
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return fmt.Errorf("error reading file: %w", readErr)
+		}
+	}
+}
+
+// RepairTornTail 检查 JSONL 文件末尾是否有写入中断留下的不完整行并修复
+//
+// 末尾缺少换行符时：最后一行是合法 JSON 则补上换行符；否则把残缺内容移到 <文件>.torn 并截断。
+// 返回是否做了修复。文件不存在时不做任何处理。
+func RepairTornTail(path string) (bool, error) {
+	if torn, err := hasTornTail(path); err != nil || !torn {
+		return false, err
+	}
+
+	lock, err := LockDir(filepath.Dir(path))
+	if err != nil {
+		return false, err
+	}
+	defer lock.Unlock()
+
+	file, err := os.OpenFile(path, os.O_RDWR, 0644)
+	if err != nil {
+		return false, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	// 加锁后重新检查，其他进程可能已完成修复
+	offset, tail, err := lastLine(file)
+	if err != nil || tail == nil {
+		return false, err
+	}
+
+	if json.Valid(tail) {
+		if _, err := file.WriteAt([]byte{'\n'}, offset+int64(len(tail))); err != nil {
+			return false, fmt.Errorf("failed to repair %s: %w", filepath.Base(path), err)
+		}
+	} else {
+		tornPath := path + ".torn"
+		torn, err := os.OpenFile(tornPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return false, fmt.Errorf("failed to save torn line: %w", err)
+		}
+		_, err = torn.Write(append(tail, '\n'))
+		if syncErr := torn.Sync(); err == nil {
+			err = syncErr
+		}
+		torn.Close()
+		if err != nil {
+			return false, fmt.Errorf("failed to save torn line: %w", err)
+		}
+		if err := file.Truncate(offset); err != nil {
+			return false, fmt.Errorf("failed to repair %s: %w", filepath.Base(path), err)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: removed torn trailing line from %s (saved to %s)\n",
+			filepath.Base(path), filepath.Base(tornPath))
+	}
+
+	if err := file.Sync(); err != nil {
+		return false, fmt.Errorf("failed to sync file: %w", err)
+	}
+	return true, nil
+}
+
+// hasTornTail 文件非空且不以换行符结尾
+func hasTornTail(path string) (bool, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || info.Size() == 0 {
+		return false, err
+	}
+	last := make([]byte, 1)
+	if _, err := file.ReadAt(last, info.Size()-1); err != nil {
+		return false, fmt.Errorf("failed to read file: %w", err)
+	}
+	return last[0] != '\n', nil
+}
+
+// lastLine 返回最后一个换行符之后的内容及其起始位置，文件以换行符结尾时返回 nil
+func lastLine(file *os.File) (int64, []byte, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return 0, nil, err
+	}
+	size := info.Size()
+
+	// 从文件末尾向前按块查找最后一个换行符
+	const chunkSize = 64 * 1024
+	end := size
+	start := size
+	for start > 0 {
+		start = end - chunkSize
+		if start < 0 {
+			start = 0
+		}
+		chunk := make([]byte, end-start)
+		if _, err := file.ReadAt(chunk, start); err != nil && err != io.EOF {
+			return 0, nil, err
+		}
+		if end == size && len(chunk) > 0 && chunk[len(chunk)-1] == '\n' {
+			return size, nil, nil
+		}
+		if i := bytes.LastIndexByte(chunk, '\n'); i >= 0 {
+			start += int64(i) + 1
+			break
+		}
+		end = start
+	}
+
+	tail := make([]byte, size-start)
+	if _, err := file.ReadAt(tail, start); err != nil && err != io.EOF {
+		return 0, nil, err
+	}
+	return start, tail, nil
+}
+
+// syncDir 同步目录项，确保重命名在崩溃后仍然生效（部分平台不支持，忽略错误）
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	d.Sync()
+	d.Close()
+}
@@ -0,0 +1,148 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRepairTornTail(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+		torn     string
+		repaired bool
+	}{
+		{"完整文件", "{\"a\":1}\n{\"b\":2}\n", "{\"a\":1}\n{\"b\":2}\n", "", false},
+		{"空文件", "", "", "", false},
+		{"末行缺少换行符", "{\"a\":1}\n{\"b\":2}", "{\"a\":1}\n{\"b\":2}\n", "", true},
+		{"末行不完整", "{\"a\":1}\n{\"b\":", "{\"a\":1}\n", "{\"b\":\n", true},
+		{"只有不完整的一行", "{\"a\"", "", "{\"a\"\n", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "trades-2024-01.jsonl")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			repaired, err := RepairTornTail(path)
+			if err != nil {
+				t.Fatalf("RepairTornTail failed: %v", err)
+			}
+			if repaired != tt.repaired {
+				t.Errorf("Expected repaired=%v, got %v", tt.repaired, repaired)
+			}
+
+			data, _ := os.ReadFile(path)
+			if string(data) != tt.expected {
+				t.Errorf("Expected content %q, got %q", tt.expected, string(data))
+			}
+			torn, _ := os.ReadFile(path + ".torn")
+			if string(torn) != tt.torn {
+				t.Errorf("Expected torn content %q, got %q", tt.torn, string(torn))
+			}
+		})
+	}
+}
+
+func TestAppendLines_AfterTornTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.jsonl")
+	if err := os.WriteFile(path, []byte("{\"a\":1}\n{\"b\""), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := AppendLines(path, []byte(`{"c":3}`)); err != nil {
+		t.Fatalf("AppendLines failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if expected := "{\"a\":1}\n{\"c\":3}\n"; string(data) != expected {
+		t.Errorf("Expected content %q, got %q", expected, string(data))
+	}
+}
+
+func TestLockDir_Reentrant(t *testing.T) {
+	dir := t.TempDir()
+
+	outer, err := LockDir(dir)
+	if err != nil {
+		t.Fatalf("LockDir failed: %v", err)
+	}
+	inner, err := LockDir(dir)
+	if err != nil {
+		t.Fatalf("Reentrant LockDir failed: %v", err)
+	}
+
+	if err := inner.Unlock(); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if len(held) != 1 {
+		t.Errorf("Expected lock to be held until the outer unlock")
+	}
+	if err := outer.Unlock(); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if len(held) != 0 {
+		t.Errorf("Expected lock to be released")
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "accounts.json")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteFileAtomic(path, []byte("new"), 0644); err != nil {
+		t.Fatalf("WriteFileAtomic failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "new" {
+		t.Errorf("Expected new content, got %q", string(data))
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected temp file to be removed, found %d entries", len(entries))
+	}
+}
+
+func TestReadLines_LongLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trades-2024-01.jsonl")
+	long := make([]byte, 200*1024)
+	for i := range long {
+		long[i] = 'x'
+	}
+	content := "{\"a\":1}\n\n" + string(long) + "\n{\"b\":2}"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []int
+	var lengths []int
+	err := ReadLines(path, func(lineNum int, line []byte) error {
+		lines = append(lines, lineNum)
+		lengths = append(lengths, len(line))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ReadLines failed: %v", err)
+	}
+	if len(lines) != 3 || lines[0] != 1 || lines[1] != 3 || lines[2] != 4 {
+		t.Errorf("Expected line numbers [1 3 4], got %v", lines)
+	}
+	if len(lengths) == 3 && lengths[1] != len(long) {
+		t.Errorf("Expected long line of %d bytes, got %d", len(long), lengths[1])
+	}
+
+	if err := ReadLines(filepath.Join(t.TempDir(), "missing.jsonl"), func(int, []byte) error {
+		t.Error("fn should not be called for a missing file")
+		return nil
+	}); err != nil {
+		t.Errorf("Expected nil error for missing file, got %v", err)
+	}
+}
@@ -0,0 +1,25 @@
+//go:build !unix && !windows
+
+package fsutil
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// unsupportedWarning 每个进程只提示一次不支持跨进程锁
+var unsupportedWarning sync.Once
+
+// tryLock 当前平台不支持文件锁，只在进程内互斥，并提示多个进程同时写入数据目录不安全
+func tryLock(file *os.File) (bool, error) {
+	unsupportedWarning.Do(func() {
+		fmt.Fprintln(os.Stderr, "Warning: file locking is not supported on this platform; do not run multiple trading-cli processes on the same data directory")
+	})
+	return true, nil
+}
+
+// unlock 释放文件锁
+func unlock(file *os.File) error {
+	return nil
+}
@@ -5,14 +5,47 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
+	"trading-journal-cli/internal/fsutil"
 )
 
 // Account 账户信息
+// Balance 由资金流水（ledger.jsonl）汇总得到，每次追加流水后更新
 type Account struct {
-	Name     string  `json:"name"`
-	Balance  float64 `json:"balance"`
-	Currency string  `json:"currency,omitempty"`
-	Template *AccountTemplate `json:"template,omitempty"` // 开仓模板
+	Name           string           `json:"name"`
+	Balance        float64          `json:"balance"`
+	Currency       string           `json:"currency,omitempty"`
+	CreatedAt      *time.Time       `json:"createdAt,omitempty"`      // 创建时间，旧账户为空
+	Template       *AccountTemplate `json:"template,omitempty"`       // 开仓模板
+	RiskLimits     *RiskLimits      `json:"riskLimits,omitempty"`     // 开仓风控限制
+	CircuitBreaker *CircuitBreaker  `json:"circuitBreaker,omitempty"` // 熔断规则
+	Lock           *AccountLock     `json:"lock,omitempty"`           // 最近一次熔断锁定
+}
+
+// RiskLimits 账户开仓风控限制，值为 0 表示不限制
+type RiskLimits struct {
+	MaxRiskPerTradePercent float64 `json:"maxRiskPerTradePercent,omitempty"` // 单笔风险占余额的最大百分比
+	MaxOpenRiskPercent     float64 `json:"maxOpenRiskPercent,omitempty"`     // 所有未平仓位（含新仓位）风险合计占余额的最大百分比
+	MaxPositionsPerSymbol  int     `json:"maxPositionsPerSymbol,omitempty"`  // 同一品种最多未平仓位数
+	MinRiskReward          float64 `json:"minRiskReward,omitempty"`          // 最低风险回报比
+	MaxMarginUsagePercent  float64 `json:"maxMarginUsagePercent,omitempty"`  // 保证金合计占余额的最大百分比
+}
+
+// IsEmpty 是否没有设置任何限制
+func (l *RiskLimits) IsEmpty() bool {
+	return l == nil || *l == RiskLimits{}
+}
+
+// DefaultCurrency 未设置币种的账户视为美元账户
+const DefaultCurrency = "USD"
+
+// CurrencyCode 账户币种，未设置时为 DefaultCurrency
+func (a *Account) CurrencyCode() string {
+	if a.Currency == "" {
+		return DefaultCurrency
+	}
+	return strings.ToUpper(a.Currency)
 }
 
 // AccountTemplate 账户开仓模板
@@ -54,33 +87,60 @@ func (am *AccountManager) Load() error {
 		return fmt.Errorf("failed to read accounts config: %w", err)
 	}
 
-	if err := json.Unmarshal(data, &am.config); err != nil {
+	// 解析到新的结构中，重新加载时不会残留上次加载的字段
+	config := &AccountConfig{Accounts: []Account{}}
+	if err := json.Unmarshal(data, config); err != nil {
 		return fmt.Errorf("failed to parse accounts config: %w", err)
 	}
+	am.config = config
 
 	return nil
 }
 
-// Save 保存账户配置
+// Save 保存账户配置（写入临时文件后原子替换，崩溃时不会留下不完整的配置）
 func (am *AccountManager) Save() error {
-	// 确保目录存在
-	dir := filepath.Dir(am.configPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create config directory: %w", err)
+	lock, err := am.Lock()
+	if err != nil {
+		return err
 	}
+	defer lock.Unlock()
 
 	data, err := json.MarshalIndent(am.config, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal accounts config: %w", err)
 	}
 
-	if err := os.WriteFile(am.configPath, data, 0644); err != nil {
+	if err := fsutil.WriteFileAtomic(am.configPath, data, 0644); err != nil {
 		return fmt.Errorf("failed to write accounts config: %w", err)
 	}
 
 	return nil
 }
 
+// Lock 获取数据目录锁，其他进程的修改在释放前会等待
+// 锁可重入，先检查后写入的操作可在持有锁时调用其他加锁的方法
+func (am *AccountManager) Lock() (*fsutil.Lock, error) {
+	return fsutil.LockDir(filepath.Dir(am.configPath))
+}
+
+// update 在数据目录锁内重新加载配置、应用修改并保存
+// 修改基于磁盘上的最新配置，避免覆盖其他进程同时做的修改
+func (am *AccountManager) update(apply func() error) error {
+	lock, err := am.Lock()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	if err := am.Load(); err != nil {
+		return err
+	}
+	if err := apply(); err != nil {
+		return err
+	}
+	return am.Save()
+}
+
 // ListAccounts 列出所有账户
 func (am *AccountManager) ListAccounts() []Account {
 	return am.config.Accounts
@@ -98,69 +158,88 @@ func (am *AccountManager) GetAccount(name string) (*Account, error) {
 
 // AddAccount 添加账户
 func (am *AccountManager) AddAccount(account Account) error {
-	// 检查是否已存在
-	for _, acc := range am.config.Accounts {
-		if acc.Name == account.Name {
-			return fmt.Errorf("account already exists: %s", account.Name)
-		}
+	lock, err := am.Lock()
+	if err != nil {
+		return err
 	}
+	defer lock.Unlock()
 
-	am.config.Accounts = append(am.config.Accounts, account)
-	return am.Save()
-}
+	now := time.Now()
+	if account.CreatedAt == nil {
+		account.CreatedAt = &now
+	}
 
-// UpdateAccount 更新账户
-func (am *AccountManager) UpdateAccount(name string, balance float64) error {
-	found := false
-	for i := range am.config.Accounts {
-		if am.config.Accounts[i].Name == name {
-			am.config.Accounts[i].Balance = balance
-			found = true
-			break
+	err = am.update(func() error {
+		// 检查是否已存在
+		for _, acc := range am.config.Accounts {
+			if acc.Name == account.Name {
+				return fmt.Errorf("account already exists: %s", account.Name)
+			}
 		}
+
+		am.config.Accounts = append(am.config.Accounts, account)
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	if !found {
-		return fmt.Errorf("account not found: %s", name)
+	// 初始余额记为一笔入金
+	if account.Balance > 0 {
+		return am.AppendLedgerEntries(NewLedgerEntry(account.Name, LedgerDeposit, account.Balance, now, "初始资金"))
 	}
+	return nil
+}
 
-	return am.Save()
+// UpdateAccountRiskLimits 更新账户风控限制，limits 为空时清除
+func (am *AccountManager) UpdateAccountRiskLimits(name string, limits *RiskLimits) error {
+	if limits.IsEmpty() {
+		limits = nil
+	}
+
+	return am.updateAccount(name, func(account *Account) {
+		account.RiskLimits = limits
+	})
+}
+
+// updateAccount 在锁内修改指定账户并保存
+func (am *AccountManager) updateAccount(name string, apply func(account *Account)) error {
+	return am.update(func() error {
+		for i := range am.config.Accounts {
+			if am.config.Accounts[i].Name == name {
+				apply(&am.config.Accounts[i])
+				return nil
+			}
+		}
+		return fmt.Errorf("account not found: %s", name)
+	})
 }
 
 // DeleteAccount 删除账户
 func (am *AccountManager) DeleteAccount(name string) error {
-	found := false
-	newAccounts := []Account{}
-	for _, acc := range am.config.Accounts {
-		if acc.Name != name {
-			newAccounts = append(newAccounts, acc)
-		} else {
-			found = true
+	return am.update(func() error {
+		found := false
+		newAccounts := []Account{}
+		for _, acc := range am.config.Accounts {
+			if acc.Name != name {
+				newAccounts = append(newAccounts, acc)
+			} else {
+				found = true
+			}
 		}
-	}
 
-	if !found {
-		return fmt.Errorf("account not found: %s", name)
-	}
+		if !found {
+			return fmt.Errorf("account not found: %s", name)
+		}
 
-	am.config.Accounts = newAccounts
-	return am.Save()
+		am.config.Accounts = newAccounts
+		return nil
+	})
 }
 
 // UpdateAccountTemplate 更新账户模板
 func (am *AccountManager) UpdateAccountTemplate(name string, template *AccountTemplate) error {
-	found := false
-	for i := range am.config.Accounts {
-		if am.config.Accounts[i].Name == name {
-			am.config.Accounts[i].Template = template
-			found = true
-			break
-		}
-	}
-
-	if !found {
-		return fmt.Errorf("account not found: %s", name)
-	}
-
-	return am.Save()
+	return am.updateAccount(name, func(account *Account) {
+		account.Template = template
+	})
 }
@@ -0,0 +1,174 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+	"trading-journal-cli/internal/fsutil"
+)
+
+// CircuitBreaker 账户熔断规则，值为 0 表示不启用该规则
+type CircuitBreaker struct {
+	MaxDailyLoss         float64 `json:"maxDailyLoss,omitempty"`         // 当日已实现净亏损上限（账户币种）
+	MaxDrawdownPercent   float64 `json:"maxDrawdownPercent,omitempty"`   // 距资金峰值的最大回撤百分比
+	MaxConsecutiveLosses int     `json:"maxConsecutiveLosses,omitempty"` // 最多连续亏损笔数
+	UnlockAt             string  `json:"unlockAt,omitempty"`             // 锁定到下一个该时刻（本地时间 HH:MM），默认 00:00
+	LockHours            float64 `json:"lockHours,omitempty"`            // 大于 0 时改为锁定固定小时数
+}
+
+// IsEmpty 是否没有启用任何规则
+func (b *CircuitBreaker) IsEmpty() bool {
+	return b == nil || (b.MaxDailyLoss == 0 && b.MaxDrawdownPercent == 0 && b.MaxConsecutiveLosses == 0)
+}
+
+// Validate 检查规则取值
+func (b *CircuitBreaker) Validate() error {
+	if b.MaxDailyLoss < 0 || b.MaxDrawdownPercent < 0 || b.MaxConsecutiveLosses < 0 || b.LockHours < 0 {
+		return fmt.Errorf("circuit breaker values must not be negative")
+	}
+	if b.UnlockAt != "" {
+		if _, err := time.Parse("15:04", b.UnlockAt); err != nil {
+			return fmt.Errorf("invalid unlock time %q, expected HH:MM", b.UnlockAt)
+		}
+	}
+	return nil
+}
+
+// LockUntil 从 from 开始触发熔断时的解锁时间
+func (b *CircuitBreaker) LockUntil(from time.Time) time.Time {
+	if b.LockHours > 0 {
+		return from.Add(time.Duration(b.LockHours * float64(time.Hour)))
+	}
+
+	unlockAt := b.UnlockAt
+	if unlockAt == "" {
+		unlockAt = "00:00"
+	}
+	clock, err := time.Parse("15:04", unlockAt)
+	if err != nil {
+		clock = time.Time{}
+	}
+
+	local := from.Local()
+	until := time.Date(local.Year(), local.Month(), local.Day(), clock.Hour(), clock.Minute(), 0, 0, time.Local)
+	if !until.After(local) {
+		until = until.AddDate(0, 0, 1)
+	}
+	return until
+}
+
+// BreakerRule 熔断规则类型
+type BreakerRule string
+
+const (
+	BreakerDailyLoss         BreakerRule = "daily_loss"         // 当日亏损
+	BreakerDrawdown          BreakerRule = "drawdown"           // 回撤
+	BreakerConsecutiveLosses BreakerRule = "consecutive_losses" // 连续亏损
+)
+
+// AccountLock 熔断锁定状态
+type AccountLock struct {
+	LockedAt   time.Time   `json:"lockedAt"`
+	Until      time.Time   `json:"until"`
+	Rule       BreakerRule `json:"rule"`
+	Value      float64     `json:"value"`                // 触发时的数值
+	Limit      float64     `json:"limit"`                // 触发时的规则上限
+	UnlockedAt *time.Time  `json:"unlockedAt,omitempty"` // 提前解锁时间
+}
+
+// ActiveAt 在 at 时刻是否仍处于锁定状态
+func (l *AccountLock) ActiveAt(at time.Time) bool {
+	return l != nil && l.UnlockedAt == nil && at.Before(l.Until)
+}
+
+// HeldAt 在 at 时刻是否处于锁定状态，之后才提前解锁的锁定在 at 时刻仍算锁定，用于查看历史状态
+func (l *AccountLock) HeldAt(at time.Time) bool {
+	return l != nil && !at.Before(l.LockedAt) && at.Before(l.ReleasedAt())
+}
+
+// ReleasedAt 锁定解除的时间（提前解锁时间或到期时间）
+func (l *AccountLock) ReleasedAt() time.Time {
+	if l.UnlockedAt != nil && l.UnlockedAt.Before(l.Until) {
+		return *l.UnlockedAt
+	}
+	return l.Until
+}
+
+// UpdateAccountCircuitBreaker 更新账户熔断规则，breaker 为空时清除
+func (am *AccountManager) UpdateAccountCircuitBreaker(name string, breaker *CircuitBreaker) error {
+	if breaker != nil {
+		if err := breaker.Validate(); err != nil {
+			return err
+		}
+		if breaker.IsEmpty() {
+			breaker = nil
+		}
+	}
+
+	return am.updateAccount(name, func(account *Account) {
+		account.CircuitBreaker = breaker
+	})
+}
+
+// SetAccountLock 保存账户的熔断锁定状态
+func (am *AccountManager) SetAccountLock(name string, lock *AccountLock) error {
+	return am.updateAccount(name, func(account *Account) {
+		account.Lock = lock
+	})
+}
+
+// AuditEntry 账户审计记录
+type AuditEntry struct {
+	Time    time.Time `json:"time"`
+	Account string    `json:"account"`
+	Action  string    `json:"action"` // 操作类型，如 "unlock"
+	Reason  string    `json:"reason,omitempty"`
+	Detail  string    `json:"detail,omitempty"`
+}
+
+// AuditActionUnlock 提前解除熔断锁定
+const AuditActionUnlock = "unlock"
+
+// auditPath 审计记录文件路径（与账户配置位于同一目录）
+func (am *AccountManager) auditPath() string {
+	return filepath.Join(filepath.Dir(am.configPath), "audit.jsonl")
+}
+
+// AppendAudit 追加审计记录
+func (am *AccountManager) AppendAudit(entry AuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+	if err := fsutil.AppendLines(am.auditPath(), data); err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	return nil
+}
+
+// ReadAudit 读取审计记录，accountName 为空时返回所有账户的记录
+func (am *AccountManager) ReadAudit(accountName string) ([]AuditEntry, error) {
+	if _, err := fsutil.RepairTornTail(am.auditPath()); err != nil {
+		return nil, err
+	}
+
+	entries := []AuditEntry{}
+	err := fsutil.ReadLines(am.auditPath(), func(lineNum int, line []byte) error {
+		var entry AuditEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping invalid JSON at line %d in audit log: %v\n", lineNum, err)
+			return nil
+		}
+		if accountName == "" || entry.Account == accountName {
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading audit log: %w", err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker_LockUntil(t *testing.T) {
+	from := time.Date(2024, 3, 15, 14, 30, 0, 0, time.Local)
+
+	tests := []struct {
+		name     string
+		breaker  CircuitBreaker
+		expected time.Time
+	}{
+		{"默认锁定到次日零点", CircuitBreaker{}, time.Date(2024, 3, 16, 0, 0, 0, 0, time.Local)},
+		{"当天稍后解锁", CircuitBreaker{UnlockAt: "21:00"}, time.Date(2024, 3, 15, 21, 0, 0, 0, time.Local)},
+		{"解锁时刻已过则到次日", CircuitBreaker{UnlockAt: "09:30"}, time.Date(2024, 3, 16, 9, 30, 0, 0, time.Local)},
+		{"固定小时数优先", CircuitBreaker{UnlockAt: "21:00", LockHours: 2}, from.Add(2 * time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.breaker.LockUntil(from); !got.Equal(tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestAccountLock_ActiveAt(t *testing.T) {
+	lockedAt := time.Date(2024, 3, 15, 14, 0, 0, 0, time.UTC)
+	lock := &AccountLock{LockedAt: lockedAt, Until: lockedAt.Add(10 * time.Hour)}
+
+	if !lock.ActiveAt(lockedAt.Add(time.Hour)) {
+		t.Error("Expected lock to be active before until")
+	}
+	if lock.ActiveAt(lockedAt.Add(10 * time.Hour)) {
+		t.Error("Expected lock to expire at until")
+	}
+
+	unlockedAt := lockedAt.Add(2 * time.Hour)
+	lock.UnlockedAt = &unlockedAt
+	if lock.ActiveAt(lockedAt.Add(3 * time.Hour)) {
+		t.Error("Expected unlocked lock to be inactive")
+	}
+	if !lock.ReleasedAt().Equal(unlockedAt) {
+		t.Errorf("Expected released at %v, got %v", unlockedAt, lock.ReleasedAt())
+	}
+	if !lock.HeldAt(lockedAt.Add(time.Hour)) {
+		t.Error("Expected lock to be held before it was unlocked")
+	}
+	if lock.HeldAt(lockedAt.Add(-time.Hour)) || lock.HeldAt(unlockedAt) {
+		t.Error("Expected lock not to be held before locking or after unlocking")
+	}
+
+	var none *AccountLock
+	if none.ActiveAt(lockedAt) || none.HeldAt(lockedAt) {
+		t.Error("Expected nil lock to be inactive")
+	}
+}
+
+func TestAppendAudit(t *testing.T) {
+	am := NewAccountManager(t.TempDir())
+
+	now := time.Now()
+	for _, account := range []string{"main", "alt", "main"} {
+		if err := am.AppendAudit(AuditEntry{Time: now, Account: account, Action: AuditActionUnlock, Reason: "test"}); err != nil {
+			t.Fatalf("AppendAudit failed: %v", err)
+		}
+	}
+
+	entries, err := am.ReadAudit("main")
+	if err != nil {
+		t.Fatalf("ReadAudit failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("Expected 2 audit entries for main, got %d", len(entries))
+	}
+
+	all, err := am.ReadAudit("")
+	if err != nil {
+		t.Fatalf("ReadAudit failed: %v", err)
+	}
+	if len(all) != 3 {
+		t.Errorf("Expected 3 audit entries, got %d", len(all))
+	}
+}
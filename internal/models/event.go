@@ -0,0 +1,299 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EventType 仓位事件类型
+type EventType string
+
+const (
+	EventOpened          EventType = "opened"           // 开仓
+	EventAdded           EventType = "added"            // 加仓
+	EventPartiallyClosed EventType = "partially_closed" // 部分平仓
+	EventClosed          EventType = "closed"           // 全部平仓
+	EventAdjusted        EventType = "adjusted"         // 调整止损止盈
+	EventEdited          EventType = "edited"           // 修改记录（不对应成交或调整的其他变化）
+	EventUndone          EventType = "undone"           // 撤销之前的一个操作
+	EventVoided          EventType = "voided"           // 作废
+	EventUnvoided        EventType = "unvoided"         // 恢复作废的仓位
+)
+
+// FieldChange 两个版本之间单个字段的变化
+// Field 为 JSON 字段路径（如 fills[1].closePrice），Old/New 为 JSON 形式的值，字段不存在时为空
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old,omitempty"`
+	New   string `json:"new,omitempty"`
+}
+
+// UndoRecord 撤销操作记录
+type UndoRecord struct {
+	Time        time.Time `json:"time"`
+	OperationID string    `json:"operationId"` // 被撤销的操作
+	Type        EventType `json:"type"`        // 被撤销操作的类型
+}
+
+// OperationID 仓位操作的标识：仓位ID:版本序号
+func OperationID(positionID string, version int) string {
+	return fmt.Sprintf("%s:%d", positionID, version)
+}
+
+// ParseOperationID 解析操作标识，返回仓位ID和版本序号
+func ParseOperationID(id string) (string, int, error) {
+	i := strings.LastIndex(id, ":")
+	if i <= 0 {
+		return "", 0, fmt.Errorf("invalid operation id: %s (expected <positionID>:<version>)", id)
+	}
+	version, err := strconv.Atoi(id[i+1:])
+	if err != nil || version < 1 {
+		return "", 0, fmt.Errorf("invalid operation id: %s (expected <positionID>:<version>)", id)
+	}
+	return id[:i], version, nil
+}
+
+// PositionEvent 仓位生命周期中的一个事件，对应存储中的一个版本
+type PositionEvent struct {
+	ID       string        `json:"id"` // 操作标识，见 OperationID
+	Type     EventType     `json:"type"`
+	Time     time.Time     `json:"time"`              // 事件发生时间，取自本次新增的成交或调整记录
+	Version  int           `json:"version"`           // 版本序号，从 1 开始
+	Changes  []FieldChange `json:"changes,omitempty"` // 与上一版本相比的字段变化，开仓事件为空
+	Position *Position     `json:"position"`          // 事件发生后的仓位快照
+}
+
+// BuildPositionEvents 按写入顺序的版本快照推导仓位事件
+//
+// 与上一版本完全相同的版本（如重复导入）不产生事件。edit 命令的修改使用修改记录中的时间；
+// 其他修改类事件没有独立的时间记录，使用上一个事件的时间，保证时间线顺序与写入顺序一致。
+func BuildPositionEvents(versions []*Position) ([]PositionEvent, error) {
+	events := make([]PositionEvent, 0, len(versions))
+	var prev *Position
+	for i, pos := range versions {
+		if prev == nil {
+			events = append(events, PositionEvent{
+				ID:       OperationID(pos.PositionID, i+1),
+				Type:     EventOpened,
+				Time:     pos.OpenTime,
+				Version:  i + 1,
+				Position: pos,
+			})
+			prev = pos
+			continue
+		}
+
+		changes, err := DiffPositions(prev, pos)
+		if err != nil {
+			return nil, err
+		}
+		if len(changes) == 0 {
+			continue
+		}
+
+		event := PositionEvent{
+			ID:       OperationID(pos.PositionID, i+1),
+			Type:     EventEdited,
+			Time:     events[len(events)-1].Time,
+			Version:  i + 1,
+			Changes:  changes,
+			Position: pos,
+		}
+		switch {
+		case len(pos.Undos) > len(prev.Undos):
+			event.Type = EventUndone
+			event.Time = pos.Undos[len(pos.Undos)-1].Time
+		case len(pos.Edits) > len(prev.Edits):
+			event.Time = pos.Edits[len(pos.Edits)-1].Time
+		case pos.Voided != nil && prev.Voided == nil:
+			event.Type = EventVoided
+			event.Time = pos.Voided.Time
+		case len(pos.Unvoids) > len(prev.Unvoids):
+			event.Type = EventUnvoided
+			event.Time = pos.Unvoids[len(pos.Unvoids)-1].Time
+		case len(pos.Fills) > len(prev.Fills):
+			event.Type = EventPartiallyClosed
+			if pos.Status == StatusClosed {
+				event.Type = EventClosed
+			}
+			event.Time = pos.Fills[len(pos.Fills)-1].CloseTime
+		case len(pos.Entries) > len(prev.Entries):
+			event.Type = EventAdded
+			event.Time = pos.Entries[len(pos.Entries)-1].Time
+		case len(pos.Adjustments) > len(prev.Adjustments):
+			event.Type = EventAdjusted
+			event.Time = pos.Adjustments[len(pos.Adjustments)-1].Time
+		}
+		events = append(events, event)
+		prev = pos
+	}
+	return events, nil
+}
+
+// EffectiveEvents 去掉已被撤销的操作及撤销事件本身，返回仍然有效的事件
+// 撤销事件总是撤销当时最后一个有效事件，因此按栈的方式处理
+func EffectiveEvents(events []PositionEvent) []PositionEvent {
+	var stack []PositionEvent
+	for _, event := range events {
+		if event.Type == EventUndone {
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			continue
+		}
+		stack = append(stack, event)
+	}
+	return stack
+}
+
+// ReplayPosition 按事件顺序重放到 at 时刻，返回当时的仓位快照，at 时尚未开仓时返回 nil
+//
+// 压缩或导入后的数据可能缺少中间版本，此时快照中晚于 at 的加仓、调整和平仓记录会被撤回，
+// 并按剩余记录重新计算数量、止损止盈、状态和平仓汇总。at 之后用 edit 更正过的仓位按更正后的内容重建。
+func ReplayPosition(events []PositionEvent, at time.Time) *Position {
+	var state *Position
+	corrected := false
+	edits := 0
+	for _, event := range events {
+		if event.Time.After(at) {
+			corrected = corrected || len(event.Position.Edits) > edits
+		} else {
+			state = event.Position
+		}
+		edits = len(event.Position.Edits)
+	}
+
+	// edit 修改的是录入错误，at 之后的修改同样适用于 at 时刻：以最新版本为准撤回之后的记录
+	if corrected {
+		state = events[len(events)-1].Position
+		if state.OpenTime.After(at) {
+			return nil
+		}
+	}
+	if state == nil {
+		return nil
+	}
+	return rewindPosition(state, at)
+}
+
+// rewindPosition 撤回快照中晚于 at 的成交和调整记录，没有需要撤回的记录时原样返回
+func rewindPosition(pos *Position, at time.Time) *Position {
+	var entries []EntryFill
+	for _, entry := range pos.Entries {
+		if !entry.Time.After(at) {
+			entries = append(entries, entry)
+		}
+	}
+	var adjustments []LevelAdjustment
+	for _, adj := range pos.Adjustments {
+		if !adj.Time.After(at) {
+			adjustments = append(adjustments, adj)
+		}
+	}
+	var fills []CloseFill
+	for _, fill := range pos.Fills {
+		if !fill.CloseTime.After(at) {
+			fills = append(fills, fill)
+		}
+	}
+	if len(entries) == len(pos.Entries) && len(adjustments) == len(pos.Adjustments) && len(fills) == len(pos.Fills) {
+		return pos
+	}
+
+	rewound := *pos
+	rewound.Entries = entries
+	rewound.Adjustments = adjustments
+	rewound.Fills = fills
+
+	// 止损止盈恢复为第一条被撤回的调整之前的值
+	if len(adjustments) < len(pos.Adjustments) {
+		first := pos.Adjustments[len(adjustments)]
+		rewound.StopLoss = first.OldStopLoss
+		rewound.TakeProfit = first.OldTakeProfit
+	}
+
+	// 按时间顺序重放开仓、加仓和平仓，得到当时的加权平均开仓价、数量和保证金
+	if len(entries) < len(pos.Entries) || len(fills) < len(pos.Fills) {
+		rewound.ReplayEntries(0)
+		if len(rewound.Entries) == 0 {
+			rewound.OpenPrice = pos.OpenPrice
+		}
+	}
+
+	rewound.Status = StatusOpen
+	if len(fills) > 0 && rewound.Quantity <= 0 {
+		rewound.Status = StatusClosed
+	}
+	rewound.RefreshCloseSummary()
+	return &rewound
+}
+
+// DiffPositions 比较两个版本的仓位，返回按字段路径排序的变化列表
+// 版本写入时间不属于仓位内容，不参与比较
+func DiffPositions(old, new *Position) ([]FieldChange, error) {
+	oldFields, err := flattenPosition(old)
+	if err != nil {
+		return nil, err
+	}
+	newFields, err := flattenPosition(new)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []FieldChange
+	for field, oldValue := range oldFields {
+		if newValue, ok := newFields[field]; !ok || newValue != oldValue {
+			changes = append(changes, FieldChange{Field: field, Old: oldValue, New: newFields[field]})
+		}
+	}
+	for field, newValue := range newFields {
+		if _, ok := oldFields[field]; !ok {
+			changes = append(changes, FieldChange{Field: field, New: newValue})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+	return changes, nil
+}
+
+// flattenPosition 将仓位的 JSON 表示展开为 字段路径 -> JSON 值
+func flattenPosition(pos *Position) (map[string]string, error) {
+	data, err := json.Marshal(pos)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal position: %w", err)
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("failed to parse position: %w", err)
+	}
+
+	fields := make(map[string]string)
+	flattenValue("", value, fields)
+	delete(fields, "recordedAt")
+	return fields, nil
+}
+
+func flattenValue(path string, value interface{}, fields map[string]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if path == "" {
+				flattenValue(key, child, fields)
+			} else {
+				flattenValue(path+"."+key, child, fields)
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			flattenValue(fmt.Sprintf("%s[%d]", path, i), child, fields)
+		}
+	default:
+		data, _ := json.Marshal(v)
+		fields[path] = strings.TrimSpace(string(data))
+	}
+}
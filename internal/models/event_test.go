@@ -0,0 +1,263 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+// positionVersions 按开仓、调整、部分平仓、重复写入、修改、全部平仓的顺序生成版本快照
+func positionVersions(openTime time.Time) []*Position {
+	opened := &Position{
+		PositionID: "P1",
+		Symbol:     "BTC",
+		OpenTime:   openTime,
+		Direction:  DirectionLong,
+		OpenPrice:  100,
+		Quantity:   2,
+		StopLoss:   90,
+		TakeProfit: 120,
+		Status:     StatusOpen,
+		Entries:    []EntryFill{{Time: openTime, Price: 100, Quantity: 2}},
+	}
+
+	adjusted := *opened
+	adjusted.StopLoss = 95
+	adjusted.Adjustments = []LevelAdjustment{{Time: openTime.Add(time.Hour), OldStopLoss: 90, NewStopLoss: 95, OldTakeProfit: 120, NewTakeProfit: 120}}
+
+	partial := adjusted
+	partial.Quantity = 1
+	partial.Fills = []CloseFill{{CloseTime: openTime.Add(2 * time.Hour), ClosePrice: 110, CloseQuantity: 1, RealizedPnL: 10}}
+	partial.RefreshCloseSummary()
+
+	// 重复写入的版本只有写入时间不同
+	duplicate := partial
+	recordedAt := openTime.Add(4 * time.Hour)
+	duplicate.RecordedAt = &recordedAt
+
+	edited := partial
+	edited.Reason = "突破回踩"
+
+	closed := edited
+	closed.Quantity = 0
+	closed.Status = StatusClosed
+	closed.Fills = append(append([]CloseFill{}, partial.Fills...),
+		CloseFill{CloseTime: openTime.Add(3 * time.Hour), ClosePrice: 112, CloseQuantity: 1, RealizedPnL: 12})
+	closed.RefreshCloseSummary()
+
+	return []*Position{opened, &adjusted, &partial, &duplicate, &edited, &closed}
+}
+
+func TestBuildPositionEvents(t *testing.T) {
+	openTime := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	events, err := BuildPositionEvents(positionVersions(openTime))
+	if err != nil {
+		t.Fatalf("BuildPositionEvents failed: %v", err)
+	}
+
+	expected := []struct {
+		eventType EventType
+		time      time.Time
+		version   int
+	}{
+		{EventOpened, openTime, 1},
+		{EventAdjusted, openTime.Add(time.Hour), 2},
+		{EventPartiallyClosed, openTime.Add(2 * time.Hour), 3},
+		{EventEdited, openTime.Add(2 * time.Hour), 5}, // 版本 4 与版本 3 相同，不产生事件
+		{EventClosed, openTime.Add(3 * time.Hour), 6},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d events, got %d", len(expected), len(events))
+	}
+	for i, want := range expected {
+		got := events[i]
+		if got.Type != want.eventType || !got.Time.Equal(want.time) || got.Version != want.version {
+			t.Errorf("Event %d: expected %s at %v (version %d), got %s at %v (version %d)",
+				i, want.eventType, want.time, want.version, got.Type, got.Time, got.Version)
+		}
+	}
+
+	// 修改事件只包含被修改的字段
+	changes := events[3].Changes
+	if len(changes) != 1 || changes[0].Field != "reason" || changes[0].Old != "" || changes[0].New != `"突破回踩"` {
+		t.Errorf("Expected single reason change, got %+v", changes)
+	}
+}
+
+func TestDiffPositions_NestedFields(t *testing.T) {
+	openTime := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	versions := positionVersions(openTime)
+
+	changes, err := DiffPositions(versions[0], versions[1])
+	if err != nil {
+		t.Fatalf("DiffPositions failed: %v", err)
+	}
+	found := make(map[string]FieldChange)
+	for _, change := range changes {
+		found[change.Field] = change
+	}
+	if c, ok := found["stopLoss"]; !ok || c.Old != "90" || c.New != "95" {
+		t.Errorf("Expected stopLoss 90 -> 95, got %+v", c)
+	}
+	if c, ok := found["adjustments[0].newStopLoss"]; !ok || c.Old != "" || c.New != "95" {
+		t.Errorf("Expected added adjustments[0].newStopLoss, got %+v", c)
+	}
+	if _, ok := found["symbol"]; ok {
+		t.Error("Unchanged field should not be reported")
+	}
+}
+
+func TestReplayPosition(t *testing.T) {
+	openTime := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	events, err := BuildPositionEvents(positionVersions(openTime))
+	if err != nil {
+		t.Fatalf("BuildPositionEvents failed: %v", err)
+	}
+
+	if pos := ReplayPosition(events, openTime.Add(-time.Minute)); pos != nil {
+		t.Errorf("Expected nil before open, got %+v", pos)
+	}
+	if pos := ReplayPosition(events, openTime.Add(90*time.Minute)); pos == nil || pos.StopLoss != 95 || pos.Quantity != 2 {
+		t.Errorf("Expected adjusted position with quantity 2, got %+v", pos)
+	}
+	if pos := ReplayPosition(events, openTime.Add(150*time.Minute)); pos == nil || pos.Quantity != 1 || pos.Reason != "突破回踩" {
+		t.Errorf("Expected partially closed and edited position, got %+v", pos)
+	}
+	if pos := ReplayPosition(events, openTime.Add(4*time.Hour)); pos == nil || pos.Status != StatusClosed {
+		t.Errorf("Expected closed position, got %+v", pos)
+	}
+}
+
+func TestReplayPosition_RewindsCompactedVersion(t *testing.T) {
+	openTime := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	versions := positionVersions(openTime)
+
+	// 压缩后只剩最终版本，中间状态需从成交和调整记录中撤回
+	events, err := BuildPositionEvents(versions[len(versions)-1:])
+	if err != nil {
+		t.Fatalf("BuildPositionEvents failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		status   Status
+		quantity float64
+		stopLoss float64
+		fills    int
+	}{
+		{"开仓后", openTime.Add(30 * time.Minute), StatusOpen, 2, 90, 0},
+		{"调整后", openTime.Add(90 * time.Minute), StatusOpen, 2, 95, 0},
+		{"部分平仓后", openTime.Add(150 * time.Minute), StatusOpen, 1, 95, 1},
+		{"全部平仓后", openTime.Add(4 * time.Hour), StatusClosed, 0, 95, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pos := ReplayPosition(events, tt.at)
+			if pos == nil {
+				t.Fatal("Expected position, got nil")
+			}
+			if pos.Status != tt.status || pos.Quantity != tt.quantity || pos.StopLoss != tt.stopLoss || len(pos.Fills) != tt.fills {
+				t.Errorf("Expected %s qty=%.0f sl=%.0f fills=%d, got %s qty=%.0f sl=%.0f fills=%d",
+					tt.status, tt.quantity, tt.stopLoss, tt.fills, pos.Status, pos.Quantity, pos.StopLoss, len(pos.Fills))
+			}
+		})
+	}
+
+	partial := ReplayPosition(events, openTime.Add(150*time.Minute))
+	if partial.RealizedPnL == nil || *partial.RealizedPnL != 10 {
+		t.Errorf("Expected realized PnL 10 after partial close, got %v", partial.RealizedPnL)
+	}
+	if versions[len(versions)-1].Status != StatusClosed {
+		t.Error("Replay should not modify the stored snapshot")
+	}
+}
+
+func TestEffectiveEvents_Undo(t *testing.T) {
+	openTime := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	versions := positionVersions(openTime)
+
+	// 撤销全部平仓：恢复到修改后的版本并记录撤销
+	undoTime := openTime.Add(4 * time.Hour)
+	undone := *versions[4]
+	undone.Undos = []UndoRecord{{Time: undoTime, OperationID: OperationID("P1", 6), Type: EventClosed}}
+	versions = append(versions, &undone)
+
+	events, err := BuildPositionEvents(versions)
+	if err != nil {
+		t.Fatalf("BuildPositionEvents failed: %v", err)
+	}
+	last := events[len(events)-1]
+	if last.Type != EventUndone || !last.Time.Equal(undoTime) || last.ID != "P1:7" {
+		t.Errorf("Expected undone event P1:7 at %v, got %s %s at %v", undoTime, last.ID, last.Type, last.Time)
+	}
+
+	effective := EffectiveEvents(events)
+	if len(effective) != 4 || effective[len(effective)-1].Type != EventEdited {
+		t.Errorf("Expected 4 effective events ending with edit, got %+v", effective)
+	}
+}
+
+func TestParseOperationID(t *testing.T) {
+	positionID, version, err := ParseOperationID(OperationID("20240301-100000-A1B2", 3))
+	if err != nil || positionID != "20240301-100000-A1B2" || version != 3 {
+		t.Errorf("Expected 20240301-100000-A1B2 version 3, got %s %d (%v)", positionID, version, err)
+	}
+	for _, id := range []string{"", "P1", ":3", "P1:0", "P1:x"} {
+		if _, _, err := ParseOperationID(id); err == nil {
+			t.Errorf("Expected error for %q", id)
+		}
+	}
+}
+
+func TestBuildPositionEvents_EditRecordTime(t *testing.T) {
+	openTime := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	versions := positionVersions(openTime)
+	closed := versions[len(versions)-1]
+
+	// edit 更正品种：事件时间取修改记录的时间
+	editTime := openTime.Add(24 * time.Hour)
+	edited := *closed
+	edited.Symbol = "ETH"
+	edited.Edits = []EditRecord{{Time: editTime, By: "alice", Reason: "品种录入错误"}}
+	versions = append(versions, &edited)
+
+	events, err := BuildPositionEvents(versions)
+	if err != nil {
+		t.Fatalf("BuildPositionEvents failed: %v", err)
+	}
+	last := events[len(events)-1]
+	if last.Type != EventEdited || !last.Time.Equal(editTime) {
+		t.Errorf("Expected edited event at %v, got %s at %v", editTime, last.Type, last.Time)
+	}
+
+	// 更正同样适用于修改之前的时刻
+	if pos := ReplayPosition(events, openTime.Add(30*time.Minute)); pos == nil || pos.Symbol != "ETH" || pos.Status != StatusOpen {
+		t.Errorf("Expected corrected open position, got %+v", pos)
+	}
+}
+
+func TestBuildPositionEvents_VoidAndUnvoid(t *testing.T) {
+	openTime := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	versions := positionVersions(openTime)
+	closed := versions[len(versions)-1]
+
+	voidTime := openTime.Add(24 * time.Hour)
+	voided := *closed
+	voided.Voided = &VoidRecord{Time: voidTime, Reason: "重复录入"}
+
+	unvoidTime := openTime.Add(48 * time.Hour)
+	restored := *closed
+	restored.Unvoids = []VoidRecord{{Time: unvoidTime, Reason: "误操作"}}
+
+	events, err := BuildPositionEvents(append(versions, &voided, &restored))
+	if err != nil {
+		t.Fatalf("BuildPositionEvents failed: %v", err)
+	}
+	n := len(events)
+	if events[n-2].Type != EventVoided || !events[n-2].Time.Equal(voidTime) {
+		t.Errorf("Expected voided event at %v, got %s at %v", voidTime, events[n-2].Type, events[n-2].Time)
+	}
+	if events[n-1].Type != EventUnvoided || !events[n-1].Time.Equal(unvoidTime) {
+		t.Errorf("Expected unvoided event at %v, got %s at %v", unvoidTime, events[n-1].Type, events[n-1].Time)
+	}
+}
@@ -0,0 +1,254 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"trading-journal-cli/internal/fsutil"
+)
+
+// FXRate 汇率：1 单位 Base 货币 = Rate 单位 Quote 货币
+type FXRate struct {
+	Date  string  `json:"date"` // 生效日期 (YYYY-MM-DD)
+	Base  string  `json:"base"`
+	Quote string  `json:"quote"`
+	Rate  float64 `json:"rate"`
+}
+
+// FXConfig 汇率文件结构
+type FXConfig struct {
+	Rates []FXRate `json:"rates"`
+}
+
+// FXTable 汇率表
+//
+// 换算时使用指定日期当天或之前最近的一条汇率；早于所有记录的日期视为缺少汇率。
+// 只登记了反向汇率时取倒数，两种货币之间没有直接汇率时尝试通过一种中间货币换算。
+type FXTable struct {
+	configPath string
+	config     *FXConfig
+}
+
+// NewFXTable 创建汇率表
+func NewFXTable(dataDir string) *FXTable {
+	configPath := filepath.Join(dataDir, "fx-rates.json")
+	return &FXTable{
+		configPath: configPath,
+		config:     &FXConfig{Rates: []FXRate{}},
+	}
+}
+
+// Load 加载汇率文件，文件不存在时视为空表
+func (t *FXTable) Load() error {
+	data, err := os.ReadFile(t.configPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read fx rates: %w", err)
+	}
+
+	// 解析到新的结构中，重新加载时不会残留上次加载的内容
+	config := &FXConfig{Rates: []FXRate{}}
+	if err := json.Unmarshal(data, config); err != nil {
+		return fmt.Errorf("failed to parse fx rates: %w", err)
+	}
+	t.config = config
+
+	return nil
+}
+
+// Save 保存汇率文件（在数据目录锁内写入临时文件后原子替换）
+func (t *FXTable) Save() error {
+	// 获取锁时会创建数据目录
+	lock, err := fsutil.LockDir(filepath.Dir(t.configPath))
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	data, err := json.MarshalIndent(t.config, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal fx rates: %w", err)
+	}
+
+	if err := fsutil.WriteFileAtomic(t.configPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write fx rates: %w", err)
+	}
+
+	return nil
+}
+
+// update 在数据目录锁内重新加载汇率、应用修改并保存
+// 修改基于磁盘上的最新内容，避免覆盖其他进程同时做的修改
+func (t *FXTable) update(apply func() error) error {
+	lock, err := fsutil.LockDir(filepath.Dir(t.configPath))
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	if err := t.Load(); err != nil {
+		return err
+	}
+	if err := apply(); err != nil {
+		return err
+	}
+	return t.Save()
+}
+
+// ListRates 列出所有汇率（按货币对和日期排序）
+func (t *FXTable) ListRates() []FXRate {
+	rates := make([]FXRate, len(t.config.Rates))
+	copy(rates, t.config.Rates)
+	sort.Slice(rates, func(i, j int) bool {
+		pi, pj := rates[i].Base+"/"+rates[i].Quote, rates[j].Base+"/"+rates[j].Quote
+		if pi != pj {
+			return pi < pj
+		}
+		return rates[i].Date < rates[j].Date
+	})
+	return rates
+}
+
+// SetRate 添加汇率，同一货币对同一日期已存在时覆盖
+func (t *FXTable) SetRate(rate FXRate) error {
+	rate.Base = strings.ToUpper(rate.Base)
+	rate.Quote = strings.ToUpper(rate.Quote)
+	if rate.Base == "" || rate.Quote == "" || rate.Base == rate.Quote {
+		return fmt.Errorf("invalid currency pair: %s/%s", rate.Base, rate.Quote)
+	}
+	if rate.Rate <= 0 {
+		return fmt.Errorf("fx rate must be greater than 0")
+	}
+	if _, err := time.Parse("2006-01-02", rate.Date); err != nil {
+		return fmt.Errorf("invalid fx rate date: %w", err)
+	}
+
+	return t.update(func() error {
+		for i := range t.config.Rates {
+			r := t.config.Rates[i]
+			if r.Base == rate.Base && r.Quote == rate.Quote && r.Date == rate.Date {
+				t.config.Rates[i] = rate
+				return nil
+			}
+		}
+
+		t.config.Rates = append(t.config.Rates, rate)
+		return nil
+	})
+}
+
+// DeleteRate 删除指定日期的汇率
+func (t *FXTable) DeleteRate(base, quote, date string) error {
+	base, quote = strings.ToUpper(base), strings.ToUpper(quote)
+	return t.update(func() error {
+		found := false
+		newRates := []FXRate{}
+		for _, r := range t.config.Rates {
+			if r.Base == base && r.Quote == quote && r.Date == date {
+				found = true
+			} else {
+				newRates = append(newRates, r)
+			}
+		}
+
+		if !found {
+			return fmt.Errorf("fx rate not found: %s/%s on %s", base, quote, date)
+		}
+
+		t.config.Rates = newRates
+		return nil
+	})
+}
+
+// Rate 获取指定日期 1 单位 from 货币可兑换的 to 货币数量
+func (t *FXTable) Rate(from, to string, at time.Time) (float64, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return 1, nil
+	}
+
+	if rate, ok := t.pairRate(from, to, at); ok {
+		return rate, nil
+	}
+
+	// 通过中间货币换算
+	for _, via := range t.currencies() {
+		if via == from || via == to {
+			continue
+		}
+		first, ok := t.pairRate(from, via, at)
+		if !ok {
+			continue
+		}
+		second, ok := t.pairRate(via, to, at)
+		if !ok {
+			continue
+		}
+		return first * second, nil
+	}
+
+	return 0, fmt.Errorf("no fx rate for %s/%s on or before %s", from, to, at.Format("2006-01-02"))
+}
+
+// Convert 将金额从 from 货币换算为 to 货币
+func (t *FXTable) Convert(amount float64, from, to string, at time.Time) (float64, error) {
+	rate, err := t.Rate(from, to, at)
+	if err != nil {
+		return 0, err
+	}
+	return amount * rate, nil
+}
+
+// pairRate 查找直接汇率，只登记了反向汇率时取倒数
+func (t *FXTable) pairRate(from, to string, at time.Time) (float64, bool) {
+	if rate, ok := t.lookup(from, to, at); ok {
+		return rate, true
+	}
+	if rate, ok := t.lookup(to, from, at); ok {
+		return 1 / rate, true
+	}
+	return 0, false
+}
+
+// lookup 查找货币对在指定日期当天或之前最近的汇率
+// 只有晚于该日期的汇率时视为没有汇率，不用未来的汇率换算过去的金额
+func (t *FXTable) lookup(base, quote string, at time.Time) (float64, bool) {
+	date := at.Format("2006-01-02")
+	var best *FXRate
+	for i := range t.config.Rates {
+		r := &t.config.Rates[i]
+		if r.Base != base || r.Quote != quote {
+			continue
+		}
+		if r.Date <= date && (best == nil || r.Date > best.Date) {
+			best = r
+		}
+	}
+
+	if best == nil {
+		return 0, false
+	}
+	return best.Rate, true
+}
+
+// currencies 汇率表中出现的所有货币
+func (t *FXTable) currencies() []string {
+	seen := make(map[string]bool)
+	result := []string{}
+	for _, r := range t.config.Rates {
+		for _, c := range []string{r.Base, r.Quote} {
+			if !seen[c] {
+				seen[c] = true
+				result = append(result, c)
+			}
+		}
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFXTable_Rate(t *testing.T) {
+	table := NewFXTable(t.TempDir())
+	for _, rate := range []FXRate{
+		{Date: "2025-01-01", Base: "USD", Quote: "CNY", Rate: 7.0},
+		{Date: "2025-02-01", Base: "USD", Quote: "CNY", Rate: 7.2},
+		{Date: "2025-01-01", Base: "EUR", Quote: "USD", Rate: 1.1},
+	} {
+		if err := table.SetRate(rate); err != nil {
+			t.Fatalf("SetRate failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		at       time.Time
+		expected float64
+	}{
+		{"Same currency", "USD", "USD", time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC), 1},
+		{"Latest rate on or before date", "USD", "CNY", time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC), 7.0},
+		{"Rate effective on date", "USD", "CNY", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), 7.2},
+		{"Inverse rate", "CNY", "USD", time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC), 1 / 7.2},
+		{"Cross rate via USD", "EUR", "CNY", time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC), 1.1 * 7.2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, err := table.Rate(tt.from, tt.to, tt.at)
+			if err != nil {
+				t.Fatalf("Rate failed: %v", err)
+			}
+			if math.Abs(rate-tt.expected) > 1e-9 {
+				t.Errorf("Expected %.6f, got %.6f", tt.expected, rate)
+			}
+		})
+	}
+
+	if _, err := table.Rate("USD", "JPY", time.Now()); err == nil {
+		t.Error("Expected error for missing rate")
+	}
+	if _, err := table.Rate("USD", "CNY", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Error("Expected error for date before the first rate")
+	}
+	if _, err := table.Convert(100, "CNY", "USD", time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Error("Expected error converting with only a later inverse rate")
+	}
+}
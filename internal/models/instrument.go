@@ -0,0 +1,192 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"trading-journal-cli/internal/fsutil"
+)
+
+// Instrument 交易品种配置
+type Instrument struct {
+	Symbol        string     `json:"symbol"`
+	MarketType    MarketType `json:"marketType,omitempty"`
+	Multiplier    float64    `json:"multiplier"`              // 合约乘数/每点价值：价格变动 1 时每单位数量的盈亏
+	TickSize      float64    `json:"tickSize,omitempty"`      // 最小价格变动单位
+	QuoteCurrency string     `json:"quoteCurrency,omitempty"` // 报价币种
+}
+
+// PointValue 每单位数量价格变动 1 对应的盈亏，未设置乘数时为 1
+func (i *Instrument) PointValue() float64 {
+	if i == nil || i.Multiplier <= 0 {
+		return 1
+	}
+	return i.Multiplier
+}
+
+// IsOnTick 价格是否为最小变动单位的整数倍，未设置最小变动单位时总是返回 true
+func (i *Instrument) IsOnTick(price float64) bool {
+	if i == nil || i.TickSize <= 0 {
+		return true
+	}
+	ticks := price / i.TickSize
+	return math.Abs(ticks-math.Round(ticks)) < 1e-6
+}
+
+// InstrumentConfig 品种配置文件结构
+type InstrumentConfig struct {
+	Instruments []Instrument `json:"instruments"`
+}
+
+// InstrumentRegistry 品种注册表
+type InstrumentRegistry struct {
+	configPath string
+	config     *InstrumentConfig
+}
+
+// NewInstrumentRegistry 创建品种注册表
+func NewInstrumentRegistry(dataDir string) *InstrumentRegistry {
+	configPath := filepath.Join(dataDir, "instruments.json")
+	return &InstrumentRegistry{
+		configPath: configPath,
+		config:     &InstrumentConfig{Instruments: []Instrument{}},
+	}
+}
+
+// Load 加载品种配置，文件不存在时视为空注册表
+func (r *InstrumentRegistry) Load() error {
+	data, err := os.ReadFile(r.configPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read instruments config: %w", err)
+	}
+
+	// 解析到新的结构中，重新加载时不会残留上次加载的内容
+	config := &InstrumentConfig{Instruments: []Instrument{}}
+	if err := json.Unmarshal(data, config); err != nil {
+		return fmt.Errorf("failed to parse instruments config: %w", err)
+	}
+	r.config = config
+
+	return nil
+}
+
+// Save 保存品种配置（在数据目录锁内写入临时文件后原子替换）
+func (r *InstrumentRegistry) Save() error {
+	// 获取锁时会创建数据目录
+	lock, err := fsutil.LockDir(filepath.Dir(r.configPath))
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	data, err := json.MarshalIndent(r.config, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal instruments config: %w", err)
+	}
+
+	if err := fsutil.WriteFileAtomic(r.configPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write instruments config: %w", err)
+	}
+
+	return nil
+}
+
+// update 在数据目录锁内重新加载品种配置、应用修改并保存
+// 修改基于磁盘上的最新内容，避免覆盖其他进程同时做的修改
+func (r *InstrumentRegistry) update(apply func() error) error {
+	lock, err := fsutil.LockDir(filepath.Dir(r.configPath))
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	if err := r.Load(); err != nil {
+		return err
+	}
+	if err := apply(); err != nil {
+		return err
+	}
+	return r.Save()
+}
+
+// ListInstruments 列出所有品种（按品种名排序）
+func (r *InstrumentRegistry) ListInstruments() []Instrument {
+	instruments := make([]Instrument, len(r.config.Instruments))
+	copy(instruments, r.config.Instruments)
+	sort.Slice(instruments, func(i, j int) bool {
+		return instruments[i].Symbol < instruments[j].Symbol
+	})
+	return instruments
+}
+
+// GetInstrument 获取指定品种
+func (r *InstrumentRegistry) GetInstrument(symbol string) (*Instrument, error) {
+	for _, inst := range r.config.Instruments {
+		if inst.Symbol == symbol {
+			return &inst, nil
+		}
+	}
+	return nil, fmt.Errorf("instrument not found: %s", symbol)
+}
+
+// PointValue 品种的每点价值，未登记的品种为 1
+func (r *InstrumentRegistry) PointValue(symbol string) float64 {
+	inst, err := r.GetInstrument(symbol)
+	if err != nil {
+		return 1
+	}
+	return inst.PointValue()
+}
+
+// SetInstrument 添加品种，已存在时覆盖
+func (r *InstrumentRegistry) SetInstrument(instrument Instrument) error {
+	if instrument.Symbol == "" {
+		return fmt.Errorf("instrument symbol is required")
+	}
+	if instrument.Multiplier <= 0 {
+		return fmt.Errorf("instrument multiplier must be greater than 0")
+	}
+	if instrument.TickSize < 0 {
+		return fmt.Errorf("instrument tick size must not be negative")
+	}
+
+	return r.update(func() error {
+		for i := range r.config.Instruments {
+			if r.config.Instruments[i].Symbol == instrument.Symbol {
+				r.config.Instruments[i] = instrument
+				return nil
+			}
+		}
+
+		r.config.Instruments = append(r.config.Instruments, instrument)
+		return nil
+	})
+}
+
+// DeleteInstrument 删除品种
+func (r *InstrumentRegistry) DeleteInstrument(symbol string) error {
+	return r.update(func() error {
+		found := false
+		newInstruments := []Instrument{}
+		for _, inst := range r.config.Instruments {
+			if inst.Symbol != symbol {
+				newInstruments = append(newInstruments, inst)
+			} else {
+				found = true
+			}
+		}
+
+		if !found {
+			return fmt.Errorf("instrument not found: %s", symbol)
+		}
+
+		r.config.Instruments = newInstruments
+		return nil
+	})
+}
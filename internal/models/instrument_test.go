@@ -0,0 +1,71 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestInstrumentRegistry_SetAndLoad(t *testing.T) {
+	dataDir := t.TempDir()
+
+	registry := NewInstrumentRegistry(dataDir)
+	if err := registry.Load(); err != nil {
+		t.Fatalf("Load on missing file should succeed, got %v", err)
+	}
+	if pv := registry.PointValue("ES"); pv != 1 {
+		t.Errorf("Expected point value 1 for unregistered symbol, got %g", pv)
+	}
+
+	if err := registry.SetInstrument(Instrument{Symbol: "ES", MarketType: MarketTypeFutures, Multiplier: 50, TickSize: 0.25}); err != nil {
+		t.Fatalf("SetInstrument failed: %v", err)
+	}
+	// 覆盖已有配置
+	if err := registry.SetInstrument(Instrument{Symbol: "ES", MarketType: MarketTypeFutures, Multiplier: 50, TickSize: 0.25, QuoteCurrency: "USD"}); err != nil {
+		t.Fatalf("SetInstrument failed: %v", err)
+	}
+
+	reloaded := NewInstrumentRegistry(dataDir)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(reloaded.ListInstruments()) != 1 {
+		t.Fatalf("Expected 1 instrument, got %d", len(reloaded.ListInstruments()))
+	}
+	inst, err := reloaded.GetInstrument("ES")
+	if err != nil {
+		t.Fatalf("GetInstrument failed: %v", err)
+	}
+	if inst.QuoteCurrency != "USD" {
+		t.Errorf("Expected quote currency USD, got %s", inst.QuoteCurrency)
+	}
+	if pv := reloaded.PointValue("ES"); pv != 50 {
+		t.Errorf("Expected point value 50, got %g", pv)
+	}
+
+	if err := reloaded.SetInstrument(Instrument{Symbol: "NQ", Multiplier: 0}); err == nil {
+		t.Error("Expected error for zero multiplier")
+	}
+}
+
+func TestInstrument_IsOnTick(t *testing.T) {
+	inst := &Instrument{Symbol: "ES", Multiplier: 50, TickSize: 0.25}
+
+	tests := []struct {
+		price float64
+		want  bool
+	}{
+		{5000.25, true},
+		{5000.75, true},
+		{5000.10, false},
+	}
+
+	for _, tt := range tests {
+		if got := inst.IsOnTick(tt.price); got != tt.want {
+			t.Errorf("IsOnTick(%.2f) = %v, want %v", tt.price, got, tt.want)
+		}
+	}
+
+	var unregistered *Instrument
+	if !unregistered.IsOnTick(1.2345) || unregistered.PointValue() != 1 {
+		t.Error("Nil instrument should accept any price with point value 1")
+	}
+}
@@ -0,0 +1,156 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+	"trading-journal-cli/internal/fsutil"
+)
+
+// LedgerEntryType 资金流水类型
+type LedgerEntryType string
+
+const (
+	LedgerDeposit     LedgerEntryType = "deposit"      // 入金
+	LedgerWithdrawal  LedgerEntryType = "withdrawal"   // 出金
+	LedgerRealizedPnL LedgerEntryType = "realized_pnl" // 平仓盈亏（毛盈亏）
+	LedgerFee         LedgerEntryType = "fee"          // 交易费用及资金费
+	LedgerAdjustment  LedgerEntryType = "adjustment"   // 余额调整（期初余额、手动校正）
+)
+
+// LedgerEntry 账户资金流水
+//
+// 流水只追加不修改，账户余额等于该账户所有流水金额之和。
+// 更正已记录的流水时追加一条金额为差额的新流水。
+type LedgerEntry struct {
+	EntryID    string          `json:"entryId"`
+	Account    string          `json:"account"`
+	Time       time.Time       `json:"time"`       // 资金变动发生时间
+	RecordedAt time.Time       `json:"recordedAt"` // 流水写入时间
+	Type       LedgerEntryType `json:"type"`
+	Amount     float64         `json:"amount"` // 带符号金额：入金和盈利为正，出金、亏损和费用为负
+	PositionID string          `json:"positionId,omitempty"`
+	Note       string          `json:"note,omitempty"`
+}
+
+// NewLedgerEntry 创建资金流水
+func NewLedgerEntry(account string, entryType LedgerEntryType, amount float64, at time.Time, note string) LedgerEntry {
+	return LedgerEntry{
+		EntryID:    GeneratePositionID(),
+		Account:    account,
+		Time:       at,
+		RecordedAt: time.Now(),
+		Type:       entryType,
+		Amount:     amount,
+		Note:       note,
+	}
+}
+
+// ledgerPath 资金流水文件路径（与账户配置位于同一目录）
+func (am *AccountManager) ledgerPath() string {
+	return filepath.Join(filepath.Dir(am.configPath), "ledger.jsonl")
+}
+
+// ReadLedger 读取账户的资金流水（按发生时间排序），accountName 为空时返回所有账户的流水
+func (am *AccountManager) ReadLedger(accountName string) ([]LedgerEntry, error) {
+	if _, err := fsutil.RepairTornTail(am.ledgerPath()); err != nil {
+		return nil, err
+	}
+
+	entries := []LedgerEntry{}
+	err := fsutil.ReadLines(am.ledgerPath(), func(lineNum int, line []byte) error {
+		var entry LedgerEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping invalid JSON at line %d in ledger: %v\n", lineNum, err)
+			return nil
+		}
+		if accountName == "" || entry.Account == accountName {
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading ledger: %w", err)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+
+	return entries, nil
+}
+
+// AppendLedgerEntries 追加资金流水并按流水重新计算账户余额
+func (am *AccountManager) AppendLedgerEntries(entries ...LedgerEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	// 追加流水和更新余额在同一把锁内完成
+	lock, err := am.Lock()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+	if err := am.Load(); err != nil {
+		return err
+	}
+
+	lines := make([][]byte, 0, len(entries))
+	for _, entry := range entries {
+		if _, err := am.GetAccount(entry.Account); err != nil {
+			return err
+		}
+		switch entry.Type {
+		case LedgerDeposit:
+			if entry.Amount <= 0 {
+				return fmt.Errorf("deposit amount must be greater than 0")
+			}
+		case LedgerWithdrawal:
+			if entry.Amount >= 0 {
+				return fmt.Errorf("withdrawal amount must be negative")
+			}
+		case LedgerRealizedPnL, LedgerFee, LedgerAdjustment:
+		default:
+			return fmt.Errorf("invalid ledger entry type: %s", entry.Type)
+		}
+
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to marshal ledger entry: %w", err)
+		}
+		lines = append(lines, data)
+	}
+
+	if err := fsutil.AppendLines(am.ledgerPath(), lines...); err != nil {
+		return fmt.Errorf("failed to write ledger entries: %w", err)
+	}
+
+	return am.RecalculateBalances()
+}
+
+// RecalculateBalances 按资金流水重新计算账户余额
+// 还没有任何流水的账户（旧数据）保留配置文件中的余额
+func (am *AccountManager) RecalculateBalances() error {
+	return am.update(func() error {
+		entries, err := am.ReadLedger("")
+		if err != nil {
+			return err
+		}
+
+		balances := make(map[string]float64)
+		for _, entry := range entries {
+			balances[entry.Account] += entry.Amount
+		}
+
+		for i := range am.config.Accounts {
+			if balance, ok := balances[am.config.Accounts[i].Name]; ok {
+				am.config.Accounts[i].Balance = balance
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAppendLedgerEntries_DerivesBalance(t *testing.T) {
+	am := NewAccountManager(t.TempDir())
+	if err := am.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if err := am.AddAccount(Account{Name: "main", Balance: 1000, Currency: "USD"}); err != nil {
+		t.Fatalf("AddAccount failed: %v", err)
+	}
+
+	now := time.Now()
+	entries := []LedgerEntry{
+		NewLedgerEntry("main", LedgerDeposit, 500, now, ""),
+		NewLedgerEntry("main", LedgerWithdrawal, -200, now, ""),
+		NewLedgerEntry("main", LedgerRealizedPnL, 80, now, ""),
+		NewLedgerEntry("main", LedgerFee, -5, now, ""),
+	}
+	if err := am.AppendLedgerEntries(entries...); err != nil {
+		t.Fatalf("AppendLedgerEntries failed: %v", err)
+	}
+
+	account, err := am.GetAccount("main")
+	if err != nil {
+		t.Fatalf("GetAccount failed: %v", err)
+	}
+	if account.Balance != 1375 {
+		t.Errorf("Expected balance 1375.00, got %.2f", account.Balance)
+	}
+
+	ledger, err := am.ReadLedger("main")
+	if err != nil {
+		t.Fatalf("ReadLedger failed: %v", err)
+	}
+	// 初始资金 + 4 笔流水
+	if len(ledger) != 5 {
+		t.Errorf("Expected 5 ledger entries, got %d", len(ledger))
+	}
+}
+
+func TestAppendLedgerEntries_Invalid(t *testing.T) {
+	am := NewAccountManager(t.TempDir())
+	if err := am.AddAccount(Account{Name: "main"}); err != nil {
+		t.Fatalf("AddAccount failed: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		entry LedgerEntry
+	}{
+		{"Unknown account", NewLedgerEntry("other", LedgerDeposit, 100, time.Now(), "")},
+		{"Negative deposit", NewLedgerEntry("main", LedgerDeposit, -100, time.Now(), "")},
+		{"Positive withdrawal", NewLedgerEntry("main", LedgerWithdrawal, 100, time.Now(), "")},
+		{"Unknown type", NewLedgerEntry("main", LedgerEntryType("bonus"), 100, time.Now(), "")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := am.AppendLedgerEntries(tt.entry); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
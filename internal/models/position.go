@@ -2,7 +2,10 @@ package models
 
 import (
 	"crypto/rand"
+	"encoding/json"
 	"fmt"
+	"math"
+	"sort"
 	"time"
 )
 
@@ -48,9 +51,9 @@ const (
 type MarketContext string
 
 const (
-	MarketContextBull MarketContext = "bull"   // 牛市
-	MarketContextBear MarketContext = "bear"   // 熊市
-	MarketContextNone MarketContext = "none"   // 不判断
+	MarketContextBull MarketContext = "bull" // 牛市
+	MarketContextBear MarketContext = "bear" // 熊市
+	MarketContextNone MarketContext = "none" // 不判断
 )
 
 // Position 仓位信息
@@ -59,6 +62,7 @@ type Position struct {
 	PositionID     string     `json:"positionId"`
 	AccountName    string     `json:"accountName"`              // 账户名称
 	AccountBalance float64    `json:"accountBalance,omitempty"` // 开仓时的账户余额
+	Currency       string     `json:"currency,omitempty"`       // 开仓时的账户币种，为空（旧记录）时按当前账户配置
 	Symbol         string     `json:"symbol"`
 	MarketType     MarketType `json:"marketType"`
 	OpenTime       time.Time  `json:"openTime"`
@@ -68,27 +72,424 @@ type Position struct {
 	StopLoss       float64    `json:"stopLoss"`
 	TakeProfit     float64    `json:"takeProfit"`
 	Margin         float64    `json:"margin"`
+	Multiplier     float64    `json:"multiplier,omitempty"`  // 开仓时品种配置中的合约乘数，为空时按品种注册表或 1 计算
+	InitialRisk    float64    `json:"initialRisk,omitempty"` // 初始风险（1R）：开仓和加仓时按当时止损计算的可能亏损，之后调整止损不会改变
 	Reason         string     `json:"reason,omitempty"`
 	Status         Status     `json:"status"`
 
+	// 违反账户风控限制时强制开仓的记录
+	RiskOverride *RiskOverride `json:"riskOverride,omitempty"`
+
+	// 作废记录，作废的仓位默认不参与查询和统计
+	Voided *VoidRecord `json:"voided,omitempty"`
+
 	// 市场背景信息（可选）
-	MarketContext        MarketContext `json:"marketContext,omitempty"`        // 市场背景：牛市/熊市
-	MarketPhase          string        `json:"marketPhase,omitempty"`          // 市场阶段（如"牛市末期"）
-	EMA20Broken          bool          `json:"ema20Broken,omitempty"`          // 日线是否跌破EMA20并反抽失败
-	VolumeDecrease       bool          `json:"volumeDecrease,omitempty"`       // 创新高但成交量明显低于前高
-	ConsecutiveLowBreak  bool          `json:"consecutiveLowBreak,omitempty"`  // 连续两次回调都打穿前低
-	MarketNote           string        `json:"marketNote,omitempty"`           // 市场背景备注
+	MarketContext       MarketContext `json:"marketContext,omitempty"`       // 市场背景：牛市/熊市
+	MarketPhase         string        `json:"marketPhase,omitempty"`         // 市场阶段（如"牛市末期"）
+	EMA20Broken         bool          `json:"ema20Broken,omitempty"`         // 日线是否跌破EMA20并反抽失败
+	VolumeDecrease      bool          `json:"volumeDecrease,omitempty"`      // 创新高但成交量明显低于前高
+	ConsecutiveLowBreak bool          `json:"consecutiveLowBreak,omitempty"` // 连续两次回调都打穿前低
+	MarketNote          string        `json:"marketNote,omitempty"`          // 市场背景备注
 
 	// 平仓信息（可选）
 	CloseTime       *time.Time   `json:"closeTime,omitempty"`
 	ClosePrice      *float64     `json:"closePrice,omitempty"`
 	CloseQuantity   *float64     `json:"closeQuantity,omitempty"`
-	RealizedPnL     *float64     `json:"realizedPnL,omitempty"`
-	PnLPercentage   *float64     `json:"pnlPercentage,omitempty"`   // 占账户余额的百分比
-	MarginROI       *float64     `json:"marginROI,omitempty"`       // 保证金回报率
+	RealizedPnL     *float64     `json:"realizedPnL,omitempty"`   // 扣除费用后的净盈亏
+	GrossPnL        *float64     `json:"grossPnL,omitempty"`      // 扣除费用前的毛盈亏
+	TotalFees       *float64     `json:"totalFees,omitempty"`     // 开仓、加仓和平仓的累计费用
+	PnLPercentage   *float64     `json:"pnlPercentage,omitempty"` // 占账户余额的百分比（按净盈亏）
+	MarginROI       *float64     `json:"marginROI,omitempty"`     // 保证金回报率（按净盈亏）
+	RMultiple       *float64     `json:"rMultiple,omitempty"`     // 净盈亏 / 初始风险
 	HoldingDuration *string      `json:"holdingDuration,omitempty"`
 	CloseReason     *CloseReason `json:"closeReason,omitempty"`
 	CloseNote       string       `json:"closeNote,omitempty"`
+
+	// 开仓成交历史（首次开仓和每次加仓各一条）
+	// OpenPrice 为剩余持仓的成交量加权平均开仓价
+	Entries []EntryFill `json:"entries,omitempty"`
+
+	// 止损止盈调整历史
+	Adjustments []LevelAdjustment `json:"adjustments,omitempty"`
+
+	// 平仓成交历史（每次部分平仓/全部平仓追加一条）
+	// 上面的平仓字段是根据成交历史汇总得到的：累计盈亏、成交量加权平均平仓价、累计平仓数量
+	Fills []CloseFill `json:"fills,omitempty"`
+
+	// 撤销操作历史
+	Undos []UndoRecord `json:"undos,omitempty"`
+
+	// 修改记录（更正录入错误的开仓信息）
+	Edits []EditRecord `json:"edits,omitempty"`
+
+	// 恢复作废的记录
+	Unvoids []VoidRecord `json:"unvoids,omitempty"`
+
+	// 版本写入时间，由存储追加版本时设置，用于按写入顺序比较不同仓位的操作（旧记录没有）
+	RecordedAt *time.Time `json:"recordedAt,omitempty"`
+}
+
+// RiskOverride 强制开仓记录
+type RiskOverride struct {
+	Time       time.Time `json:"time"`
+	Reason     string    `json:"reason"`
+	Violations []string  `json:"violations"` // 被忽略的风控限制
+}
+
+// VoidRecord 仓位作废或恢复记录
+type VoidRecord struct {
+	Time   time.Time `json:"time"`
+	Reason string    `json:"reason"`
+}
+
+// EditRecord 仓位修改记录
+type EditRecord struct {
+	Time   time.Time `json:"time"`
+	By     string    `json:"by,omitempty"` // 修改人
+	Reason string    `json:"reason"`       // 修改原因
+}
+
+// Fees 交易费用（正数为支出，资金费/隔夜利息为收入时可为负数）
+type Fees struct {
+	Commission  float64 `json:"commission,omitempty"`  // 佣金
+	ExchangeFee float64 `json:"exchangeFee,omitempty"` // 交易所/监管费用
+	Funding     float64 `json:"funding,omitempty"`     // 资金费/隔夜利息累计
+}
+
+// Total 费用合计
+func (f *Fees) Total() float64 {
+	if f == nil {
+		return 0
+	}
+	return f.Commission + f.ExchangeFee + f.Funding
+}
+
+// EntryFill 单次开仓/加仓成交记录
+type EntryFill struct {
+	Time     time.Time `json:"time"`
+	Price    float64   `json:"price"`
+	Quantity float64   `json:"quantity"`
+	Margin   float64   `json:"margin"`
+	Fees     *Fees     `json:"fees,omitempty"`
+	Note     string    `json:"note,omitempty"`
+}
+
+// LevelAdjustment 止损止盈调整记录
+type LevelAdjustment struct {
+	Time           time.Time `json:"time"`
+	OldStopLoss    float64   `json:"oldStopLoss"`
+	NewStopLoss    float64   `json:"newStopLoss"`
+	OldTakeProfit  float64   `json:"oldTakeProfit"`
+	NewTakeProfit  float64   `json:"newTakeProfit"`
+	ReferencePrice float64   `json:"referencePrice,omitempty"` // 调整时的当前价格（可选）
+	Note           string    `json:"note,omitempty"`
+}
+
+// CloseFill 单次平仓成交记录
+type CloseFill struct {
+	CloseTime     time.Time   `json:"closeTime"`
+	ClosePrice    float64     `json:"closePrice"`
+	CloseQuantity float64     `json:"closeQuantity"`
+	GrossPnL      float64     `json:"grossPnL"`
+	Fees          *Fees       `json:"fees,omitempty"`
+	RealizedPnL   float64     `json:"realizedPnL"` // 毛盈亏减去本次平仓费用
+	CloseReason   CloseReason `json:"closeReason"`
+	CloseNote     string      `json:"closeNote,omitempty"`
+	ManualPnL     bool        `json:"manualPnL,omitempty"` // 盈亏为手动输入
+}
+
+// UnmarshalJSON 反序列化仓位，并将旧版单次平仓记录转换为一条成交记录
+func (p *Position) UnmarshalJSON(data []byte) error {
+	type positionAlias Position
+	var alias positionAlias
+	if err := json.Unmarshal(data, &alias); err != nil {
+		return err
+	}
+	*p = Position(alias)
+
+	// 旧版记录只有汇总字段，没有成交历史
+	if len(p.Fills) == 0 && p.ClosePrice != nil && p.CloseQuantity != nil {
+		fill := CloseFill{
+			ClosePrice:    *p.ClosePrice,
+			CloseQuantity: *p.CloseQuantity,
+			CloseNote:     p.CloseNote,
+		}
+		if p.CloseTime != nil {
+			fill.CloseTime = *p.CloseTime
+		}
+		if p.RealizedPnL != nil {
+			fill.RealizedPnL = *p.RealizedPnL
+			fill.GrossPnL = *p.RealizedPnL
+		}
+		if p.CloseReason != nil {
+			fill.CloseReason = *p.CloseReason
+		}
+		p.Fills = []CloseFill{fill}
+	}
+
+	// 旧版成交记录没有费用信息，毛盈亏即净盈亏
+	for i := range p.Fills {
+		if p.Fills[i].Fees == nil && p.Fills[i].GrossPnL == 0 {
+			p.Fills[i].GrossPnL = p.Fills[i].RealizedPnL
+		}
+	}
+
+	// 旧版记录没有开仓历史，按开仓信息视为一次开仓（数量包含已平仓部分）
+	if len(p.Entries) == 0 && p.OpenPrice > 0 {
+		p.Entries = []EntryFill{{
+			Time:     p.OpenTime,
+			Price:    p.OpenPrice,
+			Quantity: p.Quantity + p.ClosedQuantity(),
+			Margin:   p.Margin,
+		}}
+	}
+
+	return nil
+}
+
+// AddEntry 加仓：追加开仓成交，并按剩余持仓重新计算加权平均开仓价、数量和保证金
+// 数量按 NormalizeQuantity 消除浮点误差，加仓后全部平仓时剩余数量恰好为 0
+func (p *Position) AddEntry(entry EntryFill) {
+	totalQuantity := NormalizeQuantity(p.Quantity + entry.Quantity)
+	if totalQuantity > 0 {
+		p.OpenPrice = (p.OpenPrice*p.Quantity + entry.Price*entry.Quantity) / totalQuantity
+	}
+	p.Quantity = totalQuantity
+	p.Margin += entry.Margin
+	p.Entries = append(p.Entries, entry)
+}
+
+// TotalEntryQuantity 累计开仓数量（包含加仓）
+func (p *Position) TotalEntryQuantity() float64 {
+	var total float64
+	for _, entry := range p.Entries {
+		total += entry.Quantity
+	}
+	return total
+}
+
+// ClosedQuantity 累计已平仓数量
+func (p *Position) ClosedQuantity() float64 {
+	var total float64
+	for _, fill := range p.Fills {
+		total += fill.CloseQuantity
+	}
+	return total
+}
+
+// TotalRealizedPnL 累计已实现净盈亏
+// 首次平仓起即扣除全部开仓/加仓费用
+func (p *Position) TotalRealizedPnL() float64 {
+	if len(p.Fills) == 0 {
+		return 0
+	}
+	var total float64
+	for _, fill := range p.Fills {
+		total += fill.RealizedPnL
+	}
+	return total - p.EntryFees()
+}
+
+// RealizedAmount 某一时刻实现的毛盈亏和费用
+type RealizedAmount struct {
+	Time     time.Time
+	GrossPnL float64
+	Fees     float64 // 费用合计（正数为支出）
+}
+
+// NetPnL 净盈亏
+func (a RealizedAmount) NetPnL() float64 {
+	return a.GrossPnL - a.Fees
+}
+
+// RealizedAmounts 按时间顺序列出仓位每次平仓实现的毛盈亏和费用
+//
+// 首次平仓之前的开仓和加仓费用计入首次平仓，之后的加仓费用按加仓时间单独列出，已列出的金额不会因之后的成交改变。
+// 没有平仓成交时为空，合计与 TotalGrossPnL、TotalFeesPaid 一致（首次平仓起扣除全部开仓费用）。
+func (p *Position) RealizedAmounts() []RealizedAmount {
+	if len(p.Fills) == 0 {
+		return nil
+	}
+	amounts := make([]RealizedAmount, 0, len(p.Fills))
+	for _, fill := range p.Fills {
+		amounts = append(amounts, RealizedAmount{Time: fill.CloseTime, GrossPnL: fill.GrossPnL, Fees: fill.Fees.Total()})
+	}
+	sort.SliceStable(amounts, func(i, j int) bool {
+		return amounts[i].Time.Before(amounts[j].Time)
+	})
+
+	first := amounts[0].Time
+	for _, entry := range p.Entries {
+		fees := entry.Fees.Total()
+		if fees == 0 {
+			continue
+		}
+		if !entry.Time.After(first) {
+			amounts[0].Fees += fees
+			continue
+		}
+		amounts = append(amounts, RealizedAmount{Time: entry.Time, Fees: fees})
+	}
+	sort.SliceStable(amounts, func(i, j int) bool {
+		return amounts[i].Time.Before(amounts[j].Time)
+	})
+	return amounts
+}
+
+// TotalGrossPnL 累计已实现毛盈亏（未扣除费用）
+func (p *Position) TotalGrossPnL() float64 {
+	var total float64
+	for _, fill := range p.Fills {
+		total += fill.GrossPnL
+	}
+	return total
+}
+
+// EntryFees 开仓和加仓费用合计
+func (p *Position) EntryFees() float64 {
+	var total float64
+	for _, entry := range p.Entries {
+		total += entry.Fees.Total()
+	}
+	return total
+}
+
+// TotalFeesPaid 开仓、加仓和平仓费用合计
+func (p *Position) TotalFeesPaid() float64 {
+	total := p.EntryFees()
+	for _, fill := range p.Fills {
+		total += fill.Fees.Total()
+	}
+	return total
+}
+
+// ReplayEntries 按时间顺序重放开仓、加仓和平仓成交，重新计算加权平均开仓价、数量和保证金
+// pointValue 大于 0 时按每次平仓时的加权平均开仓价重新计算成交的毛盈亏和净盈亏，手动输入的盈亏保持不变
+func (p *Position) ReplayEntries(pointValue float64) {
+	type step struct {
+		time  time.Time
+		entry *EntryFill
+		fill  int
+	}
+	entries := p.Entries
+	p.Fills = append([]CloseFill(nil), p.Fills...)
+
+	var steps []step
+	for i := range entries {
+		steps = append(steps, step{time: entries[i].Time, entry: &entries[i]})
+	}
+	for i := range p.Fills {
+		steps = append(steps, step{time: p.Fills[i].CloseTime, fill: i})
+	}
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].time.Before(steps[j].time)
+	})
+
+	p.Entries = nil
+	p.OpenPrice = 0
+	p.Quantity = 0
+	p.Margin = 0
+	for _, s := range steps {
+		if s.entry != nil {
+			p.AddEntry(*s.entry)
+			continue
+		}
+		fill := &p.Fills[s.fill]
+		if pointValue > 0 && !fill.ManualPnL {
+			fill.GrossPnL = CalculateRealizedPnL(p.Direction, p.OpenPrice, fill.ClosePrice, fill.CloseQuantity) * pointValue
+			fill.RealizedPnL = fill.GrossPnL - fill.Fees.Total()
+		}
+		p.Quantity = NormalizeQuantity(p.Quantity - fill.CloseQuantity)
+	}
+}
+
+// StopLossAt t 时刻生效的止损价：t 之前最后一次调整后的值，没有调整时为开仓时的止损
+func (p *Position) StopLossAt(t time.Time) float64 {
+	stopLoss := p.InitialStopLoss()
+	for _, adj := range p.Adjustments {
+		if adj.Time.After(t) {
+			break
+		}
+		stopLoss = adj.NewStopLoss
+	}
+	return stopLoss
+}
+
+// InitialTakeProfit 开仓时的止盈价：有调整记录时取第一次调整前的值
+func (p *Position) InitialTakeProfit() float64 {
+	if len(p.Adjustments) > 0 {
+		return p.Adjustments[0].OldTakeProfit
+	}
+	return p.TakeProfit
+}
+
+// InitialStopLoss 开仓时的止损价：有调整记录时取第一次调整前的值
+func (p *Position) InitialStopLoss() float64 {
+	if len(p.Adjustments) > 0 {
+		return p.Adjustments[0].OldStopLoss
+	}
+	return p.StopLoss
+}
+
+// AverageClosePrice 成交量加权平均平仓价
+func (p *Position) AverageClosePrice() float64 {
+	var notional, quantity float64
+	for _, fill := range p.Fills {
+		notional += fill.ClosePrice * fill.CloseQuantity
+		quantity += fill.CloseQuantity
+	}
+	if quantity == 0 {
+		return 0
+	}
+	return notional / quantity
+}
+
+// RefreshCloseSummary 根据成交历史重新计算平仓汇总字段
+func (p *Position) RefreshCloseSummary() {
+	if len(p.Fills) == 0 {
+		p.CloseTime = nil
+		p.ClosePrice = nil
+		p.CloseQuantity = nil
+		p.RealizedPnL = nil
+		p.GrossPnL = nil
+		p.TotalFees = nil
+		p.PnLPercentage = nil
+		p.MarginROI = nil
+		p.RMultiple = nil
+		p.HoldingDuration = nil
+		p.CloseReason = nil
+		p.CloseNote = ""
+		return
+	}
+
+	last := p.Fills[len(p.Fills)-1]
+	closeTime := last.CloseTime
+	closePrice := p.AverageClosePrice()
+	closeQuantity := p.ClosedQuantity()
+	realizedPnL := p.TotalRealizedPnL()
+	grossPnL := p.TotalGrossPnL()
+	totalFees := p.TotalFeesPaid()
+	pnlPercentage := CalculatePnLPercentage(realizedPnL, p.AccountBalance)
+	marginROI := CalculateMarginROI(realizedPnL, p.Margin)
+	holdingDuration := FormatHoldingDuration(closeTime.Sub(p.OpenTime))
+	closeReason := last.CloseReason
+
+	p.CloseTime = &closeTime
+	p.ClosePrice = &closePrice
+	p.CloseQuantity = &closeQuantity
+	p.RealizedPnL = &realizedPnL
+	p.GrossPnL = &grossPnL
+	p.TotalFees = &totalFees
+	p.PnLPercentage = &pnlPercentage
+	p.MarginROI = &marginROI
+	p.RMultiple = nil
+	if p.InitialRisk > 0 {
+		rMultiple := realizedPnL / p.InitialRisk
+		p.RMultiple = &rMultiple
+	}
+	p.HoldingDuration = &holdingDuration
+	p.CloseReason = &closeReason
+	p.CloseNote = last.CloseNote
 }
 
 // GeneratePositionID 生成唯一的仓位ID
@@ -118,6 +519,41 @@ func CalculateRealizedPnL(direction Direction, openPrice, closePrice, quantity f
 	return (openPrice - closePrice) * quantity
 }
 
+// QuantityEpsilon 数量比较的容差，小于该值的差异视为浮点运算误差
+const QuantityEpsilon = 1e-9
+
+// NormalizeQuantity 消除数量加减产生的浮点误差：按容差四舍五入，绝对值小于容差时为 0
+func NormalizeQuantity(quantity float64) float64 {
+	if math.Abs(quantity) < QuantityEpsilon {
+		return 0
+	}
+	return math.Round(quantity*(1/QuantityEpsilon)) / (1 / QuantityEpsilon)
+}
+
+// CalculateRiskAmount 计算止损触发时的亏损金额，止损不在亏损一侧时为 0
+func CalculateRiskAmount(direction Direction, openPrice, stopLoss, quantity, pointValue float64) float64 {
+	risk := -CalculateRealizedPnL(direction, openPrice, stopLoss, quantity) * pointValue
+	if risk < 0 {
+		return 0
+	}
+	return risk
+}
+
+// CalculatePositionQuantity 按风险金额和止损距离计算仓位数量
+// step 为数量的最小单位（0 表示不取整），数量向下取整以保证实际风险不超过风险金额
+func CalculatePositionQuantity(riskAmount, openPrice, stopLoss, pointValue, step float64) float64 {
+	riskPerUnit := math.Abs(openPrice-stopLoss) * pointValue
+	if riskPerUnit == 0 || riskAmount <= 0 {
+		return 0
+	}
+	quantity := riskAmount / riskPerUnit
+	if step > 0 {
+		// 容差避免浮点误差把恰好整除的数量向下多取一档
+		quantity = math.Floor(quantity/step+1e-9) * step
+	}
+	return quantity
+}
+
 // CalculatePnLPercentage 计算盈亏占账户余额的百分比
 func CalculatePnLPercentage(realizedPnL, accountBalance float64) float64 {
 	if accountBalance == 0 {
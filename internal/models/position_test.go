@@ -1,6 +1,8 @@
 package models
 
 import (
+	"encoding/json"
+	"math"
 	"testing"
 	"time"
 )
@@ -183,3 +185,333 @@ func TestFormatHoldingDuration(t *testing.T) {
 		})
 	}
 }
+
+func TestRefreshCloseSummary_MultipleFills(t *testing.T) {
+	openTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	pos := &Position{
+		Direction:      DirectionLong,
+		OpenTime:       openTime,
+		OpenPrice:      100.0,
+		Margin:         1000.0,
+		AccountBalance: 10000.0,
+		Fills: []CloseFill{
+			{CloseTime: openTime.Add(time.Hour), ClosePrice: 110.0, CloseQuantity: 1.0, RealizedPnL: 10.0, CloseReason: CloseReasonManual},
+			{CloseTime: openTime.Add(2 * time.Hour), ClosePrice: 120.0, CloseQuantity: 3.0, RealizedPnL: 60.0, CloseReason: CloseReasonTakeProfit},
+		},
+	}
+
+	pos.RefreshCloseSummary()
+
+	if *pos.RealizedPnL != 70.0 {
+		t.Errorf("Expected cumulative PnL 70.00, got %.2f", *pos.RealizedPnL)
+	}
+	if *pos.CloseQuantity != 4.0 {
+		t.Errorf("Expected close quantity 4.00, got %.2f", *pos.CloseQuantity)
+	}
+	if *pos.ClosePrice != 117.5 {
+		t.Errorf("Expected weighted close price 117.50, got %.2f", *pos.ClosePrice)
+	}
+	if math.Abs(*pos.PnLPercentage-0.7) > 1e-9 {
+		t.Errorf("Expected PnL percentage 0.70, got %.2f", *pos.PnLPercentage)
+	}
+	if *pos.CloseReason != CloseReasonTakeProfit {
+		t.Errorf("Expected close reason from last fill, got %s", *pos.CloseReason)
+	}
+	if *pos.HoldingDuration != "2h 0m" {
+		t.Errorf("Expected holding duration 2h 0m, got %s", *pos.HoldingDuration)
+	}
+}
+
+func TestUnmarshalLegacyClose(t *testing.T) {
+	data := []byte(`{"positionId":"20250101-100000-AAAA","status":"closed",` +
+		`"closeTime":"2025-01-02T10:00:00Z","closePrice":110,"closeQuantity":2,` +
+		`"realizedPnL":20,"closeReason":"take_profit","closeNote":"done"}`)
+
+	var pos Position
+	if err := json.Unmarshal(data, &pos); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(pos.Fills) != 1 {
+		t.Fatalf("Expected legacy close to be read as 1 fill, got %d", len(pos.Fills))
+	}
+	fill := pos.Fills[0]
+	if fill.ClosePrice != 110 || fill.CloseQuantity != 2 || fill.RealizedPnL != 20 {
+		t.Errorf("Unexpected fill values: %+v", fill)
+	}
+	if fill.CloseReason != CloseReasonTakeProfit || fill.CloseNote != "done" {
+		t.Errorf("Unexpected fill reason/note: %+v", fill)
+	}
+	if pos.TotalRealizedPnL() != 20 {
+		t.Errorf("Expected total PnL 20.00, got %.2f", pos.TotalRealizedPnL())
+	}
+}
+
+func TestAddEntry_WeightedAverage(t *testing.T) {
+	pos := &Position{
+		OpenPrice: 100.0,
+		Quantity:  2.0,
+		Margin:    200.0,
+		Entries:   []EntryFill{{Price: 100.0, Quantity: 2.0, Margin: 200.0}},
+	}
+
+	pos.AddEntry(EntryFill{Price: 130.0, Quantity: 1.0, Margin: 150.0})
+
+	if pos.OpenPrice != 110.0 {
+		t.Errorf("Expected weighted open price 110.00, got %.2f", pos.OpenPrice)
+	}
+	if pos.Quantity != 3.0 {
+		t.Errorf("Expected quantity 3.00, got %.2f", pos.Quantity)
+	}
+	if pos.Margin != 350.0 {
+		t.Errorf("Expected margin 350.00, got %.2f", pos.Margin)
+	}
+	if len(pos.Entries) != 2 || pos.TotalEntryQuantity() != 3.0 {
+		t.Errorf("Expected 2 entries totalling 3.00, got %d totalling %.2f", len(pos.Entries), pos.TotalEntryQuantity())
+	}
+}
+
+func TestUnmarshalLegacyEntry(t *testing.T) {
+	data := []byte(`{"positionId":"20250101-100000-AAAA","openTime":"2025-01-01T10:00:00Z",` +
+		`"openPrice":100,"quantity":1,"margin":100,"status":"open",` +
+		`"closePrice":110,"closeQuantity":2,"realizedPnL":20}`)
+
+	var pos Position
+	if err := json.Unmarshal(data, &pos); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(pos.Entries) != 1 {
+		t.Fatalf("Expected legacy open to be read as 1 entry, got %d", len(pos.Entries))
+	}
+	if pos.Entries[0].Quantity != 3 {
+		t.Errorf("Expected entry quantity to include closed part (3), got %.2f", pos.Entries[0].Quantity)
+	}
+}
+
+func TestRefreshCloseSummary_WithFees(t *testing.T) {
+	openTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	pos := &Position{
+		OpenTime:       openTime,
+		Margin:         100.0,
+		AccountBalance: 1000.0,
+		Entries: []EntryFill{
+			{Price: 100.0, Quantity: 2.0, Fees: &Fees{Commission: 1.0}},
+		},
+		Fills: []CloseFill{
+			{CloseTime: openTime.Add(time.Hour), GrossPnL: 20.0, Fees: &Fees{Commission: 1.0, Funding: 0.5}, RealizedPnL: 18.5},
+		},
+	}
+
+	pos.RefreshCloseSummary()
+
+	if *pos.GrossPnL != 20.0 {
+		t.Errorf("Expected gross PnL 20.00, got %.2f", *pos.GrossPnL)
+	}
+	if *pos.TotalFees != 2.5 {
+		t.Errorf("Expected total fees 2.50, got %.2f", *pos.TotalFees)
+	}
+	if *pos.RealizedPnL != 17.5 {
+		t.Errorf("Expected net PnL 17.50, got %.2f", *pos.RealizedPnL)
+	}
+	if *pos.MarginROI != 17.5 {
+		t.Errorf("Expected margin ROI on net PnL 17.50, got %.2f", *pos.MarginROI)
+	}
+}
+
+func TestCalculateRiskAmount(t *testing.T) {
+	tests := []struct {
+		name       string
+		direction  Direction
+		openPrice  float64
+		stopLoss   float64
+		quantity   float64
+		pointValue float64
+		expected   float64
+	}{
+		{"Long", DirectionLong, 100.0, 90.0, 2.0, 1.0, 20.0},
+		{"Short", DirectionShort, 100.0, 105.0, 2.0, 1.0, 10.0},
+		{"With multiplier", DirectionLong, 5000.0, 4990.0, 1.0, 50.0, 500.0},
+		{"Stop beyond entry", DirectionLong, 100.0, 101.0, 1.0, 1.0, 0.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := CalculateRiskAmount(tt.direction, tt.openPrice, tt.stopLoss, tt.quantity, tt.pointValue)
+			if result != tt.expected {
+				t.Errorf("Expected %.2f, got %.2f", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestRefreshCloseSummary_RMultiple(t *testing.T) {
+	openTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	pos := &Position{
+		OpenTime:    openTime,
+		InitialRisk: 20.0,
+		Entries:     []EntryFill{{Price: 100.0, Quantity: 2.0}},
+		Fills: []CloseFill{
+			{CloseTime: openTime.Add(time.Hour), GrossPnL: 30.0, RealizedPnL: 30.0},
+		},
+	}
+
+	pos.RefreshCloseSummary()
+
+	if pos.RMultiple == nil || *pos.RMultiple != 1.5 {
+		t.Errorf("Expected R multiple 1.50, got %v", pos.RMultiple)
+	}
+
+	// 没有初始风险时不计算 R 倍数
+	pos.InitialRisk = 0
+	pos.RefreshCloseSummary()
+	if pos.RMultiple != nil {
+		t.Errorf("Expected nil R multiple without initial risk, got %.2f", *pos.RMultiple)
+	}
+}
+
+func TestCalculatePositionQuantity(t *testing.T) {
+	tests := []struct {
+		name       string
+		riskAmount float64
+		openPrice  float64
+		stopLoss   float64
+		pointValue float64
+		step       float64
+		expected   float64
+	}{
+		{"No rounding", 100.0, 100.0, 95.0, 1.0, 0, 20.0},
+		{"Short side", 100.0, 100.0, 104.0, 1.0, 0, 25.0},
+		{"Rounded down to step", 100.0, 100.0, 97.0, 1.0, 1.0, 33.0},
+		{"Exact multiple of step", 100.0, 100.0, 95.0, 1.0, 0.5, 20.0},
+		{"With multiplier", 1000.0, 5000.0, 4990.0, 50.0, 1.0, 2.0},
+		{"Smaller than one step", 100.0, 5000.0, 4990.0, 50.0, 1.0, 0.0},
+		{"Zero stop distance", 100.0, 100.0, 100.0, 1.0, 0, 0.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := CalculatePositionQuantity(tt.riskAmount, tt.openPrice, tt.stopLoss, tt.pointValue, tt.step)
+			if result != tt.expected {
+				t.Errorf("Expected %.4f, got %.4f", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestReplayEntries_RecalculatesFills(t *testing.T) {
+	openTime := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	pos := &Position{
+		Direction: DirectionLong,
+		Entries: []EntryFill{
+			{Time: openTime, Price: 102, Quantity: 2, Margin: 100},
+			{Time: openTime.Add(2 * time.Hour), Price: 110, Quantity: 1, Margin: 50},
+		},
+		Fills: []CloseFill{
+			{CloseTime: openTime.Add(time.Hour), ClosePrice: 110, CloseQuantity: 1, GrossPnL: 10, RealizedPnL: 9, Fees: &Fees{Commission: 1}},
+			{CloseTime: openTime.Add(3 * time.Hour), ClosePrice: 120, CloseQuantity: 2, GrossPnL: 50, RealizedPnL: 50, ManualPnL: true},
+		},
+	}
+	original := pos.Fills
+
+	pos.ReplayEntries(1)
+
+	// 第一笔平仓按开仓价 102 计算；加仓后剩余 2 的加权平均开仓价为 (102*1+110*1)/2
+	if pos.Fills[0].GrossPnL != 8 || pos.Fills[0].RealizedPnL != 7 {
+		t.Errorf("Expected first fill gross 8 net 7, got %.2f / %.2f", pos.Fills[0].GrossPnL, pos.Fills[0].RealizedPnL)
+	}
+	if pos.Fills[1].GrossPnL != 50 {
+		t.Errorf("Manual PnL should be kept, got %.2f", pos.Fills[1].GrossPnL)
+	}
+	if pos.OpenPrice != 106 || pos.Quantity != 0 || pos.Margin != 150 || len(pos.Entries) != 2 {
+		t.Errorf("Expected openPrice 106, quantity 0, margin 150, got %.2f %.2f %.2f", pos.OpenPrice, pos.Quantity, pos.Margin)
+	}
+	if original[0].GrossPnL != 10 {
+		t.Error("ReplayEntries should not modify the original fills")
+	}
+}
+
+func TestNormalizeQuantity(t *testing.T) {
+	tests := []struct {
+		name     string
+		quantity float64
+		expected float64
+	}{
+		{"Exact", 0.5, 0.5},
+		{"Partial close remainder", 0.3 - 0.1, 0.2},
+		{"Sum of entries", 0.1 + 0.2, 0.3},
+		{"Residual after full close", 0.1 + 0.2 - 0.3, 0},
+		{"Negative residual", 0.3 - 0.1 - 0.2, 0},
+		{"Small but real quantity", 0.00001, 0.00001},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeQuantity(tt.quantity); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestAddEntry_NormalizesQuantity(t *testing.T) {
+	openTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	pos := &Position{
+		Direction: DirectionLong,
+		OpenPrice: 100.0,
+		Quantity:  0.1,
+		Entries:   []EntryFill{{Time: openTime, Price: 100.0, Quantity: 0.1}},
+	}
+
+	pos.AddEntry(EntryFill{Time: openTime.Add(time.Hour), Price: 100.0, Quantity: 0.2})
+	if pos.Quantity != 0.3 {
+		t.Errorf("Expected quantity 0.3, got %v", pos.Quantity)
+	}
+
+	// 重放后全部平仓的剩余数量为 0，而不是浮点误差
+	pos.Fills = []CloseFill{{CloseTime: openTime.Add(2 * time.Hour), ClosePrice: 110.0, CloseQuantity: 0.3}}
+	pos.ReplayEntries(1)
+	if pos.Quantity != 0 {
+		t.Errorf("Expected quantity 0 after replaying the full close, got %v", pos.Quantity)
+	}
+}
+
+func TestRealizedAmounts(t *testing.T) {
+	openTime := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	pos := &Position{
+		Entries: []EntryFill{
+			{Time: openTime, Quantity: 2, Fees: &Fees{Commission: 1}},
+			{Time: openTime.Add(time.Hour), Quantity: 1, Fees: &Fees{Commission: 0.5}},
+			{Time: openTime.Add(3 * time.Hour), Quantity: 1, Fees: &Fees{Commission: 2}},
+		},
+	}
+	if amounts := pos.RealizedAmounts(); len(amounts) != 0 {
+		t.Fatalf("Expected no realized amounts before the first close, got %v", amounts)
+	}
+
+	pos.Fills = []CloseFill{
+		{CloseTime: openTime.Add(4 * time.Hour), GrossPnL: -5, Fees: &Fees{Commission: 0.25}},
+		{CloseTime: openTime.Add(2 * time.Hour), GrossPnL: 10},
+	}
+
+	// 首次平仓之前的开仓费用计入首次平仓，之后的加仓费用按加仓时间列出
+	expected := []RealizedAmount{
+		{Time: openTime.Add(2 * time.Hour), GrossPnL: 10, Fees: 1.5},
+		{Time: openTime.Add(3 * time.Hour), Fees: 2},
+		{Time: openTime.Add(4 * time.Hour), GrossPnL: -5, Fees: 0.25},
+	}
+	amounts := pos.RealizedAmounts()
+	if len(amounts) != len(expected) {
+		t.Fatalf("Expected %d amounts, got %v", len(expected), amounts)
+	}
+	var net float64
+	for i, want := range expected {
+		if !amounts[i].Time.Equal(want.Time) || amounts[i].GrossPnL != want.GrossPnL || amounts[i].Fees != want.Fees {
+			t.Errorf("Amount %d: expected %+v, got %+v", i, want, amounts[i])
+		}
+		net += amounts[i].NetPnL()
+	}
+	if net != pos.TotalGrossPnL()-pos.TotalFeesPaid() {
+		t.Errorf("Expected net %.2f to match total gross minus fees %.2f", net, pos.TotalGrossPnL()-pos.TotalFeesPaid())
+	}
+}
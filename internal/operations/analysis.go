@@ -2,121 +2,227 @@ package operations
 
 import (
 	"fmt"
+	"math"
+	"sort"
 	"time"
 	"trading-journal-cli/internal/models"
 )
 
 // RiskReport 风险报告
 type RiskReport struct {
-	TotalMargin          float64
-	MaxPossibleLoss      float64
-	RiskExposurePercent  float64
-	PositionCount        int
-	PositionRisks        []PositionRisk
-	ConcentrationByType  map[models.MarketType]float64
-	ConcentrationBySymbol map[string]float64
-	Warnings             []string
+	Currency              string                        `json:"currency"`       // 报告币种，所有金额均已换算
+	AsOf                  *time.Time                    `json:"asOf,omitempty"` // 按版本历史重建的时刻，为空表示当前状态
+	TotalMargin           float64                       `json:"totalMargin"`
+	MaxPossibleLoss       float64                       `json:"maxPossibleLoss"`
+	RiskExposurePercent   float64                       `json:"riskExposurePercent"`
+	PositionCount         int                           `json:"positionCount"`
+	PositionRisks         []PositionRisk                `json:"positionRisks"`
+	ConcentrationByType   map[models.MarketType]float64 `json:"concentrationByType"`
+	ConcentrationBySymbol map[string]float64            `json:"concentrationBySymbol"`
+	Warnings              []string                      `json:"warnings"`
 }
 
 // PositionRisk 单个仓位风险
 type PositionRisk struct {
-	PositionID      string
-	Symbol          string
-	Direction       models.Direction
-	Margin          float64
-	PossibleLoss    float64
-	RiskRewardRatio float64
+	PositionID      string           `json:"positionId"`
+	AccountName     string           `json:"accountName"`
+	Symbol          string           `json:"symbol"`
+	Direction       models.Direction `json:"direction"`
+	Margin          float64          `json:"margin"`
+	PossibleLoss    float64          `json:"possibleLoss"`
+	RiskRewardRatio float64          `json:"riskRewardRatio"`
 }
 
 // PerformanceReport 表现报告
 type PerformanceReport struct {
-	TotalTrades        int
-	WinningTrades      int
-	LosingTrades       int
-	WinRate            float64
-	TotalPnL           float64
-	TotalPnLPercentage float64
-	AveragePnL         float64
-	BestTrade          *models.Position
-	WorstTrade         *models.Position
-	BySymbol           map[string]*SymbolStats
-	ByMarketType       map[models.MarketType]*MarketTypeStats
-	ByCloseReason      map[models.CloseReason]int
-	AverageHoldingTime time.Duration
+	Currency           string                                 `json:"currency"`       // 报告币种，所有金额均已换算
+	AsOf               *time.Time                             `json:"asOf,omitempty"` // 按版本历史重建的时刻，为空表示当前状态
+	TotalTrades        int                                    `json:"totalTrades"`
+	OpenTrades         int                                    `json:"openTrades"` // 其中仍未平仓（已部分平仓）的仓位数
+	WinningTrades      int                                    `json:"winningTrades"`
+	LosingTrades       int                                    `json:"losingTrades"`
+	WinRate            float64                                `json:"winRate"`
+	TotalPnL           float64                                `json:"totalPnL"` // 净盈亏
+	TotalGrossPnL      float64                                `json:"totalGrossPnL"`
+	TotalFees          float64                                `json:"totalFees"`
+	TotalPnLPercentage float64                                `json:"totalPnLPercentage"` // 净盈亏占各账户期初资金（换算后合计）的百分比
+	AveragePnL         float64                                `json:"averagePnL"`
+	BestTrade          *models.Position                       `json:"bestTrade,omitempty"`
+	WorstTrade         *models.Position                       `json:"worstTrade,omitempty"`
+	BySymbol           map[string]*SymbolStats                `json:"bySymbol"`
+	ByMarketType       map[models.MarketType]*MarketTypeStats `json:"byMarketType"`
+	ByCloseReason      map[models.CloseReason]int             `json:"byCloseReason"`
+	AverageHoldingTime time.Duration                          `json:"averageHoldingTime"`
+	RStats             RStats                                 `json:"rStats"`
+	EdgeStats
+}
+
+// RStats 以初始风险（R）衡量的交易结果，只统计能确定初始风险的交易
+type RStats struct {
+	Trades       int       `json:"trades"`
+	TotalR       float64   `json:"totalR"`
+	AverageR     float64   `json:"averageR"`
+	Distribution []RBucket `json:"distribution"`
+}
+
+// RBucket R 倍数分布的一个区间 [Min, Max)，首尾区间无下限/上限
+type RBucket struct {
+	Label string   `json:"label"`
+	Min   *float64 `json:"min,omitempty"`
+	Max   *float64 `json:"max,omitempty"`
+	Count int      `json:"count"`
+}
+
+// rBucketBounds R 倍数分布的区间边界
+var rBucketBounds = []float64{-2, -1, 0, 1, 2, 3}
+
+// newRDistribution 按区间边界创建空的 R 倍数分布
+func newRDistribution() []RBucket {
+	buckets := make([]RBucket, 0, len(rBucketBounds)+1)
+	for i := 0; i <= len(rBucketBounds); i++ {
+		var bucket RBucket
+		switch {
+		case i == 0:
+			bucket.Max = &rBucketBounds[0]
+			bucket.Label = fmt.Sprintf("< %gR", rBucketBounds[0])
+		case i == len(rBucketBounds):
+			bucket.Min = &rBucketBounds[i-1]
+			bucket.Label = fmt.Sprintf(">= %gR", rBucketBounds[i-1])
+		default:
+			bucket.Min = &rBucketBounds[i-1]
+			bucket.Max = &rBucketBounds[i]
+			bucket.Label = fmt.Sprintf("%gR ~ %gR", rBucketBounds[i-1], rBucketBounds[i])
+		}
+		buckets = append(buckets, bucket)
+	}
+	return buckets
+}
+
+// add 将一笔交易的 R 倍数计入统计
+func (s *RStats) add(r float64) {
+	s.Trades++
+	s.TotalR += r
+	for i := range s.Distribution {
+		bucket := &s.Distribution[i]
+		if (bucket.Min == nil || r >= *bucket.Min) && (bucket.Max == nil || r < *bucket.Max) {
+			bucket.Count++
+			break
+		}
+	}
+}
+
+// EdgeStats 交易优势统计，盈亏均为换算后的净盈亏
+type EdgeStats struct {
+	GrossProfit          float64  `json:"grossProfit"`            // 盈利交易的盈亏合计
+	GrossLoss            float64  `json:"grossLoss"`              // 亏损交易的盈亏合计（负数）
+	AverageWin           float64  `json:"averageWin"`             // 平均盈利
+	AverageLoss          float64  `json:"averageLoss"`            // 平均亏损（负数）
+	PayoffRatio          *float64 `json:"payoffRatio,omitempty"`  // 盈亏比：平均盈利 / |平均亏损|，没有亏损时为空
+	ProfitFactor         *float64 `json:"profitFactor,omitempty"` // 盈利因子：总盈利 / |总亏损|，没有亏损时为空
+	Expectancy           float64  `json:"expectancy"`             // 每笔期望：胜率 × 平均盈利 + 败率 × 平均亏损
+	MaxConsecutiveWins   int      `json:"maxConsecutiveWins"`     // 最大连续盈利笔数
+	MaxConsecutiveLosses int      `json:"maxConsecutiveLosses"`   // 最大连续亏损笔数
+	PnLStdDev            float64  `json:"pnlStdDev"`              // 单笔盈亏的样本标准差
 }
 
 // SymbolStats 品种统计
 type SymbolStats struct {
-	Symbol        string
-	TotalTrades   int
-	WinningTrades int
-	WinRate       float64
-	TotalPnL      float64
-	AveragePnL    float64
+	Symbol        string  `json:"symbol"`
+	TotalTrades   int     `json:"totalTrades"`
+	WinningTrades int     `json:"winningTrades"`
+	WinRate       float64 `json:"winRate"`
+	TotalPnL      float64 `json:"totalPnL"`
+	AveragePnL    float64 `json:"averagePnL"`
+	TotalFees     float64 `json:"totalFees"`
+	EdgeStats
 }
 
 // MarketTypeStats 市场类型统计
 type MarketTypeStats struct {
-	MarketType    models.MarketType
-	TotalTrades   int
-	WinningTrades int
-	WinRate       float64
-	TotalPnL      float64
-	AveragePnL    float64
+	MarketType    models.MarketType `json:"marketType"`
+	TotalTrades   int               `json:"totalTrades"`
+	WinningTrades int               `json:"winningTrades"`
+	WinRate       float64           `json:"winRate"`
+	TotalPnL      float64           `json:"totalPnL"`
+	AveragePnL    float64           `json:"averagePnL"`
+	TotalFees     float64           `json:"totalFees"`
+	EdgeStats
 }
 
 // AnalyzeRisk 分析风险
-func (o *Operations) AnalyzeRisk() (*RiskReport, error) {
-	openPositions, err := o.storage.ReadOpenPositions()
+// 只统计未平仓位，filter 中的状态条件会被忽略
+func (o *Operations) AnalyzeRisk(filter FilterParams) (*RiskReport, error) {
+	filter.Status = string(models.StatusOpen)
+	openPositions, err := o.ListPositions(filter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read open positions: %w", err)
 	}
 
+	converter := o.NewCurrencyConverter(openPositions, filter.Currency)
+	now := time.Now()
+
 	report := &RiskReport{
+		Currency:              converter.Currency(),
 		PositionRisks:         make([]PositionRisk, 0),
 		ConcentrationByType:   make(map[models.MarketType]float64),
 		ConcentrationBySymbol: make(map[string]float64),
 		Warnings:              make([]string, 0),
 	}
+	if !filter.AsOf.IsZero() {
+		now = filter.AsOf
+		report.AsOf = &filter.AsOf
+	}
 
 	// 计算总保证金和最大可能损失
 	for _, pos := range openPositions {
-		report.TotalMargin += pos.Margin
+		// 保证金按当前汇率换算为报告币种
+		margin, err := converter.ConvertPosition(pos.Margin, pos, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert position %s: %w", pos.PositionID, err)
+		}
+		report.TotalMargin += margin
 		report.PositionCount++
 
-		// 计算单个仓位的可能损失
+		// 计算单个仓位的可能损失（按合约乘数换算）
+		pointValue := o.pointValue(pos)
 		var possibleLoss float64
 		if pos.Direction == models.DirectionLong {
-			possibleLoss = (pos.OpenPrice - pos.StopLoss) * pos.Quantity
+			possibleLoss = (pos.OpenPrice - pos.StopLoss) * pos.Quantity * pointValue
 		} else {
-			possibleLoss = (pos.StopLoss - pos.OpenPrice) * pos.Quantity
+			possibleLoss = (pos.StopLoss - pos.OpenPrice) * pos.Quantity * pointValue
 		}
-		report.MaxPossibleLoss += possibleLoss
 
 		// 计算风险回报比
 		var riskRewardRatio float64
 		if possibleLoss > 0 {
 			var potentialProfit float64
 			if pos.Direction == models.DirectionLong {
-				potentialProfit = (pos.TakeProfit - pos.OpenPrice) * pos.Quantity
+				potentialProfit = (pos.TakeProfit - pos.OpenPrice) * pos.Quantity * pointValue
 			} else {
-				potentialProfit = (pos.OpenPrice - pos.TakeProfit) * pos.Quantity
+				potentialProfit = (pos.OpenPrice - pos.TakeProfit) * pos.Quantity * pointValue
 			}
 			riskRewardRatio = potentialProfit / possibleLoss
 		}
 
+		possibleLoss, err = converter.ConvertPosition(possibleLoss, pos, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert position %s: %w", pos.PositionID, err)
+		}
+		report.MaxPossibleLoss += possibleLoss
+
 		report.PositionRisks = append(report.PositionRisks, PositionRisk{
 			PositionID:      pos.PositionID,
+			AccountName:     pos.AccountName,
 			Symbol:          pos.Symbol,
 			Direction:       pos.Direction,
-			Margin:          pos.Margin,
+			Margin:          margin,
 			PossibleLoss:    possibleLoss,
 			RiskRewardRatio: riskRewardRatio,
 		})
 
 		// 统计仓位集中度
-		report.ConcentrationByType[pos.MarketType] += pos.Margin
-		report.ConcentrationBySymbol[pos.Symbol] += pos.Margin
+		report.ConcentrationByType[pos.MarketType] += margin
+		report.ConcentrationBySymbol[pos.Symbol] += margin
 	}
 
 	// 生成风险预警
@@ -154,38 +260,87 @@ func (o *Operations) AnalyzeRisk() (*RiskReport, error) {
 }
 
 // AnalyzePerformance 分析表现
-func (o *Operations) AnalyzePerformance(fromDate, toDate time.Time) (*PerformanceReport, error) {
-	// 读取所有已平仓位
-	allPositions, err := o.storage.ReadAllPositions()
+// 筛选条件与 ListPositions 一致，统计所有有平仓成交的仓位，包括已部分平仓但仍未平仓的仓位（只计已实现的部分），
+// filter 中的状态条件会被忽略
+func (o *Operations) AnalyzePerformance(filter FilterParams) (*PerformanceReport, error) {
+	filter.Status = "all"
+	positions, err := o.ListPositions(filter)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read positions: %w", err)
+		return nil, err
 	}
+	var tradedPositions []*models.Position
+	for _, pos := range positions {
+		if len(pos.Fills) > 0 {
+			tradedPositions = append(tradedPositions, pos)
+		}
+	}
+
+	converter := o.NewCurrencyConverter(tradedPositions, filter.Currency)
 
 	report := &PerformanceReport{
+		Currency:      converter.Currency(),
 		BySymbol:      make(map[string]*SymbolStats),
 		ByMarketType:  make(map[models.MarketType]*MarketTypeStats),
 		ByCloseReason: make(map[models.CloseReason]int),
+		RStats:        RStats{Distribution: newRDistribution()},
+	}
+	if !filter.AsOf.IsZero() {
+		report.AsOf = &filter.AsOf
 	}
 
 	var totalHoldingSeconds int64
-
-	for _, pos := range allPositions {
-		// 只统计已平仓位
-		if pos.Status != models.StatusClosed {
-			continue
+	var closedTrades int
+	var bestPnL, worstPnL float64
+
+	// 连续盈亏按（最近一次）平仓时间计算，各分组的单笔盈亏按同样顺序收集
+	sort.SliceStable(tradedPositions, func(i, j int) bool {
+		return closeTimeOrNow(tradedPositions[i]).Before(closeTimeOrNow(tradedPositions[j]))
+	})
+	var allPnLs []float64
+	symbolPnLs := make(map[string][]float64)
+	marketTypePnLs := make(map[models.MarketType][]float64)
+
+	// 百分比以各账户期初资金为基数：每个账户取报告中开仓最早的仓位记录的开仓时余额
+	openingBalances := make(map[string]*models.Position)
+	for _, pos := range tradedPositions {
+		if first, ok := openingBalances[pos.AccountName]; !ok || pos.OpenTime.Before(first.OpenTime) {
+			openingBalances[pos.AccountName] = pos
 		}
-
-		// 日期范围筛选
-		if !fromDate.IsZero() && pos.OpenTime.Before(fromDate) {
-			continue
+	}
+	var openingCapital float64
+	for _, pos := range openingBalances {
+		balance, err := converter.ConvertPosition(pos.AccountBalance, pos, pos.OpenTime)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert position %s: %w", pos.PositionID, err)
 		}
-		if !toDate.IsZero() && pos.OpenTime.After(toDate) {
-			continue
+		openingCapital += balance
+	}
+
+	for _, pos := range tradedPositions {
+		// 累计每次平仓实现的盈亏和费用（按各自发生时的汇率换算为报告币种）
+		var pnl, grossPnL, fees float64
+		for _, amount := range pos.RealizedAmounts() {
+			gross, err := converter.ConvertPosition(amount.GrossPnL, pos, amount.Time)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert position %s: %w", pos.PositionID, err)
+			}
+			fee, err := converter.ConvertPosition(amount.Fees, pos, amount.Time)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert position %s: %w", pos.PositionID, err)
+			}
+			grossPnL += gross
+			fees += fee
+			pnl += gross - fee
 		}
 
 		report.TotalTrades++
-		pnl := *pos.RealizedPnL
+		if pos.Status != models.StatusClosed {
+			report.OpenTrades++
+		}
 		report.TotalPnL += pnl
+		report.TotalGrossPnL += grossPnL
+		report.TotalFees += fees
+		allPnLs = append(allPnLs, pnl)
 
 		// 统计盈亏
 		if pnl > 0 {
@@ -194,12 +349,19 @@ func (o *Operations) AnalyzePerformance(fromDate, toDate time.Time) (*Performanc
 			report.LosingTrades++
 		}
 
+		// R 倍数与币种无关，不需要换算
+		if r, ok := o.RMultiple(pos); ok {
+			report.RStats.add(r)
+		}
+
 		// 记录最佳和最差交易
-		if report.BestTrade == nil || pnl > *report.BestTrade.RealizedPnL {
+		if report.BestTrade == nil || pnl > bestPnL {
 			report.BestTrade = pos
+			bestPnL = pnl
 		}
-		if report.WorstTrade == nil || pnl < *report.WorstTrade.RealizedPnL {
+		if report.WorstTrade == nil || pnl < worstPnL {
 			report.WorstTrade = pos
+			worstPnL = pnl
 		}
 
 		// 按品种统计
@@ -212,6 +374,8 @@ func (o *Operations) AnalyzePerformance(fromDate, toDate time.Time) (*Performanc
 			stats.WinningTrades++
 		}
 		stats.TotalPnL += pnl
+		stats.TotalFees += fees
+		symbolPnLs[pos.Symbol] = append(symbolPnLs[pos.Symbol], pnl)
 
 		// 按市场类型统计
 		if _, exists := report.ByMarketType[pos.MarketType]; !exists {
@@ -223,16 +387,19 @@ func (o *Operations) AnalyzePerformance(fromDate, toDate time.Time) (*Performanc
 			mtStats.WinningTrades++
 		}
 		mtStats.TotalPnL += pnl
+		mtStats.TotalFees += fees
+		marketTypePnLs[pos.MarketType] = append(marketTypePnLs[pos.MarketType], pnl)
 
 		// 按平仓原因统计
 		if pos.CloseReason != nil {
 			report.ByCloseReason[*pos.CloseReason]++
 		}
 
-		// 统计持仓时长
-		if pos.CloseTime != nil {
+		// 统计持仓时长（只统计已平仓位）
+		if pos.Status == models.StatusClosed && pos.CloseTime != nil {
 			duration := pos.CloseTime.Sub(pos.OpenTime)
 			totalHoldingSeconds += int64(duration.Seconds())
+			closedTrades++
 		}
 	}
 
@@ -240,22 +407,92 @@ func (o *Operations) AnalyzePerformance(fromDate, toDate time.Time) (*Performanc
 	if report.TotalTrades > 0 {
 		report.WinRate = float64(report.WinningTrades) / float64(report.TotalTrades) * 100
 		report.AveragePnL = report.TotalPnL / float64(report.TotalTrades)
-		report.AverageHoldingTime = time.Duration(totalHoldingSeconds/int64(report.TotalTrades)) * time.Second
+		report.TotalPnLPercentage = percentOf(report.TotalPnL, openingCapital)
+		if closedTrades > 0 {
+			report.AverageHoldingTime = time.Duration(totalHoldingSeconds/int64(closedTrades)) * time.Second
+		}
+		report.EdgeStats = calculateEdgeStats(allPnLs)
+		if report.RStats.Trades > 0 {
+			report.RStats.AverageR = report.RStats.TotalR / float64(report.RStats.Trades)
+		}
 
-		for _, stats := range report.BySymbol {
+		for symbol, stats := range report.BySymbol {
 			if stats.TotalTrades > 0 {
 				stats.WinRate = float64(stats.WinningTrades) / float64(stats.TotalTrades) * 100
 				stats.AveragePnL = stats.TotalPnL / float64(stats.TotalTrades)
+				stats.EdgeStats = calculateEdgeStats(symbolPnLs[symbol])
 			}
 		}
 
-		for _, stats := range report.ByMarketType {
+		for marketType, stats := range report.ByMarketType {
 			if stats.TotalTrades > 0 {
 				stats.WinRate = float64(stats.WinningTrades) / float64(stats.TotalTrades) * 100
 				stats.AveragePnL = stats.TotalPnL / float64(stats.TotalTrades)
+				stats.EdgeStats = calculateEdgeStats(marketTypePnLs[marketType])
 			}
 		}
 	}
 
 	return report, nil
 }
+
+// calculateEdgeStats 根据按平仓时间排列的单笔盈亏计算交易优势统计
+// 盈亏为 0 的交易既不算盈利也不算亏损，并中断连续盈亏
+func calculateEdgeStats(pnls []float64) EdgeStats {
+	var stats EdgeStats
+	if len(pnls) == 0 {
+		return stats
+	}
+
+	var wins, losses, winStreak, lossStreak int
+	var total float64
+	for _, pnl := range pnls {
+		total += pnl
+		switch {
+		case pnl > 0:
+			wins++
+			stats.GrossProfit += pnl
+			winStreak++
+			lossStreak = 0
+		case pnl < 0:
+			losses++
+			stats.GrossLoss += pnl
+			lossStreak++
+			winStreak = 0
+		default:
+			winStreak = 0
+			lossStreak = 0
+		}
+		if winStreak > stats.MaxConsecutiveWins {
+			stats.MaxConsecutiveWins = winStreak
+		}
+		if lossStreak > stats.MaxConsecutiveLosses {
+			stats.MaxConsecutiveLosses = lossStreak
+		}
+	}
+
+	count := float64(len(pnls))
+	if wins > 0 {
+		stats.AverageWin = stats.GrossProfit / float64(wins)
+	}
+	if losses > 0 {
+		stats.AverageLoss = stats.GrossLoss / float64(losses)
+		payoffRatio := stats.AverageWin / -stats.AverageLoss
+		profitFactor := stats.GrossProfit / -stats.GrossLoss
+		stats.PayoffRatio = &payoffRatio
+		stats.ProfitFactor = &profitFactor
+	}
+	stats.Expectancy = float64(wins)/count*stats.AverageWin + float64(losses)/count*stats.AverageLoss
+
+	// 样本标准差，少于两笔交易时为 0
+	if len(pnls) > 1 {
+		mean := total / count
+		var sumSquares float64
+		for _, pnl := range pnls {
+			sumSquares += (pnl - mean) * (pnl - mean)
+		}
+		stats.PnLStdDev = math.Sqrt(sumSquares / (count - 1))
+	}
+
+	return stats
+}
@@ -0,0 +1,72 @@
+package operations
+
+import (
+	"math"
+	"testing"
+	"time"
+	"trading-journal-cli/internal/models"
+)
+
+func TestAnalyzePerformance_PartialClosesAndCurrencies(t *testing.T) {
+	ops, accountMgr := newTestOperations(t)
+	if err := accountMgr.AddAccount(models.Account{Name: "cny", Currency: "CNY"}); err != nil {
+		t.Fatalf("AddAccount failed: %v", err)
+	}
+	if err := ops.fx.SetRate(models.FXRate{Date: "2025-01-01", Base: "USD", Quote: "CNY", Rate: 7}); err != nil {
+		t.Fatalf("SetRate failed: %v", err)
+	}
+
+	start := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	at := func(hours int) time.Time { return start.Add(time.Duration(hours) * time.Hour) }
+
+	// 部分平仓后仍未平仓：+10
+	partial, err := ops.OpenPosition(testOpenParams("main", "BTC", 2, at(0)))
+	if err != nil {
+		t.Fatalf("OpenPosition failed: %v", err)
+	}
+	if _, err := ops.ClosePosition(partial.PositionID, testCloseParams(110, 1, at(1))); err != nil {
+		t.Fatalf("ClosePosition failed: %v", err)
+	}
+
+	// 已平仓：-5
+	loss, err := ops.OpenPosition(testOpenParams("main", "ETH", 1, at(2)))
+	if err != nil {
+		t.Fatalf("OpenPosition failed: %v", err)
+	}
+	if _, err := ops.ClosePosition(loss.PositionID, testCloseParams(95, 1, at(3))); err != nil {
+		t.Fatalf("ClosePosition failed: %v", err)
+	}
+
+	// CNY 账户：+70 CNY = +10 USD，开仓时余额 7000 CNY = 1000 USD
+	params := testOpenParams("cny", "BTC", 1, at(4))
+	params.AccountBalance = 7000
+	win, err := ops.OpenPosition(params)
+	if err != nil {
+		t.Fatalf("OpenPosition failed: %v", err)
+	}
+	if _, err := ops.ClosePosition(win.PositionID, testCloseParams(170, 1, at(5))); err != nil {
+		t.Fatalf("ClosePosition failed: %v", err)
+	}
+
+	report, err := ops.AnalyzePerformance(FilterParams{Currency: "USD"})
+	if err != nil {
+		t.Fatalf("AnalyzePerformance failed: %v", err)
+	}
+
+	if report.TotalTrades != 3 || report.OpenTrades != 1 {
+		t.Errorf("Expected 3 trades (1 still open), got %d (%d)", report.TotalTrades, report.OpenTrades)
+	}
+	if report.WinningTrades != 2 || report.LosingTrades != 1 {
+		t.Errorf("Expected 2 wins / 1 loss, got %d / %d", report.WinningTrades, report.LosingTrades)
+	}
+	if math.Abs(report.TotalPnL-15) > 1e-9 {
+		t.Errorf("Expected total PnL 15.00, got %.2f", report.TotalPnL)
+	}
+	// 15 / (1000 + 7000/7)
+	if math.Abs(report.TotalPnLPercentage-0.75) > 1e-9 {
+		t.Errorf("Expected total PnL percentage 0.75%%, got %.4f%%", report.TotalPnLPercentage)
+	}
+	if stats := report.BySymbol["BTC"]; stats == nil || math.Abs(stats.TotalPnL-20) > 1e-9 {
+		t.Errorf("Expected BTC PnL 20.00, got %+v", stats)
+	}
+}
@@ -0,0 +1,191 @@
+package operations
+
+import (
+	"fmt"
+	"sort"
+	"time"
+	"trading-journal-cli/internal/models"
+	"trading-journal-cli/internal/storage"
+	"trading-journal-cli/internal/validator"
+)
+
+// BreakerStatus 账户熔断规则的当前状态
+type BreakerStatus struct {
+	AccountName       string              `json:"accountName"`
+	Since             time.Time           `json:"since"`             // 规则统计起点（上次锁定解除时间），零值表示全部历史
+	DailyLoss         float64             `json:"dailyLoss"`         // 当日已实现净亏损（正数）
+	DrawdownPercent   float64             `json:"drawdownPercent"`   // 距资金峰值的当前回撤百分比
+	ConsecutiveLosses int                 `json:"consecutiveLosses"` // 最近连续亏损笔数
+	Triggered         *models.AccountLock `json:"triggered,omitempty"`
+}
+
+// EvaluateCircuitBreaker 按账户熔断规则计算 at 时刻的状态，不修改锁定状态
+//
+// 只统计上次锁定解除之后的交易，避免同一次亏损在解锁后再次触发熔断。
+func (o *Operations) EvaluateCircuitBreaker(accountName string, at time.Time) (*BreakerStatus, error) {
+	account, err := o.loadAccount(accountName)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &BreakerStatus{AccountName: account.Name}
+	if account.Lock != nil {
+		status.Since = account.Lock.ReleasedAt()
+	}
+	breaker := account.CircuitBreaker
+	if breaker.IsEmpty() {
+		return status, nil
+	}
+
+	after := func(t time.Time) bool {
+		return t.After(status.Since) && !t.After(at)
+	}
+
+	// 当日已实现净亏损：与资金流水一致，按每次平仓实现的净盈亏（包括分摊的开仓和加仓费用）的发生时间统计，
+	// 部分平仓的亏损同样计入；仓位的平仓时间是最后一笔成交的时间，统计起点之后有成交的仓位平仓时间不早于起点
+	local := at.Local()
+	dayStart := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.Local)
+	var dailyPnL float64
+	tradedSince := false
+	for pos, err := range o.storage.Positions(storage.Query{Account: account.Name, CloseFrom: status.Since}) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to read positions: %w", err)
+		}
+		for _, amount := range pos.RealizedAmounts() {
+			if !after(amount.Time) {
+				continue
+			}
+			tradedSince = true
+			if !amount.Time.Before(dayStart) {
+				dailyPnL += amount.NetPnL()
+			}
+		}
+	}
+	if dailyPnL < 0 {
+		status.DailyLoss = -dailyPnL
+	}
+
+	// 当前回撤，解锁后没有新的交易时不再触发
+	if breaker.MaxDrawdownPercent > 0 && tradedSince {
+		curve, err := o.EquityCurve(EquityParams{AccountName: account.Name, ToDate: at})
+		if err != nil {
+			return nil, err
+		}
+		status.DrawdownPercent = curve.CurrentDrawdownPercent
+	}
+
+	// 从最近一笔已平仓交易往前数连续亏损
+	var closed []*models.Position
+	query := storage.Query{Account: account.Name, Status: models.StatusClosed, CloseFrom: status.Since, CloseTo: at}
+	for pos, err := range o.storage.Positions(query) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to read positions: %w", err)
+		}
+		if after(closeTimeOrNow(pos)) {
+			closed = append(closed, pos)
+		}
+	}
+	sort.SliceStable(closed, func(i, j int) bool {
+		return closeTimeOrNow(closed[i]).Before(closeTimeOrNow(closed[j]))
+	})
+	for i := len(closed) - 1; i >= 0 && closed[i].TotalRealizedPnL() < 0; i-- {
+		status.ConsecutiveLosses++
+	}
+
+	trigger := func(rule models.BreakerRule, value, limit float64) {
+		if status.Triggered == nil {
+			status.Triggered = &models.AccountLock{
+				LockedAt: at,
+				Until:    breaker.LockUntil(at),
+				Rule:     rule,
+				Value:    value,
+				Limit:    limit,
+			}
+		}
+	}
+	if breaker.MaxDailyLoss > 0 && status.DailyLoss >= breaker.MaxDailyLoss {
+		trigger(models.BreakerDailyLoss, status.DailyLoss, breaker.MaxDailyLoss)
+	}
+	if breaker.MaxDrawdownPercent > 0 && status.DrawdownPercent >= breaker.MaxDrawdownPercent {
+		trigger(models.BreakerDrawdown, status.DrawdownPercent, breaker.MaxDrawdownPercent)
+	}
+	if breaker.MaxConsecutiveLosses > 0 && status.ConsecutiveLosses >= breaker.MaxConsecutiveLosses {
+		trigger(models.BreakerConsecutiveLosses, float64(status.ConsecutiveLosses), float64(breaker.MaxConsecutiveLosses))
+	}
+
+	return status, nil
+}
+
+// checkCircuitBreaker 开仓和加仓前检查账户熔断，触发规则时锁定账户
+func (o *Operations) checkCircuitBreaker(accountName string) error {
+	if o.accountManager == nil {
+		return nil
+	}
+	account, err := o.loadAccount(accountName)
+	if err != nil {
+		return fmt.Errorf("failed to check circuit breaker: %w", err)
+	}
+
+	now := time.Now()
+	lock := account.Lock
+	if !lock.ActiveAt(now) {
+		status, err := o.EvaluateCircuitBreaker(accountName, now)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate circuit breaker: %w", err)
+		}
+		if status.Triggered == nil {
+			return nil
+		}
+		lock = status.Triggered
+		if err := o.accountManager.SetAccountLock(accountName, lock); err != nil {
+			return fmt.Errorf("failed to lock account: %w", err)
+		}
+	}
+
+	return fmt.Errorf("%w until %s (%s %g >= %g)", validator.ErrAccountLocked,
+		lock.Until.Local().Format("2006-01-02 15:04"), lock.Rule, lock.Value, lock.Limit)
+}
+
+// UnlockAccount 提前解除账户熔断锁定，并写入审计记录
+func (o *Operations) UnlockAccount(accountName, reason string) (*models.AccountLock, error) {
+	if reason == "" {
+		return nil, fmt.Errorf("unlock reason is required")
+	}
+
+	// 读取锁定状态、写审计记录和解锁在同一把锁内完成，避免并发解锁重复写入审计记录
+	dirLock, err := o.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer dirLock.Unlock()
+
+	account, err := o.loadAccount(accountName)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if !account.Lock.ActiveAt(now) {
+		return nil, fmt.Errorf("account %s is not locked", accountName)
+	}
+
+	lock := *account.Lock
+	lock.UnlockedAt = &now
+
+	// 先写审计记录，保证每次提前解锁都有据可查
+	if err := o.accountManager.AppendAudit(models.AuditEntry{
+		Time:    now,
+		Account: accountName,
+		Action:  models.AuditActionUnlock,
+		Reason:  reason,
+		Detail: fmt.Sprintf("rule=%s value=%g limit=%g lockedAt=%s until=%s", lock.Rule, lock.Value, lock.Limit,
+			lock.LockedAt.Format(time.RFC3339), lock.Until.Format(time.RFC3339)),
+	}); err != nil {
+		return nil, err
+	}
+	if err := o.accountManager.SetAccountLock(accountName, &lock); err != nil {
+		return nil, fmt.Errorf("failed to unlock account: %w", err)
+	}
+
+	return &lock, nil
+}
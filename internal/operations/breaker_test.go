@@ -0,0 +1,60 @@
+package operations
+
+import (
+	"math"
+	"testing"
+	"time"
+	"trading-journal-cli/internal/models"
+)
+
+func TestEvaluateCircuitBreaker_DailyLoss(t *testing.T) {
+	day := time.Date(2025, 3, 3, 0, 0, 0, 0, time.Local)
+	at := func(hours int) time.Time { return day.Add(time.Duration(hours) * time.Hour) }
+
+	tests := []struct {
+		name       string
+		openTime   time.Time
+		quantity   float64
+		entryFee   float64
+		closePrice float64
+		closeQty   float64
+		closeTime  time.Time
+		dailyLoss  float64
+		triggered  bool
+	}{
+		{"Entry fees count toward the loss", at(9), 1, 3, 99, 1, at(10), 4, true},
+		{"Partial close counts", at(9), 2, 0, 97, 1, at(10), 3, false},
+		{"Winning close offsets entry fees", at(9), 1, 3, 102, 1, at(10), 1, false},
+		{"Previous day is excluded", at(-15), 1, 3, 90, 1, at(-14), 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops, accountMgr := newTestOperations(t)
+			if err := accountMgr.UpdateAccountCircuitBreaker("main", &models.CircuitBreaker{MaxDailyLoss: 4}); err != nil {
+				t.Fatalf("UpdateAccountCircuitBreaker failed: %v", err)
+			}
+
+			params := testOpenParams("main", "BTC", tt.quantity, tt.openTime)
+			params.Fees = models.Fees{Commission: tt.entryFee}
+			pos, err := ops.OpenPosition(params)
+			if err != nil {
+				t.Fatalf("OpenPosition failed: %v", err)
+			}
+			if _, err := ops.ClosePosition(pos.PositionID, testCloseParams(tt.closePrice, tt.closeQty, tt.closeTime)); err != nil {
+				t.Fatalf("ClosePosition failed: %v", err)
+			}
+
+			status, err := ops.EvaluateCircuitBreaker("main", at(12))
+			if err != nil {
+				t.Fatalf("EvaluateCircuitBreaker failed: %v", err)
+			}
+			if math.Abs(status.DailyLoss-tt.dailyLoss) > 1e-9 {
+				t.Errorf("Expected daily loss %.2f, got %.2f", tt.dailyLoss, status.DailyLoss)
+			}
+			if (status.Triggered != nil) != tt.triggered {
+				t.Errorf("Expected triggered %v, got %+v", tt.triggered, status.Triggered)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package operations
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"trading-journal-cli/internal/models"
+)
+
+// CurrencyConverter 将各账户币种的金额换算为报告币种
+type CurrencyConverter struct {
+	fx       *models.FXTable
+	accounts map[string]string // 账户名称 -> 账户币种
+	currency string
+}
+
+// NewCurrencyConverter 创建报告币种换算器
+//
+// currency 为空时，如果所有仓位的币种相同则使用该币种，否则使用 models.DefaultCurrency
+func (o *Operations) NewCurrencyConverter(positions []*models.Position, currency string) *CurrencyConverter {
+	c := &CurrencyConverter{
+		fx:       o.fx,
+		accounts: make(map[string]string),
+	}
+
+	if o.accountManager != nil {
+		if err := o.accountManager.Load(); err == nil {
+			for _, acc := range o.accountManager.ListAccounts() {
+				c.accounts[acc.Name] = acc.CurrencyCode()
+			}
+		}
+	}
+
+	if currency != "" {
+		c.currency = strings.ToUpper(currency)
+		return c
+	}
+
+	for _, pos := range positions {
+		positionCurrency := c.PositionCurrency(pos)
+		if c.currency == "" {
+			c.currency = positionCurrency
+		} else if c.currency != positionCurrency {
+			c.currency = models.DefaultCurrency
+			break
+		}
+	}
+	if c.currency == "" {
+		c.currency = models.DefaultCurrency
+	}
+
+	return c
+}
+
+// Currency 报告币种
+func (c *CurrencyConverter) Currency() string {
+	return c.currency
+}
+
+// AccountCurrency 账户币种，账户不存在时为 models.DefaultCurrency
+func (c *CurrencyConverter) AccountCurrency(accountName string) string {
+	if currency, ok := c.accounts[accountName]; ok {
+		return currency
+	}
+	return models.DefaultCurrency
+}
+
+// PositionCurrency 仓位的币种：开仓时记录的账户币种，旧记录按当前账户配置
+func (c *CurrencyConverter) PositionCurrency(pos *models.Position) string {
+	if pos.Currency != "" {
+		return pos.Currency
+	}
+	return c.AccountCurrency(pos.AccountName)
+}
+
+// Convert 将账户币种的金额换算为报告币种，使用 at 当天或之前最近的汇率
+func (c *CurrencyConverter) Convert(amount float64, accountName string, at time.Time) (float64, error) {
+	return c.convert(amount, c.AccountCurrency(accountName), at)
+}
+
+// ConvertPosition 将仓位币种的金额换算为报告币种，使用 at 当天或之前最近的汇率
+func (c *CurrencyConverter) ConvertPosition(amount float64, pos *models.Position, at time.Time) (float64, error) {
+	return c.convert(amount, c.PositionCurrency(pos), at)
+}
+
+// convert 将 from 币种的金额换算为报告币种
+func (c *CurrencyConverter) convert(amount float64, from string, at time.Time) (float64, error) {
+	if from == c.currency || amount == 0 {
+		return amount, nil
+	}
+	if c.fx == nil {
+		return 0, fmt.Errorf("no fx rate for %s/%s", from, c.currency)
+	}
+	return c.fx.Convert(amount, from, c.currency, at)
+}
+
+// closeTimeOrNow 已实现盈亏按平仓时间的汇率换算，仍持仓的部分平仓按当前汇率
+func closeTimeOrNow(pos *models.Position) time.Time {
+	if pos.CloseTime != nil {
+		return *pos.CloseTime
+	}
+	return time.Now()
+}
@@ -0,0 +1,57 @@
+package operations
+
+import (
+	"testing"
+	"trading-journal-cli/internal/models"
+)
+
+func TestCurrencyConverter_PositionCurrency(t *testing.T) {
+	ops, accountMgr := newTestOperations(t)
+	if err := accountMgr.AddAccount(models.Account{Name: "cny", Currency: "CNY"}); err != nil {
+		t.Fatalf("AddAccount failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		pos      *models.Position
+		expected string
+	}{
+		{"Recorded currency", &models.Position{AccountName: "main", Currency: "EUR"}, "EUR"},
+		{"Recorded currency of deleted account", &models.Position{AccountName: "gone", Currency: "CNY"}, "CNY"},
+		{"Legacy position uses account currency", &models.Position{AccountName: "cny"}, "CNY"},
+		{"Legacy position of deleted account", &models.Position{AccountName: "gone"}, models.DefaultCurrency},
+	}
+
+	converter := ops.NewCurrencyConverter(nil, "USD")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := converter.PositionCurrency(tt.pos); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestOpenPosition_RecordsCurrency(t *testing.T) {
+	ops, accountMgr := newTestOperations(t)
+	if err := accountMgr.AddAccount(models.Account{Name: "cny", Currency: "CNY"}); err != nil {
+		t.Fatalf("AddAccount failed: %v", err)
+	}
+
+	pos, err := ops.OpenPosition(testOpenParams("cny", "BTC", 1, testTime))
+	if err != nil {
+		t.Fatalf("OpenPosition failed: %v", err)
+	}
+	if pos.Currency != "CNY" {
+		t.Errorf("Expected currency CNY, got %q", pos.Currency)
+	}
+
+	// 删除账户后仍按开仓时的币种换算
+	if err := accountMgr.DeleteAccount("cny"); err != nil {
+		t.Fatalf("DeleteAccount failed: %v", err)
+	}
+	converter := ops.NewCurrencyConverter([]*models.Position{pos}, "")
+	if converter.Currency() != "CNY" {
+		t.Errorf("Expected report currency CNY, got %s", converter.Currency())
+	}
+}
@@ -0,0 +1,142 @@
+package operations
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"trading-journal-cli/internal/models"
+)
+
+// EditParams 修改仓位参数，为空的字段保持不变
+type EditParams struct {
+	Symbol     *string
+	MarketType *models.MarketType
+	OpenPrice  *float64   // 首次开仓的成交价，有加仓时加权平均开仓价随之重新计算
+	OpenTime   *time.Time // 开仓时间（首次开仓成交的时间）
+	Reason     *string    // 交易理由
+
+	EditReason string     // 修改原因（必填）
+	EditedBy   string     // 修改人
+	Time       *time.Time // 可选，为空时使用当前时间
+}
+
+// EditResult 修改结果
+type EditResult struct {
+	Position *models.Position     // 修改后追加的版本
+	Changes  []models.FieldChange // 相对修改前的字段变化（不含修改记录本身）
+	Ledger   []models.LedgerEntry // 因盈亏变化追加的更正流水
+}
+
+// EditPosition 更正仓位的开仓信息
+//
+// 修改后按 PositionValidator 重新验证，重新计算加权平均开仓价、初始风险、每笔平仓的盈亏和平仓汇总字段，
+// 追加为新版本并在仓位上记录修改人、时间和原因。已有平仓成交的仓位盈亏变化时追加更正流水。
+func (o *Operations) EditPosition(positionID string, params EditParams) (*EditResult, error) {
+	if strings.TrimSpace(params.EditReason) == "" {
+		return nil, fmt.Errorf("edit reason is required")
+	}
+
+	lock, err := o.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	pos, err := o.storage.FindPositionByID(positionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find position: %w", err)
+	}
+
+	edited := *pos
+	edited.Entries = append([]models.EntryFill(nil), pos.Entries...)
+	edited.Edits = append([]models.EditRecord(nil), pos.Edits...)
+
+	// 影响盈亏的字段修改后需要重新计算成交盈亏
+	recalculate := false
+	if params.Symbol != nil && *params.Symbol != pos.Symbol {
+		edited.Symbol = *params.Symbol
+		// 按新品种重新记录合约乘数
+		edited.Multiplier = 0
+		if o.instruments != nil {
+			if inst, err := o.instruments.GetInstrument(edited.Symbol); err == nil {
+				edited.Multiplier = inst.PointValue()
+			}
+		}
+		recalculate = true
+	}
+	if params.MarketType != nil {
+		edited.MarketType = *params.MarketType
+	}
+	if params.OpenPrice != nil && len(edited.Entries) > 0 && *params.OpenPrice != edited.Entries[0].Price {
+		edited.Entries[0].Price = *params.OpenPrice
+		recalculate = true
+	}
+	if params.OpenTime != nil && !params.OpenTime.Equal(pos.OpenTime) {
+		edited.OpenTime = *params.OpenTime
+		if len(edited.Entries) > 0 {
+			edited.Entries[0].Time = *params.OpenTime
+		}
+		recalculate = true
+	}
+	if params.Reason != nil {
+		edited.Reason = *params.Reason
+	}
+
+	if recalculate {
+		edited.ReplayEntries(o.pointValue(&edited))
+		edited.InitialRisk = o.entryRisk(&edited)
+	}
+
+	if err := o.validator.ValidateEditPosition(&edited); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	// 重新计算平仓汇总：盈亏比例、R 倍数、持仓时长等
+	edited.RefreshCloseSummary()
+
+	changes, err := models.DiffPositions(pos, &edited)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		return nil, fmt.Errorf("no changes to position %s", positionID)
+	}
+
+	editTime := time.Now()
+	if params.Time != nil {
+		editTime = *params.Time
+	}
+	edited.Edits = append(edited.Edits, models.EditRecord{
+		Time:   editTime,
+		By:     params.EditedBy,
+		Reason: params.EditReason,
+	})
+
+	// 开仓时间改到其他月份时先移动已有版本，新版本追加到新月份
+	// 已有平仓成交的仓位盈亏变化时追加更正流水，旧账户先生成期初流水
+	entries, err := o.withPositionLedger(edited.AccountName, len(edited.Fills) > 0, func() error {
+		if err := o.storage.MovePosition(pos.PositionID, pos.OpenTime, edited.OpenTime); err != nil {
+			return err
+		}
+		if err := o.storage.UpdatePosition(&edited); err != nil {
+			return fmt.Errorf("failed to update position: %w", err)
+		}
+		return nil
+	}, func() ([]models.LedgerEntry, error) {
+		return o.postPositionLedger(&edited)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &EditResult{Position: &edited, Changes: changes, Ledger: entries}, nil
+}
+
+// entryRisk 按每次开仓成交时生效的止损计算初始风险（1R）
+func (o *Operations) entryRisk(pos *models.Position) float64 {
+	var risk float64
+	for _, entry := range pos.Entries {
+		risk += models.CalculateRiskAmount(pos.Direction, entry.Price, pos.StopLossAt(entry.Time), entry.Quantity, o.pointValue(pos))
+	}
+	return risk
+}
@@ -0,0 +1,240 @@
+package operations
+
+import (
+	"fmt"
+	"sort"
+	"time"
+	"trading-journal-cli/internal/models"
+)
+
+// EquityParams 资金曲线参数
+type EquityParams struct {
+	AccountName string    // 为空时合并所有账户
+	Currency    string    // 合并多个账户时的报告币种，规则同 FilterParams.Currency
+	FromDate    time.Time // 零值则不筛选，之前的流水计入期初余额
+	ToDate      time.Time // 零值则不筛选
+}
+
+// EquityPoint 资金曲线上的一个点（每笔资金流水一个点）
+type EquityPoint struct {
+	Time            time.Time              `json:"time"`
+	AccountName     string                 `json:"accountName"`
+	Type            models.LedgerEntryType `json:"type"`
+	Amount          float64                `json:"amount"`
+	PositionID      string                 `json:"positionId,omitempty"`
+	Balance         float64                `json:"balance"`         // 本笔流水后的余额
+	Peak            float64                `json:"peak"`            // 截至本笔流水的峰值（已按入金出金调整）
+	Drawdown        float64                `json:"drawdown"`        // 距峰值的回撤金额
+	DrawdownPercent float64                `json:"drawdownPercent"` // 距峰值的回撤百分比
+}
+
+// EquityCurve 资金曲线与回撤统计
+//
+// 入金、出金和余额调整（期初余额、手动校正）不是交易表现：发生时峰值同步增减，
+// 因此出金或向下校正余额不会被计为回撤，入金也不会抬高峰值掩盖回撤。
+type EquityCurve struct {
+	AccountName  string        `json:"accountName,omitempty"` // 为空表示所有账户合并
+	Currency     string        `json:"currency"`
+	Points       []EquityPoint `json:"points"`
+	StartBalance float64       `json:"startBalance"`
+	EndBalance   float64       `json:"endBalance"`
+	NetDeposits  float64       `json:"netDeposits"` // 期间入金 - 出金
+	Adjustments  float64       `json:"adjustments"` // 期间余额调整
+	TradingPnL   float64       `json:"tradingPnL"`  // 期间平仓盈亏 + 费用
+
+	Peak     float64   `json:"peak"`
+	PeakTime time.Time `json:"peakTime"`
+
+	MaxDrawdown         float64       `json:"maxDrawdown"`         // 最大回撤金额
+	MaxDrawdownPercent  float64       `json:"maxDrawdownPercent"`  // 最大回撤时的回撤百分比
+	MaxDrawdownPeak     time.Time     `json:"maxDrawdownPeak"`     // 最大回撤开始（峰值）时间
+	MaxDrawdownTrough   time.Time     `json:"maxDrawdownTrough"`   // 最大回撤谷底时间
+	MaxDrawdownRecovery *time.Time    `json:"maxDrawdownRecovery"` // 回到峰值的时间，未恢复为空
+	DrawdownDuration    time.Duration `json:"drawdownDuration"`    // 从峰值到恢复（未恢复则到统计截止时间）
+	RecoveryDuration    time.Duration `json:"recoveryDuration"`    // 从谷底到恢复，未恢复为 0
+
+	CurrentDrawdown        float64 `json:"currentDrawdown"`
+	CurrentDrawdownPercent float64 `json:"currentDrawdownPercent"`
+}
+
+// EquityCurve 根据资金流水计算账户（或所有账户合并）的资金曲线和最大回撤
+func (o *Operations) EquityCurve(params EquityParams) (*EquityCurve, error) {
+	if o.accountManager == nil {
+		return nil, fmt.Errorf("account manager is not configured")
+	}
+	if err := o.accountManager.Load(); err != nil {
+		return nil, fmt.Errorf("failed to load account config: %w", err)
+	}
+
+	accountNames := []string{params.AccountName}
+	if params.AccountName == "" {
+		accountNames = accountNames[:0]
+		for _, acc := range o.accountManager.ListAccounts() {
+			accountNames = append(accountNames, acc.Name)
+		}
+	}
+
+	var entries []models.LedgerEntry
+	for _, name := range accountNames {
+		accountEntries, err := o.AccountLedger(name)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, accountEntries...)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+
+	// 合并多个账户时按流水发生时的汇率换算为报告币种
+	currencyPositions := make([]*models.Position, len(accountNames))
+	for i, name := range accountNames {
+		currencyPositions[i] = &models.Position{AccountName: name}
+	}
+	converter := o.NewCurrencyConverter(currencyPositions, params.Currency)
+
+	curve := &EquityCurve{
+		AccountName: params.AccountName,
+		Currency:    converter.Currency(),
+		Points:      make([]EquityPoint, 0),
+	}
+
+	var balance, peak float64
+	inDrawdown := false
+	for _, entry := range entries {
+		if !params.ToDate.IsZero() && entry.Time.After(params.ToDate) {
+			break
+		}
+
+		amount, err := converter.Convert(entry.Amount, entry.Account, entry.Time)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert ledger entry %s: %w", entry.EntryID, err)
+		}
+
+		// 起始日期之前的流水只计入期初余额，期初余额视为起始日期的峰值
+		if !params.FromDate.IsZero() && entry.Time.Before(params.FromDate) {
+			balance += amount
+			curve.StartBalance = balance
+			peak = balance
+			curve.PeakTime = params.FromDate
+			continue
+		}
+
+		balance += amount
+		switch entry.Type {
+		case models.LedgerDeposit, models.LedgerWithdrawal:
+			peak += amount
+			curve.NetDeposits += amount
+		case models.LedgerAdjustment:
+			peak += amount
+			curve.Adjustments += amount
+		default:
+			curve.TradingPnL += amount
+		}
+
+		if balance >= peak {
+			// 回到峰值：最大回撤所在的回撤区间在此恢复
+			if inDrawdown && curve.MaxDrawdown > 0 && curve.MaxDrawdownRecovery == nil &&
+				curve.MaxDrawdownPeak.Equal(curve.PeakTime) {
+				recovery := entry.Time
+				curve.MaxDrawdownRecovery = &recovery
+			}
+			inDrawdown = false
+			if balance > peak || curve.PeakTime.IsZero() {
+				curve.PeakTime = entry.Time
+			}
+			peak = balance
+		} else {
+			inDrawdown = true
+			drawdown := peak - balance
+			if drawdown > curve.MaxDrawdown {
+				curve.MaxDrawdown = drawdown
+				curve.MaxDrawdownPercent = percentOf(drawdown, peak)
+				curve.MaxDrawdownPeak = curve.PeakTime
+				curve.MaxDrawdownTrough = entry.Time
+				curve.MaxDrawdownRecovery = nil
+			}
+		}
+
+		curve.Points = append(curve.Points, EquityPoint{
+			Time:            entry.Time,
+			AccountName:     entry.Account,
+			Type:            entry.Type,
+			Amount:          amount,
+			PositionID:      entry.PositionID,
+			Balance:         balance,
+			Peak:            peak,
+			Drawdown:        peak - balance,
+			DrawdownPercent: percentOf(peak-balance, peak),
+		})
+	}
+
+	curve.EndBalance = balance
+	curve.Peak = peak
+	curve.CurrentDrawdown = peak - balance
+	curve.CurrentDrawdownPercent = percentOf(peak-balance, peak)
+
+	// 回撤持续时间：从峰值到恢复，未恢复时到统计截止时间
+	if curve.MaxDrawdown > 0 {
+		end := time.Now()
+		if !params.ToDate.IsZero() && params.ToDate.Before(end) {
+			end = params.ToDate
+		}
+		if curve.MaxDrawdownRecovery != nil {
+			end = *curve.MaxDrawdownRecovery
+			curve.RecoveryDuration = curve.MaxDrawdownRecovery.Sub(curve.MaxDrawdownTrough)
+		}
+		curve.DrawdownDuration = end.Sub(curve.MaxDrawdownPeak)
+	}
+
+	return curve, nil
+}
+
+// EquityCurves 计算每个账户的资金曲线，多个账户时在最后附加所有账户合并的曲线
+func (o *Operations) EquityCurves(params EquityParams) ([]*EquityCurve, error) {
+	if params.AccountName != "" {
+		curve, err := o.EquityCurve(params)
+		if err != nil {
+			return nil, err
+		}
+		return []*EquityCurve{curve}, nil
+	}
+
+	if o.accountManager == nil {
+		return nil, fmt.Errorf("account manager is not configured")
+	}
+	if err := o.accountManager.Load(); err != nil {
+		return nil, fmt.Errorf("failed to load account config: %w", err)
+	}
+
+	accounts := o.accountManager.ListAccounts()
+	curves := make([]*EquityCurve, 0, len(accounts)+1)
+	for _, acc := range accounts {
+		accountParams := params
+		accountParams.AccountName = acc.Name
+		accountParams.Currency = acc.CurrencyCode()
+		curve, err := o.EquityCurve(accountParams)
+		if err != nil {
+			return nil, err
+		}
+		curves = append(curves, curve)
+	}
+
+	if len(accounts) > 1 {
+		combined, err := o.EquityCurve(params)
+		if err != nil {
+			return nil, err
+		}
+		curves = append(curves, combined)
+	}
+
+	return curves, nil
+}
+
+// percentOf 计算 value 占 base 的百分比，base 不为正时返回 0
+func percentOf(value, base float64) float64 {
+	if base <= 0 {
+		return 0
+	}
+	return value / base * 100
+}
@@ -0,0 +1,147 @@
+package operations
+
+import (
+	"math"
+	"testing"
+	"time"
+	"trading-journal-cli/internal/models"
+)
+
+func TestEquityCurve(t *testing.T) {
+	start := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	at := func(day int) time.Time { return start.AddDate(0, 0, day) }
+	entry := func(day int, entryType models.LedgerEntryType, amount float64) models.LedgerEntry {
+		return models.NewLedgerEntry("main", entryType, amount, at(day), "")
+	}
+
+	tests := []struct {
+		name               string
+		entries            []models.LedgerEntry
+		fromDate           time.Time
+		startBalance       float64
+		endBalance         float64
+		netDeposits        float64
+		adjustments        float64
+		tradingPnL         float64
+		maxDrawdown        float64
+		maxDrawdownPercent float64
+		peak               time.Time
+		trough             time.Time
+		recovery           *time.Time
+	}{
+		{
+			name: "Withdrawal is not a drawdown",
+			entries: []models.LedgerEntry{
+				entry(0, models.LedgerDeposit, 1000),
+				entry(1, models.LedgerRealizedPnL, 100),
+				entry(2, models.LedgerWithdrawal, -500),
+			},
+			endBalance:  600,
+			netDeposits: 500,
+			tradingPnL:  100,
+		},
+		{
+			name: "Adjustments are cash flows, not trading PnL",
+			entries: []models.LedgerEntry{
+				entry(0, models.LedgerAdjustment, 1000),
+				entry(1, models.LedgerRealizedPnL, -100),
+				entry(2, models.LedgerAdjustment, -200),
+			},
+			endBalance:         700,
+			adjustments:        800,
+			tradingPnL:         -100,
+			maxDrawdown:        100,
+			maxDrawdownPercent: 10,
+			peak:               at(0),
+			trough:             at(1),
+		},
+		{
+			name: "Deposit during drawdown does not hide it",
+			entries: []models.LedgerEntry{
+				entry(0, models.LedgerDeposit, 1000),
+				entry(1, models.LedgerRealizedPnL, 200),
+				entry(2, models.LedgerRealizedPnL, -300),
+				entry(2, models.LedgerFee, -60),
+				entry(3, models.LedgerDeposit, 500),
+				entry(4, models.LedgerRealizedPnL, 360),
+			},
+			endBalance:         1700,
+			netDeposits:        1500,
+			tradingPnL:         200,
+			maxDrawdown:        360,
+			maxDrawdownPercent: 30,
+			peak:               at(1),
+			trough:             at(2),
+			recovery:           func() *time.Time { t := at(4); return &t }(),
+		},
+		{
+			name: "Entries before from date form the start balance",
+			entries: []models.LedgerEntry{
+				entry(0, models.LedgerDeposit, 1000),
+				entry(1, models.LedgerRealizedPnL, -200),
+				entry(2, models.LedgerRealizedPnL, -80),
+				entry(3, models.LedgerDeposit, 100),
+			},
+			fromDate:           at(2),
+			startBalance:       800,
+			endBalance:         820,
+			netDeposits:        100,
+			tradingPnL:         -80,
+			maxDrawdown:        80,
+			maxDrawdownPercent: 10,
+			peak:               at(2),
+			trough:             at(2),
+		},
+	}
+
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops, accountMgr := newTestOperations(t)
+			if err := accountMgr.AppendLedgerEntries(tt.entries...); err != nil {
+				t.Fatalf("AppendLedgerEntries failed: %v", err)
+			}
+
+			curve, err := ops.EquityCurve(EquityParams{AccountName: "main", FromDate: tt.fromDate})
+			if err != nil {
+				t.Fatalf("EquityCurve failed: %v", err)
+			}
+
+			if !near(curve.StartBalance, tt.startBalance) || !near(curve.EndBalance, tt.endBalance) {
+				t.Errorf("Expected balance %.2f -> %.2f, got %.2f -> %.2f",
+					tt.startBalance, tt.endBalance, curve.StartBalance, curve.EndBalance)
+			}
+			if !near(curve.NetDeposits, tt.netDeposits) {
+				t.Errorf("Expected net deposits %.2f, got %.2f", tt.netDeposits, curve.NetDeposits)
+			}
+			if !near(curve.Adjustments, tt.adjustments) {
+				t.Errorf("Expected adjustments %.2f, got %.2f", tt.adjustments, curve.Adjustments)
+			}
+			if !near(curve.TradingPnL, tt.tradingPnL) {
+				t.Errorf("Expected trading PnL %.2f, got %.2f", tt.tradingPnL, curve.TradingPnL)
+			}
+			if !near(curve.StartBalance+curve.NetDeposits+curve.Adjustments+curve.TradingPnL, curve.EndBalance) {
+				t.Errorf("Start balance and period flows do not add up to end balance %.2f", curve.EndBalance)
+			}
+			if !near(curve.MaxDrawdown, tt.maxDrawdown) || !near(curve.MaxDrawdownPercent, tt.maxDrawdownPercent) {
+				t.Errorf("Expected max drawdown %.2f (%.2f%%), got %.2f (%.2f%%)",
+					tt.maxDrawdown, tt.maxDrawdownPercent, curve.MaxDrawdown, curve.MaxDrawdownPercent)
+			}
+			if tt.maxDrawdown == 0 {
+				return
+			}
+			if !curve.MaxDrawdownPeak.Equal(tt.peak) || !curve.MaxDrawdownTrough.Equal(tt.trough) {
+				t.Errorf("Expected drawdown %v -> %v, got %v -> %v",
+					tt.peak, tt.trough, curve.MaxDrawdownPeak, curve.MaxDrawdownTrough)
+			}
+			switch {
+			case tt.recovery == nil && curve.MaxDrawdownRecovery != nil:
+				t.Errorf("Expected no recovery, got %v", *curve.MaxDrawdownRecovery)
+			case tt.recovery != nil && (curve.MaxDrawdownRecovery == nil || !curve.MaxDrawdownRecovery.Equal(*tt.recovery)):
+				t.Errorf("Expected recovery at %v, got %v", *tt.recovery, curve.MaxDrawdownRecovery)
+			case tt.recovery != nil && curve.RecoveryDuration != tt.recovery.Sub(tt.trough):
+				t.Errorf("Expected recovery duration %v, got %v", tt.recovery.Sub(tt.trough), curve.RecoveryDuration)
+			}
+		})
+	}
+}
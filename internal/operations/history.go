@@ -0,0 +1,97 @@
+package operations
+
+import (
+	"fmt"
+	"time"
+	"trading-journal-cli/internal/models"
+	"trading-journal-cli/internal/storage"
+)
+
+// PositionHistory 读取仓位的所有版本并推导生命周期事件
+func (o *Operations) PositionHistory(positionID string) ([]models.PositionEvent, error) {
+	versions, err := o.storage.PositionVersions(positionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read position history: %w", err)
+	}
+	return models.BuildPositionEvents(versions)
+}
+
+// ReplayPosition 重放仓位事件，返回 at 时刻的仓位状态，当时尚未开仓时返回 nil
+func (o *Operations) ReplayPosition(positionID string, at time.Time) (*models.Position, error) {
+	events, err := o.PositionHistory(positionID)
+	if err != nil {
+		return nil, err
+	}
+	return models.ReplayPosition(events, at), nil
+}
+
+// positionsAsOf 按版本历史重建 filter.AsOf 时刻的仓位，再按筛选条件过滤
+// 当时尚未开仓的仓位不返回
+func (o *Operations) positionsAsOf(filter FilterParams) ([]*models.Position, error) {
+	q := filter.query()
+	// 存储层只按不随时间变化的条件筛选，状态和时间条件作用于重建后的仓位
+	historyQuery := storage.Query{
+		Symbol:     q.Symbol,
+		MarketType: q.MarketType,
+		Account:    q.Account,
+		OpenTo:     filter.AsOf,
+
+		IncludeVoided: q.IncludeVoided,
+	}
+
+	result := make([]*models.Position, 0)
+	for versions, err := range o.storage.PositionHistories(historyQuery) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to read position history: %w", err)
+		}
+		events, err := models.BuildPositionEvents(versions)
+		if err != nil {
+			return nil, err
+		}
+		pos := models.ReplayPosition(events, filter.AsOf)
+		if pos == nil {
+			continue
+		}
+		// 作废和恢复视为更正录入错误，与冲回的资金流水一致，以最新版本的作废状态为准
+		if latest := versions[len(versions)-1]; (pos.Voided == nil) != (latest.Voided == nil) {
+			corrected := *pos
+			corrected.Voided = latest.Voided
+			pos = &corrected
+		}
+		if q.Matches(pos) {
+			result = append(result, pos)
+		}
+	}
+	return result, nil
+}
+
+// AccountCreatedAt 账户的创建时间，旧账户按第一笔资金流水的时间，没有资金流水时返回零值（未知）
+func (o *Operations) AccountCreatedAt(accountName string) (time.Time, error) {
+	account, err := o.loadAccount(accountName)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if account.CreatedAt != nil {
+		return *account.CreatedAt, nil
+	}
+	entries, err := o.accountManager.ReadLedger(accountName)
+	if err != nil || len(entries) == 0 {
+		return time.Time{}, err
+	}
+	return entries[0].Time, nil
+}
+
+// AccountBalanceAt 账户在 at 时刻的余额（该时刻及之前的资金流水合计）
+func (o *Operations) AccountBalanceAt(accountName string, at time.Time) (float64, error) {
+	entries, err := o.AccountLedger(accountName)
+	if err != nil {
+		return 0, err
+	}
+	var balance float64
+	for _, entry := range entries {
+		if !entry.Time.After(at) {
+			balance += entry.Amount
+		}
+	}
+	return balance, nil
+}
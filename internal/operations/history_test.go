@@ -0,0 +1,64 @@
+package operations
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAsOf_VoidIsRetroactive(t *testing.T) {
+	ops, _ := newTestOperations(t)
+	asOf := testTime.Add(2 * time.Hour)
+
+	pos, err := ops.OpenPosition(testOpenParams("main", "BTC", 1, testTime))
+	if err != nil {
+		t.Fatalf("OpenPosition failed: %v", err)
+	}
+	if _, err := ops.ClosePosition(pos.PositionID, testCloseParams(110, 1, testTime.Add(time.Hour))); err != nil {
+		t.Fatalf("ClosePosition failed: %v", err)
+	}
+
+	// 列表和余额在 asOf 时刻应同时包含或同时不包含该仓位
+	check := func(step string, expectListed bool, expectBalance float64) {
+		t.Helper()
+		positions, err := ops.ListPositions(FilterParams{AsOf: asOf})
+		if err != nil {
+			t.Fatalf("%s: ListPositions failed: %v", step, err)
+		}
+		if listed := len(positions) == 1; listed != expectListed {
+			t.Errorf("%s: expected listed %v, got %d positions", step, expectListed, len(positions))
+		}
+		balance, err := ops.AccountBalanceAt("main", asOf)
+		if err != nil {
+			t.Fatalf("%s: AccountBalanceAt failed: %v", step, err)
+		}
+		if balance != expectBalance {
+			t.Errorf("%s: expected balance %.2f, got %.2f", step, expectBalance, balance)
+		}
+	}
+
+	check("closed", true, 10)
+	if _, err := ops.VoidPosition(pos.PositionID, "duplicate"); err != nil {
+		t.Fatalf("VoidPosition failed: %v", err)
+	}
+	check("voided", false, 0)
+	if _, err := ops.UnvoidPosition(pos.PositionID, "not a duplicate"); err != nil {
+		t.Fatalf("UnvoidPosition failed: %v", err)
+	}
+	check("unvoided", true, 10)
+}
+
+func TestAccountCreatedAt(t *testing.T) {
+	ops, accountMgr := newTestOperations(t)
+
+	createdAt, err := ops.AccountCreatedAt("main")
+	if err != nil {
+		t.Fatalf("AccountCreatedAt failed: %v", err)
+	}
+	account, err := accountMgr.GetAccount("main")
+	if err != nil {
+		t.Fatalf("GetAccount failed: %v", err)
+	}
+	if account.CreatedAt == nil || !createdAt.Equal(*account.CreatedAt) {
+		t.Errorf("Expected recorded creation time %v, got %v", account.CreatedAt, createdAt)
+	}
+}
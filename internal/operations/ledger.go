@@ -0,0 +1,349 @@
+package operations
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+	"trading-journal-cli/internal/models"
+	"trading-journal-cli/internal/storage"
+)
+
+// ledgerEpsilon 流水金额差异小于该值时视为一致
+const ledgerEpsilon = 1e-9
+
+// loadAccount 加载账户配置并确认账户存在
+func (o *Operations) loadAccount(accountName string) (*models.Account, error) {
+	if o.accountManager == nil {
+		return nil, fmt.Errorf("account manager is not configured")
+	}
+	if err := o.accountManager.Load(); err != nil {
+		return nil, fmt.Errorf("failed to load account config: %w", err)
+	}
+	return o.accountManager.GetAccount(accountName)
+}
+
+// ensureLedger 为还没有资金流水的账户（旧数据）写入期初流水，只在会修改账户余额的操作中调用
+// 检查和写入在同一把锁内完成，避免多个进程同时为同一账户补记期初流水。
+func (o *Operations) ensureLedger(accountName string) error {
+	lock, err := o.accountManager.Lock()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	entries, err := o.accountManager.ReadLedger(accountName)
+	if err != nil {
+		return err
+	}
+	if len(entries) > 0 {
+		return nil
+	}
+
+	backfill, err := o.ledgerBackfill(accountName)
+	if err != nil {
+		return err
+	}
+	return o.accountManager.AppendLedgerEntries(backfill...)
+}
+
+// ledgerBackfill 按账户当前余额和交易记录推算还没有资金流水的账户的期初流水，不写入
+//
+// 期初余额 = 当前余额 - 已实现的累计盈亏（包括部分平仓），随后为每次平仓补记盈亏和费用流水，
+// 使补记后的流水合计等于当前余额。
+func (o *Operations) ledgerBackfill(accountName string) ([]models.LedgerEntry, error) {
+	account, err := o.loadAccount(accountName)
+	if err != nil {
+		return nil, err
+	}
+
+	positions, err := o.ListPositions(FilterParams{AccountName: accountName})
+	if err != nil {
+		return nil, err
+	}
+
+	openingTime := time.Now()
+	var tradeEntries []models.LedgerEntry
+	var tradePnL float64
+	for _, pos := range positions {
+		if pos.OpenTime.Before(openingTime) {
+			openingTime = pos.OpenTime
+		}
+		for _, entry := range positionLedgerEntries(pos, nil) {
+			tradePnL += entry.Amount
+			tradeEntries = append(tradeEntries, entry)
+		}
+	}
+
+	newEntries := make([]models.LedgerEntry, 0, len(tradeEntries)+1)
+	if opening := account.Balance - tradePnL; opening != 0 {
+		newEntries = append(newEntries, models.NewLedgerEntry(accountName, models.LedgerAdjustment,
+			opening, openingTime, "期初余额（由已有余额和历史平仓盈亏推算）"))
+	}
+	newEntries = append(newEntries, tradeEntries...)
+	return newEntries, nil
+}
+
+// positionLedgerEntries 计算仓位应记的盈亏和费用流水与已记流水的差额
+//
+// 每次平仓（包括部分平仓）在成交时间记入毛盈亏和费用（负数），开仓和加仓费用计入之后的第一次平仓，
+// 见 Position.RealizedAmounts；作废的仓位不计入账户余额。posted 为该仓位已记的流水，按发生时间与应记金额比较，
+// 返回需要追加的更正流水：修改交易记录时在原成交时间更正，撤销的成交在原记账时间冲回。
+func positionLedgerEntries(pos *models.Position, posted []models.LedgerEntry) []models.LedgerEntry {
+	type amounts struct {
+		at                        time.Time
+		expectedPnL, expectedFees float64
+		postedPnL, postedFees     float64
+		posted                    bool
+	}
+	byTime := make(map[int64]*amounts)
+	bucket := func(at time.Time) *amounts {
+		key := at.UnixNano()
+		if byTime[key] == nil {
+			byTime[key] = &amounts{at: at}
+		}
+		return byTime[key]
+	}
+
+	var expectedPnL, expectedFees, postedPnL, postedFees float64
+	if pos.Voided == nil {
+		for _, realized := range pos.RealizedAmounts() {
+			b := bucket(realized.Time)
+			b.expectedPnL += realized.GrossPnL
+			b.expectedFees -= realized.Fees
+			expectedPnL += realized.GrossPnL
+			expectedFees -= realized.Fees
+		}
+	}
+	for _, entry := range posted {
+		b := bucket(entry.Time)
+		switch entry.Type {
+		case models.LedgerRealizedPnL:
+			b.postedPnL += entry.Amount
+			postedPnL += entry.Amount
+		case models.LedgerFee:
+			b.postedFees += entry.Amount
+			postedFees += entry.Amount
+		}
+		b.posted = true
+	}
+
+	// 旧版本只在完全平仓时按平仓时间记一笔合计，合计一致时不按成交时间重新拆分
+	if math.Abs(expectedPnL-postedPnL) <= ledgerEpsilon && math.Abs(expectedFees-postedFees) <= ledgerEpsilon {
+		return nil
+	}
+
+	keys := make([]int64, 0, len(byTime))
+	for key := range byTime {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	var result []models.LedgerEntry
+	for _, key := range keys {
+		b := byTime[key]
+		if diff := b.expectedPnL - b.postedPnL; math.Abs(diff) > ledgerEpsilon {
+			note := "平仓盈亏"
+			if b.posted {
+				note = "更正平仓盈亏（交易记录已修改）"
+			}
+			entry := models.NewLedgerEntry(pos.AccountName, models.LedgerRealizedPnL, diff, b.at, note)
+			entry.PositionID = pos.PositionID
+			result = append(result, entry)
+		}
+		if diff := b.expectedFees - b.postedFees; math.Abs(diff) > ledgerEpsilon {
+			note := "交易费用"
+			if b.posted {
+				note = "更正交易费用（交易记录已修改）"
+			}
+			entry := models.NewLedgerEntry(pos.AccountName, models.LedgerFee, diff, b.at, note)
+			entry.PositionID = pos.PositionID
+			result = append(result, entry)
+		}
+	}
+
+	return result
+}
+
+// postPositionLedger 按仓位当前状态补记盈亏和费用流水
+func (o *Operations) postPositionLedger(pos *models.Position) ([]models.LedgerEntry, error) {
+	entries, err := o.accountManager.ReadLedger(pos.AccountName)
+	if err != nil {
+		return nil, err
+	}
+
+	var posted []models.LedgerEntry
+	for _, entry := range entries {
+		if entry.PositionID == pos.PositionID {
+			posted = append(posted, entry)
+		}
+	}
+
+	newEntries := positionLedgerEntries(pos, posted)
+	if err := o.accountManager.AppendLedgerEntries(newEntries...); err != nil {
+		return nil, err
+	}
+	return newEntries, nil
+}
+
+// withPositionLedger 按"生成期初流水 → 修改仓位 → 记账"的顺序执行会影响账户余额的仓位修改
+//
+// ensure 为 true 时先为旧账户生成期初流水，失败时放弃修改：否则本仓位的流水会写入空的流水文件，
+// 按流水重算余额后期初余额丢失。mutate 保存仓位修改，失败时不记账；post 追加本仓位的流水，
+// 失败不影响已保存的修改，只输出警告，可稍后通过 account rebuild 补记。
+func (o *Operations) withPositionLedger(accountName string, ensure bool, mutate func() error, post func() ([]models.LedgerEntry, error)) ([]models.LedgerEntry, error) {
+	ledger := ensure && o.accountManager != nil
+	if ledger {
+		if err := o.ensureLedger(accountName); err != nil {
+			return nil, fmt.Errorf("failed to initialize account ledger: %w", err)
+		}
+	}
+
+	if err := mutate(); err != nil {
+		return nil, err
+	}
+
+	if !ledger {
+		return nil, nil
+	}
+	entries, err := post()
+	if err != nil {
+		fmt.Printf("Warning: failed to update account balance: %v\n", err)
+	}
+	return entries, nil
+}
+
+// Deposit 入金
+func (o *Operations) Deposit(accountName string, amount float64, at time.Time, note string) (*models.LedgerEntry, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("deposit amount must be greater than 0")
+	}
+	return o.appendCashEntry(accountName, models.LedgerDeposit, amount, at, note)
+}
+
+// Withdraw 出金，amount 为正数
+func (o *Operations) Withdraw(accountName string, amount float64, at time.Time, note string) (*models.LedgerEntry, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("withdrawal amount must be greater than 0")
+	}
+	return o.appendCashEntry(accountName, models.LedgerWithdrawal, -amount, at, note)
+}
+
+// SetBalance 将账户余额校正为指定值，差额记为一笔调整流水
+func (o *Operations) SetBalance(accountName string, balance float64, note string) (*models.LedgerEntry, error) {
+	lock, err := o.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	if err := o.ensureLedger(accountName); err != nil {
+		return nil, err
+	}
+	account, err := o.accountManager.GetAccount(accountName)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := balance - account.Balance
+	if math.Abs(diff) <= ledgerEpsilon {
+		return nil, fmt.Errorf("balance is unchanged")
+	}
+	if note == "" {
+		note = "手动校正余额"
+	}
+	return o.appendCashEntry(accountName, models.LedgerAdjustment, diff, time.Now(), note)
+}
+
+// appendCashEntry 追加一笔与交易无关的资金流水
+// 与平仓等操作使用同一把数据目录锁，期初流水的推算不会与并发的仓位修改交错
+func (o *Operations) appendCashEntry(accountName string, entryType models.LedgerEntryType, amount float64, at time.Time, note string) (*models.LedgerEntry, error) {
+	lock, err := o.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	if err := o.ensureLedger(accountName); err != nil {
+		return nil, err
+	}
+
+	entry := models.NewLedgerEntry(accountName, entryType, amount, at, note)
+	if err := o.accountManager.AppendLedgerEntries(entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// AccountLedger 获取账户的资金流水（按发生时间排序）
+// 还没有资金流水的账户（旧数据）返回在内存中推算的期初流水，只读不写
+func (o *Operations) AccountLedger(accountName string) ([]models.LedgerEntry, error) {
+	entries, err := o.accountManager.ReadLedger(accountName)
+	if err != nil || len(entries) > 0 {
+		return entries, err
+	}
+
+	backfill, err := o.ledgerBackfill(accountName)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(backfill, func(i, j int) bool {
+		return backfill[i].Time.Before(backfill[j].Time)
+	})
+	return backfill, nil
+}
+
+// RebuildBalance 按当前交易记录核对账户的盈亏和费用流水
+//
+// 修改过去的交易后，已记流水与交易记录不再一致。该操作不改写已有流水，
+// 而是为每个不一致的仓位追加差额流水，返回追加的流水。
+func (o *Operations) RebuildBalance(accountName string) ([]models.LedgerEntry, error) {
+	lock, err := o.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	if err := o.ensureLedger(accountName); err != nil {
+		return nil, err
+	}
+
+	entries, err := o.accountManager.ReadLedger(accountName)
+	if err != nil {
+		return nil, err
+	}
+	posted := make(map[string][]models.LedgerEntry)
+	for _, entry := range entries {
+		if entry.PositionID != "" {
+			posted[entry.PositionID] = append(posted[entry.PositionID], entry)
+		}
+	}
+
+	var corrections []models.LedgerEntry
+	seen := make(map[string]bool)
+	for pos, err := range o.storage.Positions(storage.Query{Account: accountName}) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to read positions: %w", err)
+		}
+		seen[pos.PositionID] = true
+		corrections = append(corrections, positionLedgerEntries(pos, posted[pos.PositionID])...)
+	}
+
+	// 已记流水但交易记录已不存在（或已转到其他账户）的仓位，冲回全部流水
+	removedIDs := make([]string, 0)
+	for positionID := range posted {
+		if !seen[positionID] {
+			removedIDs = append(removedIDs, positionID)
+		}
+	}
+	sort.Strings(removedIDs)
+	for _, positionID := range removedIDs {
+		removed := &models.Position{PositionID: positionID, AccountName: accountName}
+		corrections = append(corrections, positionLedgerEntries(removed, posted[positionID])...)
+	}
+
+	if err := o.accountManager.AppendLedgerEntries(corrections...); err != nil {
+		return nil, err
+	}
+	return corrections, nil
+}
@@ -0,0 +1,179 @@
+package operations
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"trading-journal-cli/internal/models"
+	"trading-journal-cli/internal/storage"
+	"trading-journal-cli/internal/validator"
+)
+
+// ledgerKey 按发生时间和类型汇总的流水金额
+type ledgerKey struct {
+	hour      int // 相对 testTime 的小时数
+	entryType models.LedgerEntryType
+}
+
+// ledgerAmounts 按发生时间和类型汇总账户流水，金额为 0 的项不保留
+func ledgerAmounts(t *testing.T, accountMgr *models.AccountManager) map[ledgerKey]float64 {
+	t.Helper()
+	entries, err := accountMgr.ReadLedger("main")
+	if err != nil {
+		t.Fatalf("ReadLedger failed: %v", err)
+	}
+	amounts := make(map[ledgerKey]float64)
+	for _, entry := range entries {
+		key := ledgerKey{int(entry.Time.Sub(testTime) / time.Hour), entry.Type}
+		amounts[key] += entry.Amount
+		if math.Abs(amounts[key]) < 1e-9 {
+			delete(amounts, key)
+		}
+	}
+	return amounts
+}
+
+// checkLedger 比较按发生时间和类型汇总的流水，以及按流水计算的账户余额
+func checkLedger(t *testing.T, accountMgr *models.AccountManager, expected map[ledgerKey]float64, balance float64) {
+	t.Helper()
+	got := ledgerAmounts(t, accountMgr)
+	for key, amount := range expected {
+		if math.Abs(got[key]-amount) > 1e-9 {
+			t.Errorf("Expected %s %.2f at +%dh, got %.2f", key.entryType, amount, key.hour, got[key])
+		}
+	}
+	for key, amount := range got {
+		if _, ok := expected[key]; !ok {
+			t.Errorf("Unexpected %s %.2f at +%dh", key.entryType, amount, key.hour)
+		}
+	}
+
+	account, err := accountMgr.GetAccount("main")
+	if err != nil {
+		t.Fatalf("GetAccount failed: %v", err)
+	}
+	if math.Abs(account.Balance-balance) > 1e-9 {
+		t.Errorf("Expected balance %.2f, got %.2f", balance, account.Balance)
+	}
+}
+
+func TestPositionLedger(t *testing.T) {
+	at := func(hours int) time.Time { return testTime.Add(time.Duration(hours) * time.Hour) }
+	fee := func(amount float64) models.Fees { return models.Fees{Commission: amount} }
+
+	tests := []struct {
+		name     string
+		run      func(t *testing.T, ops *Operations)
+		expected map[ledgerKey]float64
+		balance  float64
+	}{
+		{
+			name: "Partial closes post each fill, entry fees go to the first",
+			run: func(t *testing.T, ops *Operations) {
+				params := testOpenParams("main", "BTC", 2, at(0))
+				params.Fees = fee(2)
+				pos, err := ops.OpenPosition(params)
+				if err != nil {
+					t.Fatalf("OpenPosition failed: %v", err)
+				}
+				closeParams := testCloseParams(110, 1, at(1))
+				closeParams.Fees = fee(1)
+				if _, err := ops.ClosePosition(pos.PositionID, closeParams); err != nil {
+					t.Fatalf("ClosePosition failed: %v", err)
+				}
+				addTime := at(2)
+				if _, err := ops.AddToPosition(pos.PositionID, AddParams{Price: 100, Quantity: 1, Margin: 50, Fees: fee(1), Time: &addTime}); err != nil {
+					t.Fatalf("AddToPosition failed: %v", err)
+				}
+				closeParams = testCloseParams(90, 2, at(3))
+				closeParams.Fees = fee(1)
+				if _, err := ops.ClosePosition(pos.PositionID, closeParams); err != nil {
+					t.Fatalf("ClosePosition failed: %v", err)
+				}
+			},
+			expected: map[ledgerKey]float64{
+				{1, models.LedgerRealizedPnL}: 10,
+				{1, models.LedgerFee}:         -3,
+				{2, models.LedgerFee}:         -1,
+				{3, models.LedgerRealizedPnL}: -20,
+				{3, models.LedgerFee}:         -1,
+			},
+			balance: -15,
+		},
+		{
+			name: "Edit corrects each fill at its original time",
+			run: func(t *testing.T, ops *Operations) {
+				pos, err := ops.OpenPosition(testOpenParams("main", "BTC", 2, at(0)))
+				if err != nil {
+					t.Fatalf("OpenPosition failed: %v", err)
+				}
+				if _, err := ops.ClosePosition(pos.PositionID, testCloseParams(110, 1, at(1))); err != nil {
+					t.Fatalf("ClosePosition failed: %v", err)
+				}
+				if _, err := ops.ClosePosition(pos.PositionID, testCloseParams(120, 1, at(2))); err != nil {
+					t.Fatalf("ClosePosition failed: %v", err)
+				}
+				openPrice := 105.0
+				if _, err := ops.EditPosition(pos.PositionID, EditParams{OpenPrice: &openPrice, EditReason: "typo"}); err != nil {
+					t.Fatalf("EditPosition failed: %v", err)
+				}
+			},
+			expected: map[ledgerKey]float64{
+				{1, models.LedgerRealizedPnL}: 5,
+				{2, models.LedgerRealizedPnL}: 15,
+			},
+			balance: 20,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops, accountMgr := newTestOperations(t)
+			tt.run(t, ops)
+			checkLedger(t, accountMgr, tt.expected, tt.balance)
+		})
+	}
+}
+
+func TestPositionLedger_Backfill(t *testing.T) {
+	at := func(hours int) time.Time { return testTime.Add(time.Duration(hours) * time.Hour) }
+
+	// 旧账户：余额已包含部分平仓的盈亏（+5 - 1），但还没有资金流水
+	dataDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dataDir, "accounts.json"), []byte(`{"accounts":[{"name":"main","balance":1004}]}`), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	store := storage.NewJSONLStorage(dataDir)
+	legacy := NewOperations(store, validator.NewPositionValidator(), nil, nil, nil)
+	pos, err := legacy.OpenPosition(testOpenParams("main", "BTC", 2, at(0)))
+	if err != nil {
+		t.Fatalf("OpenPosition failed: %v", err)
+	}
+	closeParams := testCloseParams(105, 1, at(1))
+	closeParams.Fees = models.Fees{Commission: 1}
+	if _, err := legacy.ClosePosition(pos.PositionID, closeParams); err != nil {
+		t.Fatalf("ClosePosition failed: %v", err)
+	}
+
+	// 之后的平仓先按已有余额和历史成交补记期初流水，再记入本次成交
+	accountMgr := models.NewAccountManager(dataDir)
+	if err := accountMgr.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	ops := NewOperations(store, validator.NewPositionValidator(), accountMgr, nil, models.NewFXTable(dataDir))
+	closeParams = testCloseParams(110, 1, at(2))
+	closeParams.Fees = models.Fees{Commission: 1}
+	if _, err := ops.ClosePosition(pos.PositionID, closeParams); err != nil {
+		t.Fatalf("ClosePosition failed: %v", err)
+	}
+
+	checkLedger(t, accountMgr, map[ledgerKey]float64{
+		{0, models.LedgerAdjustment}:  1000,
+		{1, models.LedgerRealizedPnL}: 5,
+		{1, models.LedgerFee}:         -1,
+		{2, models.LedgerRealizedPnL}: 10,
+		{2, models.LedgerFee}:         -1,
+	}, 1013)
+}
@@ -1,8 +1,10 @@
 package operations
 
 import (
+	"errors"
 	"fmt"
 	"time"
+	"trading-journal-cli/internal/fsutil"
 	"trading-journal-cli/internal/models"
 	"trading-journal-cli/internal/storage"
 	"trading-journal-cli/internal/validator"
@@ -20,8 +22,10 @@ type OpenParams struct {
 	StopLoss       float64
 	TakeProfit     float64
 	Margin         float64
+	Fees           models.Fees // 开仓费用
 	Reason         string
 	OpenTime       *time.Time // 可选，为空时使用当前时间
+	RiskOverride   string     // 违反账户风控限制时强制开仓的理由，为空时拒绝开仓
 
 	// 市场背景信息
 	MarketContext       models.MarketContext
@@ -38,8 +42,28 @@ type CloseParams struct {
 	CloseQuantity float64
 	CloseReason   models.CloseReason
 	CloseNote     string
-	CloseTime     *time.Time // 可选，为空时使用当前时间
-	ManualPnL     *float64   // 可选，手动输入的盈亏（优先使用）
+	CloseTime     *time.Time  // 可选，为空时使用当前时间
+	ManualPnL     *float64    // 可选，手动输入的毛盈亏（优先使用，费用另行扣除）
+	Fees          models.Fees // 平仓费用及持仓期间累计的资金费
+}
+
+// AddParams 加仓参数
+type AddParams struct {
+	Price    float64
+	Quantity float64
+	Margin   float64     // 本次加仓追加的保证金
+	Fees     models.Fees // 加仓费用
+	Note     string
+	Time     *time.Time // 可选，为空时使用当前时间
+}
+
+// AdjustParams 止损止盈调整参数
+type AdjustParams struct {
+	StopLoss     *float64 // 可选，为空时保持不变
+	TakeProfit   *float64 // 可选，为空时保持不变
+	CurrentPrice float64  // 可选，大于 0 时以当前价格验证止损止盈范围，否则以开仓价验证
+	Note         string
+	Time         *time.Time // 可选，为空时使用当前时间
 }
 
 // FilterParams 筛选参数
@@ -50,6 +74,17 @@ type FilterParams struct {
 	AccountName string    // 账户名称，为空则不筛选
 	FromDate    time.Time // 零值则不筛选
 	ToDate      time.Time // 零值则不筛选
+
+	// 包含已作废的仓位，默认排除
+	IncludeVoided bool
+
+	// 查看过去某一时刻的状态：按版本历史重建仓位，零值表示当前状态
+	// 状态筛选和其他条件都作用于重建后的仓位
+	AsOf time.Time
+
+	// 报告币种，分析时将各账户币种的金额换算为该币种后再汇总
+	// 为空时使用所有仓位共同的账户币种，账户币种不一致时使用 models.DefaultCurrency
+	Currency string
 }
 
 // Operations 操作接口
@@ -57,15 +92,75 @@ type Operations struct {
 	storage        storage.Storage
 	validator      validator.Validator
 	accountManager *models.AccountManager
+	instruments    *models.InstrumentRegistry
+	fx             *models.FXTable
 }
 
 // NewOperations 创建新的操作实例
-func NewOperations(store storage.Storage, valid validator.Validator, accountMgr *models.AccountManager) *Operations {
+func NewOperations(store storage.Storage, valid validator.Validator, accountMgr *models.AccountManager, instruments *models.InstrumentRegistry, fx *models.FXTable) *Operations {
 	return &Operations{
 		storage:        store,
 		validator:      valid,
 		accountManager: accountMgr,
+		instruments:    instruments,
+		fx:             fx,
+	}
+}
+
+// lock 获取数据目录锁
+// 读取仓位、验证、追加新版本和记账需在同一把锁内完成，否则多个进程会基于同一版本修改仓位，
+// 后写入的版本覆盖先写入的修改，更正流水也可能重复记入
+func (o *Operations) lock() (*fsutil.Lock, error) {
+	return o.storage.Lock()
+}
+
+// pointValue 仓位每单位数量价格变动 1 对应的盈亏
+// 优先使用开仓时记录的合约乘数，其次查询品种注册表，都没有时为 1
+func (o *Operations) pointValue(pos *models.Position) float64 {
+	if pos.Multiplier > 0 {
+		return pos.Multiplier
 	}
+	if o.instruments != nil {
+		return o.instruments.PointValue(pos.Symbol)
+	}
+	return 1
+}
+
+// InitialRisk 仓位的初始风险（1R）
+// 开仓时已记录则直接使用；旧记录按第一次调整前的止损和开仓成交补算
+func (o *Operations) InitialRisk(pos *models.Position) float64 {
+	if pos.InitialRisk > 0 {
+		return pos.InitialRisk
+	}
+	var risk float64
+	stopLoss := pos.InitialStopLoss()
+	for _, entry := range pos.Entries {
+		risk += models.CalculateRiskAmount(pos.Direction, entry.Price, stopLoss, entry.Quantity, o.pointValue(pos))
+	}
+	return risk
+}
+
+// RMultiple 仓位的 R 倍数（净盈亏 / 初始风险），尚未平仓或无法确定初始风险时返回 false
+func (o *Operations) RMultiple(pos *models.Position) (float64, bool) {
+	if pos.RMultiple != nil {
+		return *pos.RMultiple, true
+	}
+	if len(pos.Fills) == 0 {
+		return 0, false
+	}
+	risk := o.InitialRisk(pos)
+	if risk <= 0 {
+		return 0, false
+	}
+	return pos.TotalRealizedPnL() / risk, true
+}
+
+// feesOrNil 未填写任何费用时返回 nil，避免写入空的费用字段
+func feesOrNil(fees models.Fees) *models.Fees {
+	if fees == (models.Fees{}) {
+		return nil
+	}
+	return &fees
 }
 
 // OpenPosition 开仓操作
@@ -100,13 +195,54 @@ func (o *Operations) OpenPosition(params OpenParams) (*models.Position, error) {
 		VolumeDecrease:      params.VolumeDecrease,
 		ConsecutiveLowBreak: params.ConsecutiveLowBreak,
 		MarketNote:          params.MarketNote,
+
+		Entries: []models.EntryFill{{
+			Time:     openTime,
+			Price:    params.OpenPrice,
+			Quantity: params.Quantity,
+			Margin:   params.Margin,
+			Fees:     feesOrNil(params.Fees),
+		}},
 	}
 
+	// 记录品种注册表中的合约乘数，之后修改注册表不影响已开仓位
+	if o.instruments != nil {
+		if inst, err := o.instruments.GetInstrument(pos.Symbol); err == nil {
+			pos.Multiplier = inst.PointValue()
+		}
+	}
+
+	// 记录开仓时的账户币种，之后账户被删除或改名时仓位仍按原币种换算
+	if o.accountManager != nil {
+		if account, err := o.loadAccount(pos.AccountName); err == nil {
+			pos.Currency = account.CurrencyCode()
+		}
+	}
+
+	// 冻结初始风险（1R），之后调整止损不影响 R 倍数
+	pos.InitialRisk = models.CalculateRiskAmount(pos.Direction, pos.OpenPrice, pos.StopLoss, pos.Quantity, o.pointValue(pos))
+
 	// 验证数据
 	if err := o.validator.ValidateOpenPosition(pos); err != nil {
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
 
+	lock, err := o.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	// 检查账户熔断，锁定期间拒绝开仓
+	if err := o.checkCircuitBreaker(pos.AccountName); err != nil {
+		return nil, err
+	}
+
+	// 检查账户风控限制
+	if err := o.checkRiskLimits(pos, params.RiskOverride); err != nil {
+		return nil, err
+	}
+
 	// 保存到存储
 	if err := o.storage.AppendPosition(pos); err != nil {
 		return nil, fmt.Errorf("failed to save position: %w", err)
@@ -115,8 +251,62 @@ func (o *Operations) OpenPosition(params OpenParams) (*models.Position, error) {
 	return pos, nil
 }
 
+// checkRiskLimits 按账户现有未平仓位检查新仓位的风控限制
+// 违反限制且提供了强制开仓理由时，将理由和违反项记录在仓位上
+func (o *Operations) checkRiskLimits(pos *models.Position, overrideReason string) error {
+	if o.accountManager == nil {
+		return nil
+	}
+	account, err := o.loadAccount(pos.AccountName)
+	if err != nil {
+		return fmt.Errorf("failed to check risk limits: %w", err)
+	}
+	if account.RiskLimits.IsEmpty() {
+		return nil
+	}
+
+	// 现有仓位按当前止损计算风险，已移到保本以上的仓位风险为 0
+	exposure := validator.RiskExposure{Balance: account.Balance}
+	for open, err := range o.storage.Positions(storage.Query{Account: pos.AccountName, Status: models.StatusOpen}) {
+		if err != nil {
+			return fmt.Errorf("failed to read open positions: %w", err)
+		}
+		exposure.OpenRisk += models.CalculateRiskAmount(open.Direction, open.OpenPrice, open.StopLoss, open.Quantity, o.pointValue(open))
+		exposure.OpenMargin += open.Margin
+		if open.Symbol == pos.Symbol {
+			exposure.SymbolPositions++
+		}
+	}
+
+	err = o.validator.ValidateRiskLimits(pos, account.RiskLimits, exposure)
+	var limitErr *validator.RiskLimitError
+	if !errors.As(err, &limitErr) {
+		return err
+	}
+	if overrideReason == "" {
+		return fmt.Errorf("risk limit check failed: %w", err)
+	}
+
+	violations := make([]string, len(limitErr.Violations))
+	for i, v := range limitErr.Violations {
+		violations[i] = v.Error()
+	}
+	pos.RiskOverride = &models.RiskOverride{
+		Time:       time.Now(),
+		Reason:     overrideReason,
+		Violations: violations,
+	}
+	return nil
+}
+
 // ClosePosition 平仓操作
 func (o *Operations) ClosePosition(positionID string, params CloseParams) (*models.Position, error) {
+	lock, err := o.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
 	// 查找仓位
 	pos, err := o.storage.FindPositionByID(positionID)
 	if err != nil {
@@ -134,120 +324,238 @@ func (o *Operations) ClosePosition(positionID string, params CloseParams) (*mode
 		closeTime = *params.CloseTime
 	}
 
-	// 计算盈亏（优先使用手动输入的值）
-	var realizedPnL float64
+	// 计算本次成交毛盈亏（优先使用手动输入的值）
+	var grossPnL float64
 	if params.ManualPnL != nil {
 		// 使用手动输入的盈亏
-		realizedPnL = *params.ManualPnL
+		grossPnL = *params.ManualPnL
 	} else {
-		// 自动计算盈亏
-		realizedPnL = models.CalculateRealizedPnL(pos.Direction, pos.OpenPrice, params.ClosePrice, params.CloseQuantity)
+		// 自动计算盈亏（按合约乘数换算为账户货币）
+		grossPnL = models.CalculateRealizedPnL(pos.Direction, pos.OpenPrice, params.ClosePrice, params.CloseQuantity) * o.pointValue(pos)
 	}
-	pnlPercentage := models.CalculatePnLPercentage(realizedPnL, pos.AccountBalance)
-	marginROI := models.CalculateMarginROI(realizedPnL, pos.Margin)
-	holdingDuration := models.FormatHoldingDuration(closeTime.Sub(pos.OpenTime))
-
-	// 更新仓位数量（减去已平仓数量）
-	pos.Quantity -= params.CloseQuantity
+	fees := feesOrNil(params.Fees)
+
+	// 追加成交记录（净盈亏 = 毛盈亏 - 本次平仓费用）
+	pos.Fills = append(pos.Fills, models.CloseFill{
+		CloseTime:     closeTime,
+		ClosePrice:    params.ClosePrice,
+		CloseQuantity: params.CloseQuantity,
+		GrossPnL:      grossPnL,
+		Fees:          fees,
+		RealizedPnL:   grossPnL - fees.Total(),
+		CloseReason:   params.CloseReason,
+		CloseNote:     params.CloseNote,
+		ManualPnL:     params.ManualPnL != nil,
+	})
+
+	// 更新仓位数量（减去已平仓数量），剩余数量在容差以内视为完全平仓
+	pos.Quantity = models.NormalizeQuantity(pos.Quantity - params.CloseQuantity)
 
 	// 判断是否完全平仓
-	if pos.Quantity == 0 {
+	if pos.Quantity <= 0 {
+		pos.Quantity = 0
 		pos.Status = models.StatusClosed
 	} else {
 		// 部分平仓，状态保持 open
 		pos.Status = models.StatusOpen
 	}
 
-	pos.CloseTime = &closeTime
-	pos.ClosePrice = &params.ClosePrice
-	pos.CloseQuantity = &params.CloseQuantity
-	pos.RealizedPnL = &realizedPnL
-	pos.PnLPercentage = &pnlPercentage
-	pos.MarginROI = &marginROI
-	pos.HoldingDuration = &holdingDuration
-	pos.CloseReason = &params.CloseReason
-	pos.CloseNote = params.CloseNote
-
-	// 保存更新后的记录
-	if err := o.storage.UpdatePosition(pos); err != nil {
-		return nil, fmt.Errorf("failed to update position: %w", err)
+	// 旧记录没有冻结初始风险，按开仓时的止损补算后再计算 R 倍数
+	if pos.InitialRisk == 0 {
+		pos.InitialRisk = o.InitialRisk(pos)
 	}
 
-	// 更新账户余额（在完全平仓时）
-	if pos.Status == models.StatusClosed && o.accountManager != nil {
-		// 加载账户配置
-		if err := o.accountManager.Load(); err != nil {
-			// 如果加载失败，记录错误但不影响平仓操作
-			fmt.Printf("Warning: failed to load account config: %v\n", err)
-		} else {
-			// 获取当前账户
-			account, err := o.accountManager.GetAccount(pos.AccountName)
-			if err != nil {
-				fmt.Printf("Warning: failed to get account %s: %v\n", pos.AccountName, err)
-			} else {
-				// 更新账户余额：当前余额 + 盈亏
-				newBalance := account.Balance + realizedPnL
-				if err := o.accountManager.UpdateAccount(pos.AccountName, newBalance); err != nil {
-					fmt.Printf("Warning: failed to update account balance: %v\n", err)
-				}
-			}
+	// 汇总所有成交：累计盈亏、加权平均平仓价、累计平仓数量
+	pos.RefreshCloseSummary()
+
+	// 保存更新后的记录；旧账户在记录本次平仓之前生成期初流水，避免本次盈亏被重复计入
+	// 每次平仓（包括部分平仓）记入本次的盈亏和费用流水，账户余额由流水汇总得到
+	_, err = o.withPositionLedger(pos.AccountName, true, func() error {
+		if err := o.storage.UpdatePosition(pos); err != nil {
+			return fmt.Errorf("failed to update position: %w", err)
 		}
+		return nil
+	}, func() ([]models.LedgerEntry, error) {
+		return o.postPositionLedger(pos)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return pos, nil
 }
 
-// ListPositions 列出仓位
-func (o *Operations) ListPositions(filter FilterParams) ([]*models.Position, error) {
-	// 读取所有仓位
-	allPositions, err := o.storage.ReadAllPositions()
+// AddToPosition 加仓操作
+func (o *Operations) AddToPosition(positionID string, params AddParams) (*models.Position, error) {
+	lock, err := o.lock()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read positions: %w", err)
+		return nil, err
 	}
+	defer lock.Unlock()
 
-	// 应用筛选条件
-	result := make([]*models.Position, 0)
-	for _, pos := range allPositions {
-		// 状态筛选
-		if filter.Status != "" && filter.Status != "all" {
-			if filter.Status == "open" && pos.Status != models.StatusOpen {
-				continue
-			}
-			if filter.Status == "closed" && pos.Status != models.StatusClosed {
-				continue
-			}
-		}
+	// 查找仓位
+	pos, err := o.storage.FindPositionByID(positionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find position: %w", err)
+	}
 
-		// 品种筛选
-		if filter.Symbol != "" && pos.Symbol != filter.Symbol {
-			continue
-		}
+	// 设置加仓时间
+	entryTime := time.Now()
+	if params.Time != nil {
+		entryTime = *params.Time
+	}
 
-		// 市场类型筛选
-		if filter.MarketType != "" && string(pos.MarketType) != filter.MarketType {
-			continue
-		}
+	entry := models.EntryFill{
+		Time:     entryTime,
+		Price:    params.Price,
+		Quantity: params.Quantity,
+		Margin:   params.Margin,
+		Fees:     feesOrNil(params.Fees),
+		Note:     params.Note,
+	}
 
-		// 账户筛选
-		if filter.AccountName != "" && pos.AccountName != filter.AccountName {
-			continue
-		}
+	// 验证加仓数据
+	if err := o.validator.ValidateAddToPosition(pos, entry); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
 
-		// 日期范围筛选
-		if !filter.FromDate.IsZero() && pos.OpenTime.Before(filter.FromDate) {
-			continue
-		}
-		if !filter.ToDate.IsZero() && pos.OpenTime.After(filter.ToDate) {
-			continue
+	// 检查账户熔断，锁定期间与开仓一样拒绝加仓
+	if err := o.checkCircuitBreaker(pos.AccountName); err != nil {
+		return nil, err
+	}
+
+	// 加仓部分按当前止损计入初始风险（旧记录先补算开仓时的风险）
+	if pos.InitialRisk == 0 {
+		pos.InitialRisk = o.InitialRisk(pos)
+	}
+	pos.InitialRisk += models.CalculateRiskAmount(pos.Direction, entry.Price, pos.StopLoss, entry.Quantity, o.pointValue(pos))
+
+	// 重新计算加权平均开仓价、数量和保证金
+	pos.AddEntry(entry)
+
+	// 合并后的仓位需满足当前止损止盈的范围要求
+	if err := o.validator.ValidateOpenPosition(pos); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	// 部分平仓后加仓，汇总字段中的盈亏比例需按新的保证金重新计算
+	if len(pos.Fills) > 0 {
+		pos.RefreshCloseSummary()
+	}
+
+	// 保存更新后的记录；部分平仓后加仓的费用已计入累计净盈亏，同时记入费用流水
+	_, err = o.withPositionLedger(pos.AccountName, len(pos.Fills) > 0, func() error {
+		if err := o.storage.UpdatePosition(pos); err != nil {
+			return fmt.Errorf("failed to update position: %w", err)
 		}
+		return nil
+	}, func() ([]models.LedgerEntry, error) {
+		return o.postPositionLedger(pos)
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		result = append(result, pos)
+	return pos, nil
+}
+
+// AdjustPosition 调整止损止盈
+func (o *Operations) AdjustPosition(positionID string, params AdjustParams) (*models.Position, error) {
+	lock, err := o.lock()
+	if err != nil {
+		return nil, err
 	}
+	defer lock.Unlock()
 
-	return result, nil
+	// 查找仓位
+	pos, err := o.storage.FindPositionByID(positionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find position: %w", err)
+	}
+
+	stopLoss := pos.StopLoss
+	if params.StopLoss != nil {
+		stopLoss = *params.StopLoss
+	}
+	takeProfit := pos.TakeProfit
+	if params.TakeProfit != nil {
+		takeProfit = *params.TakeProfit
+	}
+
+	// 验证新的止损止盈
+	if err := o.validator.ValidateAdjustPosition(pos, stopLoss, takeProfit, params.CurrentPrice); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if stopLoss == pos.StopLoss && takeProfit == pos.TakeProfit {
+		return nil, fmt.Errorf("stop loss and take profit are unchanged")
+	}
+
+	// 设置调整时间
+	adjustTime := time.Now()
+	if params.Time != nil {
+		adjustTime = *params.Time
+	}
+
+	// 记录调整历史
+	pos.Adjustments = append(pos.Adjustments, models.LevelAdjustment{
+		Time:           adjustTime,
+		OldStopLoss:    pos.StopLoss,
+		NewStopLoss:    stopLoss,
+		OldTakeProfit:  pos.TakeProfit,
+		NewTakeProfit:  takeProfit,
+		ReferencePrice: params.CurrentPrice,
+		Note:           params.Note,
+	})
+	pos.StopLoss = stopLoss
+	pos.TakeProfit = takeProfit
+
+	// 保存更新后的记录
+	if err := o.storage.UpdatePosition(pos); err != nil {
+		return nil, fmt.Errorf("failed to update position: %w", err)
+	}
+
+	return pos, nil
+}
+
+// ListPositions 列出仓位
+func (o *Operations) ListPositions(filter FilterParams) ([]*models.Position, error) {
+	if !filter.AsOf.IsZero() {
+		return o.positionsAsOf(filter)
+	}
+	positions, err := storage.Collect(o.storage.Positions(filter.query()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read positions: %w", err)
+	}
+	return positions, nil
+}
+
+// query 将筛选条件转换为存储查询，由存储层跳过不相关的数据
+func (f FilterParams) query() storage.Query {
+	q := storage.Query{
+		Symbol:     f.Symbol,
+		MarketType: models.MarketType(f.MarketType),
+		Account:    f.AccountName,
+		OpenFrom:   f.FromDate,
+		OpenTo:     f.ToDate,
+
+		IncludeVoided: f.IncludeVoided,
+	}
+	switch f.Status {
+	case "open":
+		q.Status = models.StatusOpen
+	case "closed":
+		q.Status = models.StatusClosed
+	}
+	return q
 }
 
 // GetOpenPositions 获取所有未平仓位
 func (o *Operations) GetOpenPositions() ([]*models.Position, error) {
 	return o.storage.ReadOpenPositions()
 }
+
+// GetPosition 按ID获取仓位的最新版本
+func (o *Operations) GetPosition(positionID string) (*models.Position, error) {
+	return o.storage.FindPositionByID(positionID)
+}
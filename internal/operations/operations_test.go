@@ -0,0 +1,54 @@
+package operations
+
+import (
+	"testing"
+	"time"
+	"trading-journal-cli/internal/models"
+	"trading-journal-cli/internal/storage"
+	"trading-journal-cli/internal/validator"
+)
+
+// testTime 测试中默认的交易时间
+var testTime = time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+
+// newTestOperations 在临时目录中创建操作实例和一个没有资金流水的 USD 账户 main
+func newTestOperations(t *testing.T) (*Operations, *models.AccountManager) {
+	t.Helper()
+	dataDir := t.TempDir()
+	accountMgr := models.NewAccountManager(dataDir)
+	if err := accountMgr.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if err := accountMgr.AddAccount(models.Account{Name: "main", Currency: "USD"}); err != nil {
+		t.Fatalf("AddAccount failed: %v", err)
+	}
+	ops := NewOperations(storage.NewJSONLStorage(dataDir), validator.NewPositionValidator(), accountMgr, nil, models.NewFXTable(dataDir))
+	return ops, accountMgr
+}
+
+// testOpenParams 做多开仓参数：开仓价 100，止损 90，止盈 120，保证金 100，开仓时账户余额 1000
+func testOpenParams(accountName, symbol string, quantity float64, at time.Time) OpenParams {
+	return OpenParams{
+		AccountName:    accountName,
+		AccountBalance: 1000,
+		Symbol:         symbol,
+		MarketType:     models.MarketTypeCrypto,
+		Direction:      models.DirectionLong,
+		OpenPrice:      100,
+		Quantity:       quantity,
+		StopLoss:       90,
+		TakeProfit:     120,
+		Margin:         100,
+		OpenTime:       &at,
+	}
+}
+
+// testCloseParams 手动平仓参数
+func testCloseParams(price, quantity float64, at time.Time) CloseParams {
+	return CloseParams{
+		ClosePrice:    price,
+		CloseQuantity: quantity,
+		CloseReason:   models.CloseReasonManual,
+		CloseTime:     &at,
+	}
+}
@@ -0,0 +1,124 @@
+package operations
+
+import (
+	"fmt"
+	"math"
+	"trading-journal-cli/internal/models"
+)
+
+// SizeParams 仓位计算参数
+type SizeParams struct {
+	AccountName  string
+	Symbol       string  // 用于查询合约乘数，可为空
+	OpenPrice    float64 // 计划开仓价
+	StopLoss     float64
+	TakeProfit   float64 // 可选，为 0 时不计算风险回报比
+	RiskPercent  float64 // 风险占账户余额的百分比，与 RiskAmount 二选一
+	RiskAmount   float64 // 固定风险金额（账户币种）
+	Leverage     float64 // 杠杆倍数，0 视为 1（全额）
+	QuantityStep float64 // 数量最小单位，0 表示不取整
+}
+
+// SizeResult 仓位计算结果
+type SizeResult struct {
+	AccountName     string           `json:"accountName"`
+	Currency        string           `json:"currency"`
+	Balance         float64          `json:"balance"`
+	Symbol          string           `json:"symbol,omitempty"`
+	Direction       models.Direction `json:"direction"`
+	PointValue      float64          `json:"pointValue"`
+	StopDistance    float64          `json:"stopDistance"`
+	RiskBudget      float64          `json:"riskBudget"` // 按风险比例或固定金额得到的可承受亏损
+	Quantity        float64          `json:"quantity"`
+	RiskAmount      float64          `json:"riskAmount"`  // 按计算出的数量触发止损的实际亏损
+	RiskPercent     float64          `json:"riskPercent"` // 实际亏损占账户余额的百分比
+	Notional        float64          `json:"notional"`    // 名义价值
+	Leverage        float64          `json:"leverage"`
+	Margin          float64          `json:"margin"` // 名义价值 / 杠杆
+	RewardAmount    float64          `json:"rewardAmount,omitempty"`
+	RiskRewardRatio float64          `json:"riskRewardRatio,omitempty"`
+}
+
+// CalculatePositionSize 按账户余额、止损距离和风险比例（或固定风险金额）计算仓位数量
+// 方向由止损相对开仓价的位置决定：止损低于开仓价为做多，高于开仓价为做空
+func (o *Operations) CalculatePositionSize(params SizeParams) (*SizeResult, error) {
+	account, err := o.loadAccount(params.AccountName)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.OpenPrice <= 0 || params.StopLoss <= 0 {
+		return nil, fmt.Errorf("open price and stop loss must be positive")
+	}
+	if params.OpenPrice == params.StopLoss {
+		return nil, fmt.Errorf("stop loss must differ from open price")
+	}
+	if params.RiskPercent > 0 && params.RiskAmount > 0 {
+		return nil, fmt.Errorf("specify either risk percent or risk amount, not both")
+	}
+	if params.RiskPercent <= 0 && params.RiskAmount <= 0 {
+		return nil, fmt.Errorf("risk percent or risk amount must be positive")
+	}
+	if params.Leverage < 0 || params.QuantityStep < 0 {
+		return nil, fmt.Errorf("leverage and quantity step must not be negative")
+	}
+
+	direction := models.DirectionLong
+	if params.StopLoss > params.OpenPrice {
+		direction = models.DirectionShort
+	}
+
+	// 止盈必须在止损的另一侧
+	if params.TakeProfit > 0 {
+		if (direction == models.DirectionLong && params.TakeProfit <= params.OpenPrice) ||
+			(direction == models.DirectionShort && params.TakeProfit >= params.OpenPrice) {
+			return nil, fmt.Errorf("take profit must be on the opposite side of stop loss")
+		}
+	}
+
+	pointValue := o.pointValue(&models.Position{Symbol: params.Symbol})
+
+	riskBudget := params.RiskAmount
+	if params.RiskPercent > 0 {
+		if account.Balance <= 0 {
+			return nil, fmt.Errorf("account balance must be positive to size by risk percent")
+		}
+		riskBudget = account.Balance * params.RiskPercent / 100
+	}
+
+	quantity := models.CalculatePositionQuantity(riskBudget, params.OpenPrice, params.StopLoss, pointValue, params.QuantityStep)
+	if quantity <= 0 {
+		riskPerUnit := math.Abs(params.OpenPrice-params.StopLoss) * pointValue
+		return nil, fmt.Errorf("risk budget %.2f is too small: one unit risks %.2f (quantity step %g)",
+			riskBudget, riskPerUnit, params.QuantityStep)
+	}
+
+	leverage := params.Leverage
+	if leverage == 0 {
+		leverage = 1
+	}
+
+	result := &SizeResult{
+		AccountName:  account.Name,
+		Currency:     account.CurrencyCode(),
+		Balance:      account.Balance,
+		Symbol:       params.Symbol,
+		Direction:    direction,
+		PointValue:   pointValue,
+		StopDistance: math.Abs(params.OpenPrice - params.StopLoss),
+		RiskBudget:   riskBudget,
+		Quantity:     quantity,
+		RiskAmount:   models.CalculateRiskAmount(direction, params.OpenPrice, params.StopLoss, quantity, pointValue),
+		Notional:     params.OpenPrice * quantity * pointValue,
+		Leverage:     leverage,
+	}
+	result.Margin = result.Notional / leverage
+	result.RiskPercent = models.CalculatePnLPercentage(result.RiskAmount, account.Balance)
+
+	if params.TakeProfit > 0 {
+		result.RewardAmount = models.CalculateRealizedPnL(direction, params.OpenPrice, params.TakeProfit, quantity) * pointValue
+		result.RiskRewardRatio = result.RewardAmount / result.RiskAmount
+	}
+
+	return result, nil
+}
@@ -0,0 +1,240 @@
+package operations
+
+import (
+	"fmt"
+	"time"
+	"trading-journal-cli/internal/models"
+	"trading-journal-cli/internal/storage"
+)
+
+// UndoPlan 撤销操作的预览
+type UndoPlan struct {
+	Operation models.PositionEvent   // 要撤销的操作
+	Current   *models.Position       // 仓位当前状态
+	Restored  *models.Position       // 撤销后追加的版本
+	Changes   []models.FieldChange   // 撤销后相对当前状态的字段变化
+	Ledger    []models.LedgerEntry   // 撤销后需要冲回的资金流水
+	Dependent []models.PositionEvent // 依赖该操作的后续操作，不为空时不能撤销
+}
+
+// undoableEvents 可以撤销的操作类型
+var undoableEvents = map[models.EventType]bool{
+	models.EventOpened:          true,
+	models.EventAdded:           true,
+	models.EventPartiallyClosed: true,
+	models.EventClosed:          true,
+	models.EventAdjusted:        true,
+	models.EventEdited:          true,
+	models.EventVoided:          true, // 撤销作废即恢复仓位
+	models.EventUnvoided:        true, // 撤销恢复即重新作废
+}
+
+// PlanUndo 生成撤销操作的预览，operationID 为空时选择最近一次可撤销的操作
+//
+// 只能撤销仓位最后一个有效操作：之后还有同一仓位的其他操作时返回的预览中 Dependent 不为空。
+func (o *Operations) PlanUndo(operationID string) (*UndoPlan, error) {
+	if operationID == "" {
+		return o.planLatestUndo()
+	}
+
+	positionID, version, err := models.ParseOperationID(operationID)
+	if err != nil {
+		return nil, err
+	}
+	// 作废的仓位同样可以撤销（撤销作废或之前的操作）
+	pos, err := o.findPositionIncludingVoided(positionID)
+	if err != nil {
+		return nil, err
+	}
+	events, err := o.PositionHistory(pos.PositionID)
+	if err != nil {
+		return nil, err
+	}
+
+	effective := models.EffectiveEvents(events)
+	for i, event := range effective {
+		if event.Version != version {
+			continue
+		}
+		plan, err := o.buildUndoPlan(effective[:i+1], events[len(events)-1].Position)
+		if err != nil {
+			return nil, err
+		}
+		plan.Dependent = effective[i+1:]
+		return plan, nil
+	}
+
+	for _, event := range events {
+		if event.Version == version {
+			return nil, fmt.Errorf("operation %s cannot be undone (already undone or not an operation)", operationID)
+		}
+	}
+	return nil, fmt.Errorf("operation not found: %s", operationID)
+}
+
+// planLatestUndo 在所有仓位中选择最近写入的可撤销操作
+// 按版本的写入时间而不是事件时间比较，补录的历史操作（如 --time 指定过去或将来的时间）不影响选择
+func (o *Operations) planLatestUndo() (*UndoPlan, error) {
+	var latest []models.PositionEvent
+	var latestCurrent *models.Position
+	for versions, err := range o.storage.PositionHistories(storage.Query{IncludeVoided: true}) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to read position history: %w", err)
+		}
+		events, err := models.BuildPositionEvents(versions)
+		if err != nil {
+			return nil, err
+		}
+		effective := models.EffectiveEvents(events)
+		if len(effective) == 0 {
+			continue
+		}
+		top := effective[len(effective)-1]
+		if !undoableEvents[top.Type] {
+			continue
+		}
+		if latest == nil || !recordedBefore(top, latest[len(latest)-1]) {
+			latest = effective
+			latestCurrent = versions[len(versions)-1]
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("no operation to undo")
+	}
+	return o.buildUndoPlan(latest, latestCurrent)
+}
+
+// recordedBefore 事件 a 的版本是否早于 b 写入
+// 旧版本没有写入时间，视为早于有写入时间的版本；两者都没有时按事件时间比较
+func recordedBefore(a, b models.PositionEvent) bool {
+	ra, rb := a.Position.RecordedAt, b.Position.RecordedAt
+	switch {
+	case ra != nil && rb != nil:
+		return ra.Before(*rb)
+	case ra != nil || rb != nil:
+		return ra == nil
+	}
+	return a.Time.Before(b.Time)
+}
+
+// buildUndoPlan 撤销 effective 中最后一个事件：恢复到前一个有效事件的状态
+// 撤销开仓时仓位被作废
+func (o *Operations) buildUndoPlan(effective []models.PositionEvent, current *models.Position) (*UndoPlan, error) {
+	target := effective[len(effective)-1]
+	if !undoableEvents[target.Type] {
+		return nil, fmt.Errorf("operation %s (%s) cannot be undone", target.ID, target.Type)
+	}
+
+	// 压缩后只剩一个版本的仓位，开仓版本中已包含之后的成交或调整，不能当作单纯的开仓撤销
+	if target.Type == models.EventOpened {
+		if pos := target.Position; len(pos.Fills) > 0 || len(pos.Adjustments) > 0 || len(pos.Entries) > 1 {
+			return nil, fmt.Errorf("operation %s cannot be undone: the opening version already contains later fills or adjustments (versions were compacted)", target.ID)
+		}
+	}
+
+	now := time.Now()
+	var restored models.Position
+	if len(effective) > 1 {
+		restored = *effective[len(effective)-2].Position
+	} else {
+		restored = *current
+		restored.Voided = &models.VoidRecord{Time: now, Reason: fmt.Sprintf("撤销开仓 %s", target.ID)}
+	}
+	restored.Undos = append(append([]models.UndoRecord{}, current.Undos...), models.UndoRecord{
+		Time:        now,
+		OperationID: target.ID,
+		Type:        target.Type,
+	})
+
+	changes, err := models.DiffPositions(current, &restored)
+	if err != nil {
+		return nil, err
+	}
+	plan := &UndoPlan{
+		Operation: target,
+		Current:   current,
+		Restored:  &restored,
+		Changes:   changes,
+	}
+
+	// 撤销平仓（包括部分平仓）时冲回该次成交已记的盈亏和费用流水
+	// 与 edit 的更正流水一致，冲回流水记在原流水的发生时间，撤销之前的历史余额同样不包含录错的成交
+	if o.accountManager != nil {
+		entries, err := o.accountManager.ReadLedger(current.AccountName)
+		if err != nil {
+			return nil, err
+		}
+		var posted []models.LedgerEntry
+		for _, entry := range entries {
+			if entry.PositionID == current.PositionID {
+				posted = append(posted, entry)
+			}
+		}
+		expected := &restored
+		if restored.Voided != nil {
+			// 作废的仓位不计入账户余额
+			expected = &models.Position{PositionID: restored.PositionID, AccountName: restored.AccountName}
+		}
+		for _, entry := range positionLedgerEntries(expected, posted) {
+			switch target.Type {
+			case models.EventVoided:
+				entry.Note = fmt.Sprintf("撤销作废，重新记入%s（%s）", undoLedgerNote(entry.Type), target.ID)
+			case models.EventUnvoided:
+				entry.Note = fmt.Sprintf("撤销恢复，冲回%s（%s）", undoLedgerNote(entry.Type), target.ID)
+			default:
+				entry.Note = fmt.Sprintf("撤销%s（%s）", undoLedgerNote(entry.Type), target.ID)
+			}
+			plan.Ledger = append(plan.Ledger, entry)
+		}
+	}
+
+	return plan, nil
+}
+
+// undoLedgerNote 冲回流水的说明
+func undoLedgerNote(t models.LedgerEntryType) string {
+	if t == models.LedgerFee {
+		return "交易费用"
+	}
+	return "平仓盈亏"
+}
+
+// Undo 执行撤销：追加恢复后的版本，并冲回相应的资金流水
+// 执行前重新生成预览，确保期间没有新的操作
+func (o *Operations) Undo(plan *UndoPlan) (*UndoPlan, error) {
+	lock, err := o.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	// 旧账户先生成期初流水，之后才能冲回本仓位的盈亏
+	var fresh *UndoPlan
+	_, err = o.withPositionLedger(plan.Current.AccountName, true, func() error {
+		var err error
+		fresh, err = o.PlanUndo(plan.Operation.ID)
+		if err != nil {
+			return err
+		}
+		if len(fresh.Dependent) > 0 {
+			return fmt.Errorf("cannot undo %s: later operations depend on it (%s)",
+				plan.Operation.ID, fresh.Dependent[0].ID)
+		}
+
+		// 撤销的修改改过开仓时间时，已有版本随恢复的开仓时间移回原月份
+		if err := o.storage.MovePosition(fresh.Current.PositionID, fresh.Current.OpenTime, fresh.Restored.OpenTime); err != nil {
+			return err
+		}
+		if err := o.storage.UpdatePosition(fresh.Restored); err != nil {
+			return fmt.Errorf("failed to update position: %w", err)
+		}
+		return nil
+	}, func() ([]models.LedgerEntry, error) {
+		return fresh.Ledger, o.accountManager.AppendLedgerEntries(fresh.Ledger...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return fresh, nil
+}
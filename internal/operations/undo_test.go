@@ -0,0 +1,100 @@
+package operations
+
+import (
+	"testing"
+	"time"
+	"trading-journal-cli/internal/models"
+)
+
+func TestUndo_ReversesLedgerAtOriginalTime(t *testing.T) {
+	at := func(hours int) time.Time { return testTime.Add(time.Duration(hours) * time.Hour) }
+
+	// 每个用例先开仓 2 并在 +1h 以 110 平仓 1（+10），再执行要撤销的操作
+	tests := []struct {
+		name      string
+		run       func(t *testing.T, ops *Operations, positionID string)
+		undone    models.EventType
+		expected  map[ledgerKey]float64
+		balance   float64
+		quantity  float64
+		openPrice float64
+	}{
+		{
+			name: "Undo close",
+			run: func(t *testing.T, ops *Operations, positionID string) {
+				if _, err := ops.ClosePosition(positionID, testCloseParams(90, 1, at(2))); err != nil {
+					t.Fatalf("ClosePosition failed: %v", err)
+				}
+			},
+			undone:    models.EventClosed,
+			expected:  map[ledgerKey]float64{{1, models.LedgerRealizedPnL}: 10},
+			balance:   10,
+			quantity:  1,
+			openPrice: 100,
+		},
+		{
+			name: "Undo add",
+			run: func(t *testing.T, ops *Operations, positionID string) {
+				addTime := at(2)
+				params := AddParams{Price: 110, Quantity: 1, Margin: 50, Fees: models.Fees{Commission: 2}, Time: &addTime}
+				if _, err := ops.AddToPosition(positionID, params); err != nil {
+					t.Fatalf("AddToPosition failed: %v", err)
+				}
+			},
+			undone:    models.EventAdded,
+			expected:  map[ledgerKey]float64{{1, models.LedgerRealizedPnL}: 10},
+			balance:   10,
+			quantity:  1,
+			openPrice: 100,
+		},
+		{
+			name: "Undo edit",
+			run: func(t *testing.T, ops *Operations, positionID string) {
+				openPrice := 105.0
+				if _, err := ops.EditPosition(positionID, EditParams{OpenPrice: &openPrice, EditReason: "typo"}); err != nil {
+					t.Fatalf("EditPosition failed: %v", err)
+				}
+			},
+			undone:    models.EventEdited,
+			expected:  map[ledgerKey]float64{{1, models.LedgerRealizedPnL}: 10},
+			balance:   10,
+			quantity:  1,
+			openPrice: 100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops, accountMgr := newTestOperations(t)
+			pos, err := ops.OpenPosition(testOpenParams("main", "BTC", 2, at(0)))
+			if err != nil {
+				t.Fatalf("OpenPosition failed: %v", err)
+			}
+			if _, err := ops.ClosePosition(pos.PositionID, testCloseParams(110, 1, at(1))); err != nil {
+				t.Fatalf("ClosePosition failed: %v", err)
+			}
+			tt.run(t, ops, pos.PositionID)
+
+			plan, err := ops.PlanUndo("")
+			if err != nil {
+				t.Fatalf("PlanUndo failed: %v", err)
+			}
+			if plan.Operation.Type != tt.undone {
+				t.Fatalf("Expected to undo %s, got %s", tt.undone, plan.Operation.Type)
+			}
+			if _, err := ops.Undo(plan); err != nil {
+				t.Fatalf("Undo failed: %v", err)
+			}
+
+			checkLedger(t, accountMgr, tt.expected, tt.balance)
+			restored, err := ops.GetPosition(pos.PositionID)
+			if err != nil {
+				t.Fatalf("GetPosition failed: %v", err)
+			}
+			if restored.Quantity != tt.quantity || restored.OpenPrice != tt.openPrice || restored.Status != models.StatusOpen {
+				t.Errorf("Expected open position with quantity %.2f at %.2f, got %s %.2f at %.2f",
+					tt.quantity, tt.openPrice, restored.Status, restored.Quantity, restored.OpenPrice)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package operations
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"trading-journal-cli/internal/models"
+	"trading-journal-cli/internal/storage"
+)
+
+// VoidResult 作废或恢复仓位的结果
+type VoidResult struct {
+	Position *models.Position     // 追加的新版本
+	Ledger   []models.LedgerEntry // 冲回或重新记入的资金流水
+}
+
+// findPositionIncludingVoided 按ID查找仓位的最新版本，包括已作废的仓位
+func (o *Operations) findPositionIncludingVoided(positionID string) (*models.Position, error) {
+	for pos, err := range o.storage.Positions(storage.Query{PositionID: positionID, IncludeVoided: true}) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to find position: %w", err)
+		}
+		return pos, nil
+	}
+	return nil, fmt.Errorf("failed to find position: position not found: %s", positionID)
+}
+
+// VoidPosition 作废仓位：追加带作废记录的新版本，之后的查询和统计默认不包含该仓位
+// 已有平仓成交的仓位冲回已记的平仓盈亏和费用流水
+func (o *Operations) VoidPosition(positionID, reason string) (*VoidResult, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("void reason is required")
+	}
+
+	lock, err := o.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	pos, err := o.findPositionIncludingVoided(positionID)
+	if err != nil {
+		return nil, err
+	}
+	if pos.Voided != nil {
+		return nil, fmt.Errorf("position %s is already voided", positionID)
+	}
+
+	// 已有平仓成交的仓位冲回已记的流水，旧账户先生成期初流水
+	voided := *pos
+	voided.Voided = &models.VoidRecord{Time: time.Now(), Reason: reason}
+	entries, err := o.withPositionLedger(pos.AccountName, len(pos.Fills) > 0, func() error {
+		if err := o.storage.UpdatePosition(&voided); err != nil {
+			return fmt.Errorf("failed to update position: %w", err)
+		}
+		return nil
+	}, func() ([]models.LedgerEntry, error) {
+		// 作废的仓位不计入账户余额
+		expected := &models.Position{PositionID: voided.PositionID, AccountName: voided.AccountName}
+		return o.postLedgerCorrection(expected, "作废仓位冲回")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &VoidResult{Position: &voided, Ledger: entries}, nil
+}
+
+// UnvoidPosition 恢复已作废的仓位，已有平仓成交的仓位重新记入平仓盈亏和费用流水
+func (o *Operations) UnvoidPosition(positionID, reason string) (*VoidResult, error) {
+	lock, err := o.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	pos, err := o.findPositionIncludingVoided(positionID)
+	if err != nil {
+		return nil, err
+	}
+	if pos.Voided == nil {
+		return nil, fmt.Errorf("position %s is not voided", positionID)
+	}
+
+	restored := *pos
+	restored.Voided = nil
+	restored.Unvoids = append(append([]models.VoidRecord(nil), pos.Unvoids...), models.VoidRecord{
+		Time:   time.Now(),
+		Reason: reason,
+	})
+	// 已有平仓成交的仓位重新记入流水，旧账户先生成期初流水
+	entries, err := o.withPositionLedger(pos.AccountName, len(pos.Fills) > 0, func() error {
+		if err := o.storage.UpdatePosition(&restored); err != nil {
+			return fmt.Errorf("failed to update position: %w", err)
+		}
+		return nil
+	}, func() ([]models.LedgerEntry, error) {
+		return o.postLedgerCorrection(&restored, "恢复作废仓位，重新记入")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &VoidResult{Position: &restored, Ledger: entries}, nil
+}
+
+// postLedgerCorrection 按 expected 追加本仓位的差额流水，说明为 notePrefix 加流水类型
+// 作废和恢复视为更正录入错误，与 edit 的更正流水一致，差额流水记在原流水的发生时间，--as-of 查看的历史余额同样按作废后的状态计算
+func (o *Operations) postLedgerCorrection(expected *models.Position, notePrefix string) ([]models.LedgerEntry, error) {
+	entries, err := o.accountManager.ReadLedger(expected.AccountName)
+	if err != nil {
+		return nil, err
+	}
+	var posted []models.LedgerEntry
+	for _, entry := range entries {
+		if entry.PositionID == expected.PositionID {
+			posted = append(posted, entry)
+		}
+	}
+
+	newEntries := positionLedgerEntries(expected, posted)
+	for i := range newEntries {
+		newEntries[i].Note = fmt.Sprintf("%s%s（%s）", notePrefix, undoLedgerNote(newEntries[i].Type), expected.PositionID)
+	}
+	if err := o.accountManager.AppendLedgerEntries(newEntries...); err != nil {
+		return nil, err
+	}
+	return newEntries, nil
+}
@@ -0,0 +1,79 @@
+package operations
+
+import (
+	"testing"
+	"time"
+	"trading-journal-cli/internal/models"
+)
+
+func TestVoidUnvoidUndo(t *testing.T) {
+	ops, accountMgr := newTestOperations(t)
+	pos, err := ops.OpenPosition(testOpenParams("main", "BTC", 1, testTime))
+	if err != nil {
+		t.Fatalf("OpenPosition failed: %v", err)
+	}
+	closeParams := testCloseParams(110, 1, testTime.Add(time.Hour))
+	closeParams.Fees = models.Fees{Commission: 1}
+	if _, err := ops.ClosePosition(pos.PositionID, closeParams); err != nil {
+		t.Fatalf("ClosePosition failed: %v", err)
+	}
+
+	posted := map[ledgerKey]float64{
+		{1, models.LedgerRealizedPnL}: 10,
+		{1, models.LedgerFee}:         -1,
+	}
+	undo := func(expected models.EventType) {
+		t.Helper()
+		plan, err := ops.PlanUndo("")
+		if err != nil {
+			t.Fatalf("PlanUndo failed: %v", err)
+		}
+		if plan.Operation.Type != expected {
+			t.Fatalf("Expected to undo %s, got %s", expected, plan.Operation.Type)
+		}
+		if _, err := ops.Undo(plan); err != nil {
+			t.Fatalf("Undo failed: %v", err)
+		}
+	}
+	checkVoided := func(step string, expected bool) {
+		t.Helper()
+		current, err := ops.findPositionIncludingVoided(pos.PositionID)
+		if err != nil {
+			t.Fatalf("%s: find position failed: %v", step, err)
+		}
+		if (current.Voided != nil) != expected {
+			t.Errorf("%s: expected voided %v, got %+v", step, expected, current.Voided)
+		}
+	}
+
+	steps := []struct {
+		name     string
+		run      func()
+		voided   bool
+		expected map[ledgerKey]float64
+		balance  float64
+	}{
+		{"Void", func() {
+			if _, err := ops.VoidPosition(pos.PositionID, "duplicate"); err != nil {
+				t.Fatalf("VoidPosition failed: %v", err)
+			}
+		}, true, map[ledgerKey]float64{}, 0},
+		{"Unvoid", func() {
+			if _, err := ops.UnvoidPosition(pos.PositionID, "not a duplicate"); err != nil {
+				t.Fatalf("UnvoidPosition failed: %v", err)
+			}
+		}, false, posted, 9},
+		{"Undo unvoid", func() { undo(models.EventUnvoided) }, true, map[ledgerKey]float64{}, 0},
+		{"Undo void", func() { undo(models.EventVoided) }, false, posted, 9},
+	}
+
+	// 各步骤依次执行，每步之后检查作废状态和按原发生时间汇总的流水
+	for _, step := range steps {
+		step.run()
+		checkVoided(step.name, step.voided)
+		checkLedger(t, accountMgr, step.expected, step.balance)
+		if t.Failed() {
+			t.Fatalf("%s: unexpected state", step.name)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"trading-journal-cli/internal/fsutil"
+)
+
+// CompactOptions 压缩选项
+type CompactOptions struct {
+	DryRun bool // 只统计可回收的空间，不修改文件
+
+	// 直接丢弃被取代的旧版本；默认追加到 history-YYYY-MM.jsonl，
+	// 仓位时间线、--as-of 和 undo 依赖这些旧版本
+	DiscardHistory bool
+}
+
+// CompactResult 单个月份文件的压缩结果
+type CompactResult struct {
+	File         string `json:"file"`
+	LinesBefore  int    `json:"linesBefore"`
+	LinesAfter   int    `json:"linesAfter"`
+	Superseded   int    `json:"superseded"`   // 被取代的旧版本行数
+	InvalidLines int    `json:"invalidLines"` // 无法解析的行（原样保留）
+	BytesBefore  int64  `json:"bytesBefore"`
+	BytesAfter   int64  `json:"bytesAfter"`
+	BackupPath   string `json:"backupPath,omitempty"`
+	ArchivePath  string `json:"archivePath,omitempty"`
+}
+
+// Reclaimed 回收的字节数
+func (r CompactResult) Reclaimed() int64 {
+	return r.BytesBefore - r.BytesAfter
+}
+
+// Compact 重写每个 trades-YYYY-MM.jsonl，每个 PositionID 只保留最后一个版本
+//
+// 仓位按首次出现的顺序保留，内容为原始行不重新序列化。无法解析的行原样保留。
+// 被取代的旧版本默认归档到 history-YYYY-MM.jsonl。原文件先备份为带时间戳的 .backup，
+// 多次压缩不会覆盖之前的备份；新内容写入临时文件后再原子替换原文件。
+func (s *JSONLStorage) Compact(opts CompactOptions) ([]CompactResult, error) {
+	if _, err := os.Stat(s.dataDir); os.IsNotExist(err) {
+		return []CompactResult{}, nil
+	}
+
+	// 压缩期间阻止其他进程写入
+	lock, err := fsutil.LockDir(s.dataDir)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	entries, err := os.ReadDir(s.dataDir)
+	if os.IsNotExist(err) {
+		return []CompactResult{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data directory: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".jsonl" || !strings.HasPrefix(entry.Name(), "trades-") {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	results := make([]CompactResult, 0, len(names))
+	for _, name := range names {
+		result, err := s.compactFile(name, opts)
+		if err != nil {
+			return results, fmt.Errorf("failed to compact %s: %w", name, err)
+		}
+		results = append(results, *result)
+	}
+
+	return results, nil
+}
+
+// compactFile 压缩单个月份文件
+func (s *JSONLStorage) compactFile(name string, opts CompactOptions) (*CompactResult, error) {
+	filePath := filepath.Join(s.dataDir, name)
+	if _, err := fsutil.RepairTornTail(filePath); err != nil {
+		return nil, err
+	}
+	lines, err := readRawLines(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CompactResult{File: name, LinesBefore: len(lines)}
+
+	// 每个 PositionID 最后一个版本所在的行
+	latest := make(map[string]int)
+	ids := make([]string, len(lines))
+	for i, line := range lines {
+		var record struct {
+			PositionID string `json:"positionId"`
+		}
+		if err := json.Unmarshal(line, &record); err != nil || record.PositionID == "" {
+			result.InvalidLines++
+			continue
+		}
+		ids[i] = record.PositionID
+		latest[record.PositionID] = i
+	}
+
+	var kept, superseded [][]byte
+	seen := make(map[string]bool)
+	for i, line := range lines {
+		id := ids[i]
+		switch {
+		case id == "":
+			kept = append(kept, line)
+		case latest[id] == i:
+			// 放在该仓位首次出现的位置
+			if !seen[id] {
+				kept = append(kept, line)
+			}
+		default:
+			superseded = append(superseded, line)
+			if !seen[id] {
+				seen[id] = true
+				kept = append(kept, lines[latest[id]])
+			}
+		}
+	}
+
+	result.LinesAfter = len(kept)
+	result.Superseded = len(superseded)
+	if info, err := os.Stat(filePath); err == nil {
+		result.BytesBefore = info.Size()
+	} else {
+		result.BytesBefore = rawLinesSize(lines)
+	}
+	result.BytesAfter = rawLinesSize(kept)
+
+	if opts.DryRun || result.BytesAfter == result.BytesBefore {
+		return result, nil
+	}
+
+	// 先归档旧版本，确保替换原文件前旧数据已有去处
+	if !opts.DiscardHistory && len(superseded) > 0 {
+		archivePath := filepath.Join(s.dataDir, historyFileName(name))
+		if err := fsutil.AppendLines(archivePath, superseded...); err != nil {
+			return nil, fmt.Errorf("failed to archive superseded versions: %w", err)
+		}
+		result.ArchivePath = archivePath
+	}
+
+	backupPath, err := backupFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup: %w", err)
+	}
+	result.BackupPath = backupPath
+
+	if err := fsutil.WriteFileAtomic(filePath, bytes.Join(append(kept, nil), []byte{'\n'}), 0644); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// readRawLines 读取文件的所有非空行
+func readRawLines(filePath string) ([][]byte, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	var lines [][]byte
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if trimmed := strings.TrimSpace(string(line)); trimmed != "" {
+			lines = append(lines, []byte(trimmed))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading file: %w", err)
+		}
+	}
+
+	return lines, nil
+}
+
+// rawLinesSize 按每行加换行符计算写出后的字节数
+func rawLinesSize(lines [][]byte) int64 {
+	var size int64
+	for _, line := range lines {
+		size += int64(len(line)) + 1
+	}
+	return size
+}
+
+// backupFile 把文件复制为 <文件>.<时间戳>.backup 并返回备份路径，同名备份已存在时追加序号，不覆盖已有备份
+func backupFile(filePath string) (string, error) {
+	base := filePath + "." + time.Now().Format("20060102-150405")
+	backupPath := base + ".backup"
+	for i := 2; ; i++ {
+		if _, err := os.Stat(backupPath); os.IsNotExist(err) {
+			break
+		} else if err != nil {
+			return "", err
+		}
+		backupPath = fmt.Sprintf("%s-%d.backup", base, i)
+	}
+
+	if err := copyFile(filePath, backupPath); err != nil {
+		return "", err
+	}
+	return backupPath, nil
+}
+
+// copyFile 复制文件
+func copyFile(src, dst string) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	dstFile, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer dstFile.Close()
+
+	if _, err := io.Copy(dstFile, srcFile); err != nil {
+		return err
+	}
+	return dstFile.Sync()
+}
@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const compactInput = `{"positionId":"A","v":1}
+{"positionId":"B","v":1}
+not json
+{"positionId":"A","v":2}
+{"positionId":"B","v":2}
+{"positionId":"A","v":3}
+`
+
+// writeTradeFile 在临时数据目录中写入一个月份文件
+func writeTradeFile(t *testing.T, content string) (*JSONLStorage, string) {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "trades-2025-01.jsonl")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return NewJSONLStorage(dir), path
+}
+
+func readFileString(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestCompact_KeepsLatestVersionInFirstSlot(t *testing.T) {
+	s, path := writeTradeFile(t, compactInput)
+
+	results, err := s.Compact(CompactOptions{})
+	if err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(results))
+	}
+
+	// 每个仓位的最新版本放在首次出现的位置，无法解析的行原样保留
+	expected := `{"positionId":"A","v":3}
+{"positionId":"B","v":2}
+not json
+`
+	if got := readFileString(t, path); got != expected {
+		t.Errorf("Expected compacted file:\n%s\ngot:\n%s", expected, got)
+	}
+
+	r := results[0]
+	if r.LinesBefore != 6 || r.LinesAfter != 3 || r.Superseded != 3 || r.InvalidLines != 1 {
+		t.Errorf("Unexpected counts: %+v", r)
+	}
+	if r.BackupPath == "" || readFileString(t, r.BackupPath) != compactInput {
+		t.Errorf("Expected backup with original content at %q", r.BackupPath)
+	}
+}
+
+func TestCompact_ArchivesSupersededVersions(t *testing.T) {
+	s, _ := writeTradeFile(t, compactInput)
+
+	results, err := s.Compact(CompactOptions{})
+	if err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+
+	archivePath := filepath.Join(s.dataDir, "history-2025-01.jsonl")
+	if results[0].ArchivePath != archivePath {
+		t.Errorf("Expected archive path %s, got %s", archivePath, results[0].ArchivePath)
+	}
+	expected := `{"positionId":"A","v":1}
+{"positionId":"B","v":1}
+{"positionId":"A","v":2}
+`
+	if got := readFileString(t, archivePath); got != expected {
+		t.Errorf("Expected archive:\n%s\ngot:\n%s", expected, got)
+	}
+
+	// 归档后仍能按写入顺序读到所有版本
+	versions, err := s.PositionVersions("A")
+	if err != nil {
+		t.Fatalf("PositionVersions failed: %v", err)
+	}
+	if len(versions) != 3 {
+		t.Errorf("Expected 3 versions of A after compact, got %d", len(versions))
+	}
+}
+
+func TestCompact_DiscardHistory(t *testing.T) {
+	s, _ := writeTradeFile(t, compactInput)
+
+	results, err := s.Compact(CompactOptions{DiscardHistory: true})
+	if err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	if results[0].ArchivePath != "" {
+		t.Errorf("Expected no archive, got %s", results[0].ArchivePath)
+	}
+	if _, err := os.Stat(filepath.Join(s.dataDir, "history-2025-01.jsonl")); !os.IsNotExist(err) {
+		t.Errorf("Expected no history file, got err=%v", err)
+	}
+}
+
+func TestCompact_SecondRunIsNoOp(t *testing.T) {
+	s, path := writeTradeFile(t, compactInput)
+
+	if _, err := s.Compact(CompactOptions{}); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	compacted := readFileString(t, path)
+	backups, _ := filepath.Glob(path + ".*.backup")
+
+	results, err := s.Compact(CompactOptions{})
+	if err != nil {
+		t.Fatalf("Second compact failed: %v", err)
+	}
+	r := results[0]
+	if r.Superseded != 0 || r.Reclaimed() != 0 || r.BackupPath != "" || r.ArchivePath != "" {
+		t.Errorf("Expected no-op second run, got %+v", r)
+	}
+	if got := readFileString(t, path); got != compacted {
+		t.Errorf("Expected file unchanged by second run, got:\n%s", got)
+	}
+	if again, _ := filepath.Glob(path + ".*.backup"); len(again) != len(backups) {
+		t.Errorf("Expected no new backup, had %d now %d", len(backups), len(again))
+	}
+}
+
+func TestCompact_KeepsEarlierBackups(t *testing.T) {
+	s, path := writeTradeFile(t, compactInput)
+
+	first, err := s.Compact(CompactOptions{})
+	if err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+
+	// 追加新版本后再次压缩，不能覆盖第一次压缩前的备份
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"positionId":"B","v":3}` + "\n")
+	f.Close()
+
+	second, err := s.Compact(CompactOptions{})
+	if err != nil {
+		t.Fatalf("Second compact failed: %v", err)
+	}
+	if second[0].BackupPath == first[0].BackupPath {
+		t.Fatalf("Expected a new backup path, both are %s", first[0].BackupPath)
+	}
+	if got := readFileString(t, first[0].BackupPath); got != compactInput {
+		t.Errorf("Expected first backup to keep the original content, got:\n%s", got)
+	}
+}
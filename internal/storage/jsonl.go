@@ -1,24 +1,37 @@
 package storage
 
 import (
-	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"os"
 	"path/filepath"
 	"sort"
 	"time"
+	"trading-journal-cli/internal/fsutil"
 	"trading-journal-cli/internal/models"
 )
 
 // Storage 存储接口
 type Storage interface {
 	AppendPosition(pos *models.Position) error
+	Positions(q Query) iter.Seq2[*models.Position, error] // 按开仓时间顺序遍历满足条件的仓位
 	ReadPositions(year int, month time.Month) ([]*models.Position, error)
 	ReadAllPositions() ([]*models.Position, error)
 	ReadOpenPositions() ([]*models.Position, error)
 	UpdatePosition(pos *models.Position) error
 	FindPositionByID(positionID string) (*models.Position, error)
+	PositionVersions(positionID string) ([]*models.Position, error) // 仓位的所有版本，按写入顺序
+
+	// 最新版本满足条件的仓位的所有版本，按开仓时间顺序，用于重建过去某一时刻的状态
+	PositionHistories(q Query) iter.Seq2[[]*models.Position, error]
+
+	// 开仓时间修改到其他月份前调用，把仓位已有的版本移到新开仓时间对应的位置
+	MovePosition(positionID string, from, to time.Time) error
+
+	// 获取数据目录锁，读取仓位、修改后追加新版本的操作在持有锁时完成，避免基于同一版本并发修改
+	Lock() (*fsutil.Lock, error)
 }
 
 // JSONLStorage JSONL文件存储
@@ -39,6 +52,11 @@ func (s *JSONLStorage) ensureDataDir() error {
 	return nil
 }
 
+// Lock 获取数据目录锁
+func (s *JSONLStorage) Lock() (*fsutil.Lock, error) {
+	return fsutil.LockDir(s.dataDir)
+}
+
 // getFilePath 获取指定月份的文件路径
 func (s *JSONLStorage) getFilePath(year int, month time.Month) string {
 	filename := fmt.Sprintf("trades-%04d-%02d.jsonl", year, month)
@@ -51,59 +69,62 @@ func (s *JSONLStorage) AppendPosition(pos *models.Position) error {
 		return fmt.Errorf("failed to create data directory: %w", err)
 	}
 
-	filePath := s.getFilePath(pos.OpenTime.Year(), pos.OpenTime.Month())
-	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return fmt.Errorf("failed to open file: %w", err)
-	}
-	defer file.Close()
-
+	recordedAt := time.Now()
+	pos.RecordedAt = &recordedAt
 	data, err := json.Marshal(pos)
 	if err != nil {
 		return fmt.Errorf("failed to marshal position: %w", err)
 	}
 
-	if _, err := file.Write(append(data, '\n')); err != nil {
+	// 在数据目录锁内追加并同步到磁盘，多个进程同时写入时不会交错
+	filePath := s.getFilePath(pos.OpenTime.Year(), pos.OpenTime.Month())
+	if err := fsutil.AppendLines(filePath, data); err != nil {
 		return fmt.Errorf("failed to write position: %w", err)
 	}
 
 	return nil
 }
 
-// ReadPositions 读取指定月份的所有仓位
+// ReadPositions 读取指定月份的所有仓位（不含已作废的仓位）
 func (s *JSONLStorage) ReadPositions(year int, month time.Month) ([]*models.Position, error) {
-	filePath := s.getFilePath(year, month)
-
-	// 文件不存在时返回空列表
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return []*models.Position{}, nil
+	positions, err := s.readFile(filepath.Base(s.getFilePath(year, month)))
+	if err != nil {
+		return nil, err
+	}
+	result := positions[:0]
+	for _, pos := range positions {
+		if (Query{}).Matches(pos) {
+			result = append(result, pos)
+		}
 	}
+	return result, nil
+}
 
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+// readFile 读取一个月份文件，同一 positionId 取最后一条记录，按开仓时间排序
+// 行长度不受限制，损坏的行跳过并输出警告
+func (s *JSONLStorage) readFile(name string) ([]*models.Position, error) {
+	filePath := filepath.Join(s.dataDir, name)
+
+	// 修复上次写入中断留下的不完整末行
+	if _, err := fsutil.RepairTornTail(filePath); err != nil {
+		return nil, err
 	}
-	defer file.Close()
 
 	positions := make(map[string]*models.Position)
-	scanner := bufio.NewScanner(file)
-	lineNum := 0
-
-	for scanner.Scan() {
-		lineNum++
+	err := fsutil.ReadLines(filePath, func(lineNum int, line []byte) error {
 		var pos models.Position
-		if err := json.Unmarshal(scanner.Bytes(), &pos); err != nil {
+		if err := json.Unmarshal(line, &pos); err != nil {
 			// 跳过损坏的行，继续处理
 			fmt.Fprintf(os.Stderr, "Warning: skipping invalid JSON at line %d in %s: %v\n",
 				lineNum, filePath, err)
-			continue
+			return nil
 		}
 		// 同一 positionId 取最后一条记录
 		positions[pos.PositionID] = &pos
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading file: %w", err)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// 转换为切片
@@ -120,99 +141,242 @@ func (s *JSONLStorage) ReadPositions(year int, month time.Month) ([]*models.Posi
 	return result, nil
 }
 
-// ReadAllPositions 读取所有月份的仓位
-func (s *JSONLStorage) ReadAllPositions() ([]*models.Position, error) {
-	if err := s.ensureDataDir(); err != nil {
-		return nil, fmt.Errorf("failed to ensure data directory: %w", err)
-	}
-
-	entries, err := os.ReadDir(s.dataDir)
+// fileCovers 月份文件中是否可能有满足查询条件的仓位
+// 仓位按开仓月份分文件；文件名按仓位时间所在时区的月份命名，边界各放宽一天
+func fileCovers(name string, q Query) bool {
+	month, err := time.ParseInLocation("trades-2006-01.jsonl", name, time.Local)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read data directory: %w", err)
+		return true
 	}
+	if !q.OpenFrom.IsZero() && month.AddDate(0, 1, 1).Before(q.OpenFrom) {
+		return false
+	}
+	if latest := q.latestOpenTime(); !latest.IsZero() && month.AddDate(0, 0, -1).After(latest) {
+		return false
+	}
+	return true
+}
 
-	allPositions := make(map[string]*models.Position)
-
-	for _, entry := range entries {
-		if entry.IsDir() || filepath.Ext(entry.Name()) != ".jsonl" {
-			continue
-		}
-
-		filePath := filepath.Join(s.dataDir, entry.Name())
-		file, err := os.Open(filePath)
+// Positions 按开仓时间顺序遍历满足查询条件的仓位（每个仓位的最新版本）
+// 只读取开仓月份可能满足条件的文件，一次只加载一个月份文件
+func (s *JSONLStorage) Positions(q Query) iter.Seq2[*models.Position, error] {
+	return func(yield func(*models.Position, error) bool) {
+		names, err := tradeFileNames(s.dataDir)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to open file %s: %v\n", entry.Name(), err)
-			continue
+			yield(nil, fmt.Errorf("failed to read data directory: %w", err))
+			return
 		}
 
-		scanner := bufio.NewScanner(file)
-		lineNum := 0
-		for scanner.Scan() {
-			lineNum++
-			var pos models.Position
-			if err := json.Unmarshal(scanner.Bytes(), &pos); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: skipping invalid JSON at line %d in %s: %v\n",
-					lineNum, entry.Name(), err)
+		for _, name := range names {
+			if !fileCovers(name, q) {
+				continue
+			}
+			positions, err := s.readFile(name)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: error reading file %s: %v\n", name, err)
 				continue
 			}
-			allPositions[pos.PositionID] = &pos
+			for _, pos := range positions {
+				if q.Matches(pos) && !yield(pos, nil) {
+					return
+				}
+			}
 		}
+	}
+}
 
-		file.Close()
+// ReadAllPositions 读取所有月份的仓位
+func (s *JSONLStorage) ReadAllPositions() ([]*models.Position, error) {
+	return Collect(s.Positions(Query{}))
+}
 
-		if err := scanner.Err(); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: error reading file %s: %v\n", entry.Name(), err)
-		}
-	}
+// ReadOpenPositions 读取所有未平仓位
+func (s *JSONLStorage) ReadOpenPositions() ([]*models.Position, error) {
+	return Collect(s.Positions(Query{Status: models.StatusOpen}))
+}
 
-	// 转换为切片
-	result := make([]*models.Position, 0, len(allPositions))
-	for _, pos := range allPositions {
-		result = append(result, pos)
-	}
+// UpdatePosition 更新仓位（追加新版本）
+func (s *JSONLStorage) UpdatePosition(pos *models.Position) error {
+	return s.AppendPosition(pos)
+}
 
-	// 按开仓时间排序
-	sort.Slice(result, func(i, j int) bool {
-		return result[i].OpenTime.Before(result[j].OpenTime)
-	})
+// MovePosition 把仓位的所有版本从 from 所在月份的文件移到 to 所在月份的文件（归档的旧版本同样移动）
+// 仓位按开仓月份分文件，开仓时间改到其他月份后新版本会追加到新月份的文件，旧版本需随之移动，
+// 否则两个文件中各有一个"最新版本"。先追加到新文件再原子重写原文件，同一月份时不做任何处理。
+func (s *JSONLStorage) MovePosition(positionID string, from, to time.Time) error {
+	if from.Year() == to.Year() && from.Month() == to.Month() {
+		return nil
+	}
 
-	return result, nil
+	lock, err := fsutil.LockDir(s.dataDir)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	fromName := filepath.Base(s.getFilePath(from.Year(), from.Month()))
+	toName := filepath.Base(s.getFilePath(to.Year(), to.Month()))
+	// 归档文件先移动，与读取时归档版本在前的顺序一致
+	for _, names := range [][2]string{
+		{historyFileName(fromName), historyFileName(toName)},
+		{fromName, toName},
+	} {
+		src := filepath.Join(s.dataDir, names[0])
+		dst := filepath.Join(s.dataDir, names[1])
+		if err := movePositionLines(positionID, src, dst); err != nil {
+			return fmt.Errorf("failed to move position %s: %w", positionID, err)
+		}
+	}
+	return nil
 }
 
-// ReadOpenPositions 读取所有未平仓位
-func (s *JSONLStorage) ReadOpenPositions() ([]*models.Position, error) {
-	allPositions, err := s.ReadAllPositions()
+// movePositionLines 把 src 中属于该仓位的行追加到 dst，再重写 src 去掉这些行
+func movePositionLines(positionID, src, dst string) error {
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return nil
+	}
+	if _, err := fsutil.RepairTornTail(src); err != nil {
+		return err
+	}
+	lines, err := readRawLines(src)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	openPositions := make([]*models.Position, 0)
-	for _, pos := range allPositions {
-		if pos.Status == models.StatusOpen {
-			openPositions = append(openPositions, pos)
+	var kept, moved [][]byte
+	for _, line := range lines {
+		var record struct {
+			PositionID string `json:"positionId"`
+		}
+		if json.Unmarshal(line, &record) == nil && record.PositionID == positionID {
+			moved = append(moved, line)
+		} else {
+			kept = append(kept, line)
 		}
 	}
+	if len(moved) == 0 {
+		return nil
+	}
 
-	return openPositions, nil
-}
-
-// UpdatePosition 更新仓位（追加新版本）
-func (s *JSONLStorage) UpdatePosition(pos *models.Position) error {
-	return s.AppendPosition(pos)
+	if err := fsutil.AppendLines(dst, moved...); err != nil {
+		return err
+	}
+	if len(kept) == 0 {
+		return os.Remove(src)
+	}
+	return fsutil.WriteFileAtomic(src, bytes.Join(append(kept, nil), []byte{'\n'}), 0644)
 }
 
 // FindPositionByID 根据ID查找仓位
 func (s *JSONLStorage) FindPositionByID(positionID string) (*models.Position, error) {
-	allPositions, err := s.ReadAllPositions()
+	for pos, err := range s.Positions(Query{PositionID: positionID}) {
+		if err != nil {
+			return nil, err
+		}
+		return pos, nil
+	}
+
+	return nil, fmt.Errorf("position not found: %s", positionID)
+}
+
+// PositionVersions 按写入顺序返回仓位的所有版本
+func (s *JSONLStorage) PositionVersions(positionID string) ([]*models.Position, error) {
+	names, err := tradeFileNames(s.dataDir)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to read data directory: %w", err)
+	}
+
+	// 先按原始文本筛选，只解析可能属于该仓位的行
+	needle := []byte(`"` + positionID + `"`)
+	match := func(line []byte) bool {
+		return bytes.Contains(line, needle)
 	}
 
-	for _, pos := range allPositions {
-		if pos.PositionID == positionID {
-			return pos, nil
+	var versions []*models.Position
+	for _, name := range names {
+		_, byID, err := s.readMonthVersions(name, match)
+		if err != nil {
+			return nil, err
 		}
+		versions = append(versions, byID[positionID]...)
 	}
 
-	return nil, fmt.Errorf("position not found: %s", positionID)
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("position not found: %s", positionID)
+	}
+	return versions, nil
+}
+
+// PositionHistories 按开仓时间顺序遍历最新版本满足查询条件的仓位，每次返回一个仓位按写入顺序的所有版本
+// 与 Positions 一样只读取开仓月份可能满足条件的文件
+func (s *JSONLStorage) PositionHistories(q Query) iter.Seq2[[]*models.Position, error] {
+	return func(yield func([]*models.Position, error) bool) {
+		names, err := tradeFileNames(s.dataDir)
+		if err != nil {
+			yield(nil, fmt.Errorf("failed to read data directory: %w", err))
+			return
+		}
+
+		for _, name := range names {
+			if !fileCovers(name, q) {
+				continue
+			}
+			ids, byID, err := s.readMonthVersions(name, nil)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: error reading file %s: %v\n", name, err)
+				continue
+			}
+
+			histories := make([][]*models.Position, 0, len(ids))
+			for _, id := range ids {
+				versions := byID[id]
+				if q.Matches(versions[len(versions)-1]) {
+					histories = append(histories, versions)
+				}
+			}
+			sort.SliceStable(histories, func(i, j int) bool {
+				return histories[i][len(histories[i])-1].OpenTime.Before(histories[j][len(histories[j])-1].OpenTime)
+			})
+			for _, versions := range histories {
+				if !yield(versions, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// readMonthVersions 按写入顺序读取一个月份的所有版本，按仓位分组
+// compact --archive 归档的旧版本（history-YYYY-MM.jsonl）排在交易文件中的版本之前；
+// match 不为空时只解析 match 返回 true 的行。返回的 ids 按仓位首次出现的顺序排列
+func (s *JSONLStorage) readMonthVersions(name string, match func(line []byte) bool) ([]string, map[string][]*models.Position, error) {
+	var ids []string
+	byID := make(map[string][]*models.Position)
+
+	for _, fileName := range []string{historyFileName(name), name} {
+		filePath := filepath.Join(s.dataDir, fileName)
+		if _, err := fsutil.RepairTornTail(filePath); err != nil {
+			return nil, nil, err
+		}
+		err := fsutil.ReadLines(filePath, func(lineNum int, line []byte) error {
+			if match != nil && !match(line) {
+				return nil
+			}
+			var pos models.Position
+			if err := json.Unmarshal(line, &pos); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: skipping invalid JSON at line %d in %s: %v\n",
+					lineNum, filePath, err)
+				return nil
+			}
+			if _, ok := byID[pos.PositionID]; !ok {
+				ids = append(ids, pos.PositionID)
+			}
+			byID[pos.PositionID] = append(byID[pos.PositionID], &pos)
+			return nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return ids, byID, nil
 }
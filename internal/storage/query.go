@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"iter"
+	"time"
+	"trading-journal-cli/internal/models"
+)
+
+// Query 仓位查询条件，零值字段不筛选
+type Query struct {
+	PositionID string
+	Status     models.Status
+	Symbol     string
+	MarketType models.MarketType
+	Account    string
+
+	OpenFrom  time.Time // 开仓时间不早于该时间
+	OpenTo    time.Time // 开仓时间不晚于该时间
+	CloseFrom time.Time // 平仓时间不早于该时间（只匹配已有平仓时间的仓位）
+	CloseTo   time.Time // 平仓时间不晚于该时间（只匹配已有平仓时间的仓位）
+
+	IncludeVoided bool // 包含已作废的仓位，默认排除
+}
+
+// Matches 仓位是否满足查询条件
+func (q Query) Matches(pos *models.Position) bool {
+	if pos.Voided != nil && !q.IncludeVoided {
+		return false
+	}
+	if q.PositionID != "" && pos.PositionID != q.PositionID {
+		return false
+	}
+	if q.Status != "" && pos.Status != q.Status {
+		return false
+	}
+	if q.Symbol != "" && pos.Symbol != q.Symbol {
+		return false
+	}
+	if q.MarketType != "" && pos.MarketType != q.MarketType {
+		return false
+	}
+	if q.Account != "" && pos.AccountName != q.Account {
+		return false
+	}
+	if !q.OpenFrom.IsZero() && pos.OpenTime.Before(q.OpenFrom) {
+		return false
+	}
+	if !q.OpenTo.IsZero() && pos.OpenTime.After(q.OpenTo) {
+		return false
+	}
+	if !q.CloseFrom.IsZero() || !q.CloseTo.IsZero() {
+		if pos.CloseTime == nil {
+			return false
+		}
+		if !q.CloseFrom.IsZero() && pos.CloseTime.Before(q.CloseFrom) {
+			return false
+		}
+		if !q.CloseTo.IsZero() && pos.CloseTime.After(q.CloseTo) {
+			return false
+		}
+	}
+	return true
+}
+
+// latestOpenTime 满足查询条件的仓位最晚的开仓时间，零值表示不限制
+// 平仓时间不早于开仓时间，因此平仓时间上限同样限制了开仓时间
+func (q Query) latestOpenTime() time.Time {
+	latest := q.OpenTo
+	if !q.CloseTo.IsZero() && (latest.IsZero() || q.CloseTo.Before(latest)) {
+		latest = q.CloseTo
+	}
+	return latest
+}
+
+// Collect 读取迭代器中的所有仓位
+func Collect(seq iter.Seq2[*models.Position, error]) ([]*models.Position, error) {
+	result := make([]*models.Position, 0)
+	for pos, err := range seq {
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, pos)
+	}
+	return result, nil
+}
@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"testing"
+	"time"
+	"trading-journal-cli/internal/models"
+)
+
+func TestQuery_Matches(t *testing.T) {
+	openTime := time.Date(2025, 3, 10, 9, 30, 0, 0, time.UTC)
+	closeTime := time.Date(2025, 3, 12, 15, 0, 0, 0, time.UTC)
+	closed := &models.Position{
+		PositionID:  "A",
+		AccountName: "main",
+		Symbol:      "BTC",
+		MarketType:  models.MarketTypeCrypto,
+		Status:      models.StatusClosed,
+		OpenTime:    openTime,
+		CloseTime:   &closeTime,
+	}
+	open := &models.Position{PositionID: "B", AccountName: "main", Symbol: "BTC", Status: models.StatusOpen, OpenTime: openTime}
+	voided := *closed
+	voided.Voided = &models.VoidRecord{Time: closeTime, Reason: "duplicate"}
+
+	tests := []struct {
+		name     string
+		query    Query
+		pos      *models.Position
+		expected bool
+	}{
+		{"Empty query", Query{}, closed, true},
+		{"Position ID", Query{PositionID: "B"}, closed, false},
+		{"Status", Query{Status: models.StatusOpen}, closed, false},
+		{"Symbol", Query{Symbol: "ETH"}, closed, false},
+		{"Market type", Query{MarketType: models.MarketTypeForex}, closed, false},
+		{"Account", Query{Account: "other"}, closed, false},
+		{"Voided excluded by default", Query{}, &voided, false},
+		{"Voided included", Query{IncludeVoided: true}, &voided, true},
+		{"Open from is inclusive", Query{OpenFrom: openTime}, closed, true},
+		{"Open from after open", Query{OpenFrom: openTime.Add(time.Second)}, closed, false},
+		{"Open to is inclusive", Query{OpenTo: openTime}, closed, true},
+		{"Open to before open", Query{OpenTo: openTime.Add(-time.Second)}, closed, false},
+		{"Close from is inclusive", Query{CloseFrom: closeTime}, closed, true},
+		{"Close from after close", Query{CloseFrom: closeTime.Add(time.Second)}, closed, false},
+		{"Close to is inclusive", Query{CloseTo: closeTime}, closed, true},
+		{"Close to before close", Query{CloseTo: closeTime.Add(-time.Second)}, closed, false},
+		{"Close range skips positions without close time", Query{CloseTo: closeTime}, open, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.query.Matches(tt.pos); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestQuery_LatestOpenTime(t *testing.T) {
+	early := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	late := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		query    Query
+		expected time.Time
+	}{
+		{"No upper bound", Query{OpenFrom: early, CloseFrom: early}, time.Time{}},
+		{"Open to only", Query{OpenTo: late}, late},
+		{"Close to only", Query{CloseTo: early}, early},
+		{"Close to earlier than open to", Query{OpenTo: late, CloseTo: early}, early},
+		{"Open to earlier than close to", Query{OpenTo: early, CloseTo: late}, early},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.query.latestOpenTime(); !got.Equal(tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFileCovers(t *testing.T) {
+	local := func(year int, month time.Month, day, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, time.Local)
+	}
+	// 查询边界来自其他时区时，换算为本地时间后与月份边界相差不超过一天
+	farEast := time.FixedZone("UTC+14", 14*3600)
+	farWest := time.FixedZone("UTC-12", -12*3600)
+
+	const march = "trades-2025-03.jsonl"
+	tests := []struct {
+		name     string
+		file     string
+		query    Query
+		expected bool
+	}{
+		{"No time bounds", march, Query{Symbol: "BTC"}, true},
+		{"Unrecognized file name", "trades-latest.jsonl", Query{OpenFrom: local(2030, 1, 1, 0)}, true},
+
+		// 开仓时间下限：月末之后放宽一天
+		{"Open from inside month", march, Query{OpenFrom: local(2025, 3, 15, 0)}, true},
+		{"Open from on next month start", march, Query{OpenFrom: local(2025, 4, 1, 0)}, true},
+		{"Open from within one day slack", march, Query{OpenFrom: local(2025, 4, 1, 23)}, true},
+		{"Open from beyond slack", march, Query{OpenFrom: local(2025, 4, 2, 1)}, false},
+
+		// 开仓时间上限：月初之前放宽一天
+		{"Open to inside month", march, Query{OpenTo: local(2025, 3, 15, 0)}, true},
+		{"Open to within one day slack", march, Query{OpenTo: local(2025, 2, 28, 1)}, true},
+		{"Open to beyond slack", march, Query{OpenTo: local(2025, 2, 27, 23)}, false},
+
+		// 其他时区的查询边界：在放宽的一天内，不会跳过该月份文件
+		{"Open from in far east zone", march, Query{OpenFrom: time.Date(2025, 4, 1, 12, 0, 0, 0, farEast)}, true},
+		{"Open to in far west zone", march, Query{OpenTo: time.Date(2025, 2, 28, 12, 0, 0, 0, farWest)}, true},
+
+		// 只有平仓时间条件：平仓时间上限同样限制开仓时间，平仓时间下限不限制
+		{"Close to before month", march, Query{CloseTo: local(2025, 2, 15, 0)}, false},
+		{"Close to inside month", march, Query{CloseTo: local(2025, 3, 2, 0)}, true},
+		{"Close to after month", march, Query{CloseTo: local(2025, 6, 1, 0)}, true},
+		{"Close from after month", march, Query{CloseFrom: local(2025, 6, 1, 0)}, true},
+		{"Close to tighter than open to", march, Query{OpenTo: local(2025, 6, 1, 0), CloseTo: local(2025, 2, 1, 0)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fileCovers(tt.file, tt.query); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,403 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"trading-journal-cli/internal/fsutil"
+	"trading-journal-cli/internal/models"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteFileName 数据目录下的 SQLite 数据库文件名
+const SQLiteFileName = "trades.db"
+
+// sqliteSchemaVersion 当前数据库结构版本
+const sqliteSchemaVersion = 2
+
+// sqliteMigrations 按版本顺序执行的结构变更，索引 i 将数据库从版本 i 升级到 i+1
+var sqliteMigrations = []string{
+	`CREATE TABLE position_versions (
+		seq          INTEGER PRIMARY KEY AUTOINCREMENT,
+		position_id  TEXT    NOT NULL,
+		recorded_at  INTEGER NOT NULL,
+		data         TEXT    NOT NULL
+	);
+	CREATE INDEX idx_position_versions_id ON position_versions(position_id, seq);
+
+	CREATE TABLE positions (
+		position_id  TEXT    PRIMARY KEY,
+		account      TEXT    NOT NULL,
+		symbol       TEXT    NOT NULL,
+		market_type  TEXT    NOT NULL,
+		status       TEXT    NOT NULL,
+		open_time    INTEGER NOT NULL,
+		close_time   INTEGER,
+		version_seq  INTEGER NOT NULL REFERENCES position_versions(seq),
+		data         TEXT    NOT NULL
+	);
+	CREATE INDEX idx_positions_status ON positions(status, open_time);
+	CREATE INDEX idx_positions_symbol ON positions(symbol, open_time);
+	CREATE INDEX idx_positions_account ON positions(account, open_time);
+	CREATE INDEX idx_positions_open_time ON positions(open_time);
+	CREATE INDEX idx_positions_close_time ON positions(close_time);`,
+
+	`ALTER TABLE positions ADD COLUMN voided INTEGER NOT NULL DEFAULT 0;`,
+}
+
+// SQLiteStorage SQLite 存储
+//
+// position_versions 保存每次追加的完整版本（与 JSONL 文件的追加历史一致），
+// positions 保存每个仓位的最新版本并按 ID、状态、品种、账户和时间建立索引。
+type SQLiteStorage struct {
+	db   *sql.DB
+	path string
+}
+
+// NewSQLiteStorage 打开（不存在时创建）数据目录下的 SQLite 数据库并升级到最新结构
+func NewSQLiteStorage(dataDir string) (*SQLiteStorage, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	path := filepath.Join(dataDir, SQLiteFileName)
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)", path)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	s := &SQLiteStorage{db: db, path: path}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Path 数据库文件路径
+func (s *SQLiteStorage) Path() string {
+	return s.path
+}
+
+// Lock 获取数据库所在数据目录的锁，与资金流水和账户配置共用同一把锁
+func (s *SQLiteStorage) Lock() (*fsutil.Lock, error) {
+	return fsutil.LockDir(filepath.Dir(s.path))
+}
+
+// Close 关闭数据库
+func (s *SQLiteStorage) Close() error {
+	return s.db.Close()
+}
+
+// migrate 按 schema_version 表记录的版本执行尚未执行的结构变更
+func (s *SQLiteStorage) migrate() error {
+	if _, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS schema_version (version INTEGER NOT NULL)`); err != nil {
+		return fmt.Errorf("failed to create schema_version table: %w", err)
+	}
+
+	var version int
+	err := s.db.QueryRow(`SELECT version FROM schema_version`).Scan(&version)
+	if err == sql.ErrNoRows {
+		if _, err := s.db.Exec(`INSERT INTO schema_version (version) VALUES (0)`); err != nil {
+			return fmt.Errorf("failed to initialize schema version: %w", err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	if version > sqliteSchemaVersion {
+		return fmt.Errorf("database schema version %d is newer than supported version %d", version, sqliteSchemaVersion)
+	}
+
+	for ; version < sqliteSchemaVersion; version++ {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(sqliteMigrations[version]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to migrate database to version %d: %w", version+1, err)
+		}
+		if _, err := tx.Exec(`UPDATE schema_version SET version = ?`, version+1); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to update schema version: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to migrate database to version %d: %w", version+1, err)
+		}
+	}
+
+	return nil
+}
+
+// AppendPosition 追加仓位版本并更新最新版本
+func (s *SQLiteStorage) AppendPosition(pos *models.Position) error {
+	recordedAt := time.Now()
+	pos.RecordedAt = &recordedAt
+	data, err := json.Marshal(pos)
+	if err != nil {
+		return fmt.Errorf("failed to marshal position: %w", err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if err := appendVersion(tx, pos, data, recordedAt); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to save position: %w", err)
+	}
+	return nil
+}
+
+// appendVersion 在事务内写入一个版本并更新 positions 表
+func appendVersion(tx *sql.Tx, pos *models.Position, data []byte, recordedAt time.Time) error {
+	result, err := tx.Exec(`INSERT INTO position_versions (position_id, recorded_at, data) VALUES (?, ?, ?)`,
+		pos.PositionID, recordedAt.UnixNano(), string(data))
+	if err != nil {
+		return fmt.Errorf("failed to write position version: %w", err)
+	}
+	seq, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to write position version: %w", err)
+	}
+
+	var closeTime interface{}
+	if pos.CloseTime != nil {
+		closeTime = pos.CloseTime.UnixNano()
+	}
+	_, err = tx.Exec(`INSERT INTO positions
+			(position_id, account, symbol, market_type, status, open_time, close_time, voided, version_seq, data)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(position_id) DO UPDATE SET
+			account = excluded.account,
+			symbol = excluded.symbol,
+			market_type = excluded.market_type,
+			status = excluded.status,
+			open_time = excluded.open_time,
+			close_time = excluded.close_time,
+			voided = excluded.voided,
+			version_seq = excluded.version_seq,
+			data = excluded.data`,
+		pos.PositionID, pos.AccountName, pos.Symbol, string(pos.MarketType), string(pos.Status),
+		pos.OpenTime.UnixNano(), closeTime, pos.Voided != nil, seq, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to update position: %w", err)
+	}
+	return nil
+}
+
+// whereClause 将查询条件转换为 positions 表（别名 p）上的 WHERE 子句
+func whereClause(q Query) (string, []interface{}) {
+	var where []string
+	var args []interface{}
+	add := func(clause string, arg interface{}) {
+		where = append(where, clause)
+		args = append(args, arg)
+	}
+	if q.PositionID != "" {
+		add("p.position_id = ?", q.PositionID)
+	}
+	if !q.IncludeVoided {
+		where = append(where, "p.voided = 0")
+	}
+	if q.Status != "" {
+		add("p.status = ?", string(q.Status))
+	}
+	if q.Symbol != "" {
+		add("p.symbol = ?", q.Symbol)
+	}
+	if q.MarketType != "" {
+		add("p.market_type = ?", string(q.MarketType))
+	}
+	if q.Account != "" {
+		add("p.account = ?", q.Account)
+	}
+	if !q.OpenFrom.IsZero() {
+		add("p.open_time >= ?", q.OpenFrom.UnixNano())
+	}
+	if !q.OpenTo.IsZero() {
+		add("p.open_time <= ?", q.OpenTo.UnixNano())
+	}
+	if !q.CloseFrom.IsZero() {
+		add("p.close_time >= ?", q.CloseFrom.UnixNano())
+	}
+	if !q.CloseTo.IsZero() {
+		add("p.close_time <= ?", q.CloseTo.UnixNano())
+	}
+
+	if len(where) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(where, " AND "), args
+}
+
+// Positions 按开仓时间顺序遍历满足查询条件的仓位，筛选条件均使用索引在数据库中完成
+func (s *SQLiteStorage) Positions(q Query) iter.Seq2[*models.Position, error] {
+	return func(yield func(*models.Position, error) bool) {
+		where, args := whereClause(q)
+		query := "SELECT p.data FROM positions p" + where + " ORDER BY p.open_time"
+
+		rows, err := s.db.Query(query, args...)
+		if err != nil {
+			yield(nil, fmt.Errorf("failed to query positions: %w", err))
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var data string
+			if err := rows.Scan(&data); err != nil {
+				yield(nil, fmt.Errorf("failed to read position: %w", err))
+				return
+			}
+			var pos models.Position
+			if err := json.Unmarshal([]byte(data), &pos); err != nil {
+				yield(nil, fmt.Errorf("failed to parse position: %w", err))
+				return
+			}
+			if !yield(&pos, nil) {
+				return
+			}
+		}
+		if err := rows.Err(); err != nil {
+			yield(nil, fmt.Errorf("failed to query positions: %w", err))
+		}
+	}
+}
+
+// PositionHistories 按开仓时间顺序遍历最新版本满足查询条件的仓位，每次返回一个仓位按写入顺序的所有版本
+func (s *SQLiteStorage) PositionHistories(q Query) iter.Seq2[[]*models.Position, error] {
+	return func(yield func([]*models.Position, error) bool) {
+		where, args := whereClause(q)
+		query := `SELECT v.position_id, v.data FROM position_versions v
+			JOIN positions p ON p.position_id = v.position_id` + where + `
+			ORDER BY p.open_time, v.position_id, v.seq`
+
+		rows, err := s.db.Query(query, args...)
+		if err != nil {
+			yield(nil, fmt.Errorf("failed to query position versions: %w", err))
+			return
+		}
+		defer rows.Close()
+
+		var current string
+		var versions []*models.Position
+		for rows.Next() {
+			var positionID, data string
+			if err := rows.Scan(&positionID, &data); err != nil {
+				yield(nil, fmt.Errorf("failed to read position version: %w", err))
+				return
+			}
+			var pos models.Position
+			if err := json.Unmarshal([]byte(data), &pos); err != nil {
+				yield(nil, fmt.Errorf("failed to parse position version: %w", err))
+				return
+			}
+			if positionID != current && len(versions) > 0 {
+				if !yield(versions, nil) {
+					return
+				}
+				versions = nil
+			}
+			current = positionID
+			versions = append(versions, &pos)
+		}
+		if err := rows.Err(); err != nil {
+			yield(nil, fmt.Errorf("failed to query position versions: %w", err))
+			return
+		}
+		if len(versions) > 0 {
+			yield(versions, nil)
+		}
+	}
+}
+
+// ReadPositions 读取指定月份开仓的所有仓位
+func (s *SQLiteStorage) ReadPositions(year int, month time.Month) ([]*models.Position, error) {
+	from := time.Date(year, month, 1, 0, 0, 0, 0, time.Local)
+	to := from.AddDate(0, 1, 0).Add(-time.Nanosecond)
+	return Collect(s.Positions(Query{OpenFrom: from, OpenTo: to}))
+}
+
+// ReadAllPositions 读取所有仓位
+func (s *SQLiteStorage) ReadAllPositions() ([]*models.Position, error) {
+	return Collect(s.Positions(Query{}))
+}
+
+// ReadOpenPositions 读取所有未平仓位
+func (s *SQLiteStorage) ReadOpenPositions() ([]*models.Position, error) {
+	return Collect(s.Positions(Query{Status: models.StatusOpen}))
+}
+
+// UpdatePosition 更新仓位（追加新版本）
+func (s *SQLiteStorage) UpdatePosition(pos *models.Position) error {
+	return s.AppendPosition(pos)
+}
+
+// MovePosition 数据库不按月份分文件，写入新版本时 positions 表的开仓时间随之更新，无需移动
+func (s *SQLiteStorage) MovePosition(positionID string, from, to time.Time) error {
+	return nil
+}
+
+// FindPositionByID 根据ID查找仓位
+func (s *SQLiteStorage) FindPositionByID(positionID string) (*models.Position, error) {
+	for pos, err := range s.Positions(Query{PositionID: positionID}) {
+		if err != nil {
+			return nil, err
+		}
+		return pos, nil
+	}
+	return nil, fmt.Errorf("position not found: %s", positionID)
+}
+
+// PositionVersions 按写入顺序返回仓位的所有版本
+func (s *SQLiteStorage) PositionVersions(positionID string) ([]*models.Position, error) {
+	rows, err := s.db.Query(`SELECT data FROM position_versions WHERE position_id = ? ORDER BY seq`, positionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query position versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []*models.Position
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to read position version: %w", err)
+		}
+		var pos models.Position
+		if err := json.Unmarshal([]byte(data), &pos); err != nil {
+			return nil, fmt.Errorf("failed to parse position version: %w", err)
+		}
+		versions = append(versions, &pos)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query position versions: %w", err)
+	}
+
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("position not found: %s", positionID)
+	}
+	return versions, nil
+}
+
+// CountVersions 数据库中的仓位数和版本数
+func (s *SQLiteStorage) CountVersions() (positions, versions int, err error) {
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM positions`).Scan(&positions); err != nil {
+		return 0, 0, fmt.Errorf("failed to count positions: %w", err)
+	}
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM position_versions`).Scan(&versions); err != nil {
+		return 0, 0, fmt.Errorf("failed to count versions: %w", err)
+	}
+	return positions, versions, nil
+}
@@ -0,0 +1,221 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"trading-journal-cli/internal/fsutil"
+	"trading-journal-cli/internal/models"
+)
+
+// TransferResult JSONL 与 SQLite 之间导入导出的统计
+type TransferResult struct {
+	Files     []string `json:"files"`
+	Positions int      `json:"positions"`
+	Versions  int      `json:"versions"`
+	Skipped   int      `json:"skipped"` // 无法解析而跳过的行
+}
+
+// tradeFileNames 目录下所有 trades-YYYY-MM.jsonl 文件名（按名称排序）
+func tradeFileNames(dir string) ([]string, error) {
+	return jsonlFileNames(dir, "trades-")
+}
+
+// historyFileName 月份文件对应的归档文件名，compact 把被取代的旧版本归档到该文件
+func historyFileName(tradeFileName string) string {
+	return "history-" + strings.TrimPrefix(tradeFileName, "trades-")
+}
+
+// jsonlFileNames 目录下以 prefix 开头的所有 .jsonl 文件名（按名称排序）
+func jsonlFileNames(dir, prefix string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".jsonl" || !strings.HasPrefix(entry.Name(), prefix) {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ImportJSONL 将目录下 trades-YYYY-MM.jsonl 中的所有版本按原有顺序导入数据库
+//
+// 与 JSONLStorage 读取时一致，每个月份先导入 compact 归档的旧版本（history-YYYY-MM.jsonl），
+// 再导入交易文件。每一行作为一个版本原样保存，同一仓位的最后一行成为最新版本。
+// 数据库已有数据时需要指定 replace，先清空再导入，避免重复版本。
+func (s *SQLiteStorage) ImportJSONL(dir string, replace bool) (*TransferResult, error) {
+	names, err := tradeFileNames(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var existing int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM position_versions`).Scan(&existing); err != nil {
+		return nil, fmt.Errorf("failed to count versions: %w", err)
+	}
+	if existing > 0 {
+		if !replace {
+			return nil, fmt.Errorf("database already contains %d position versions", existing)
+		}
+		if _, err := tx.Exec(`DELETE FROM positions`); err != nil {
+			return nil, fmt.Errorf("failed to clear positions: %w", err)
+		}
+		if _, err := tx.Exec(`DELETE FROM position_versions`); err != nil {
+			return nil, fmt.Errorf("failed to clear position versions: %w", err)
+		}
+	}
+
+	result := &TransferResult{}
+	positions := make(map[string]bool)
+	recordedAt := time.Now()
+	for _, name := range names {
+		for _, fileName := range []string{historyFileName(name), name} {
+			filePath := filepath.Join(dir, fileName)
+			if _, err := os.Stat(filePath); os.IsNotExist(err) {
+				continue
+			}
+			if _, err := fsutil.RepairTornTail(filePath); err != nil {
+				return nil, err
+			}
+			lines, err := readRawLines(filePath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", fileName, err)
+			}
+			result.Files = append(result.Files, fileName)
+
+			for i, line := range lines {
+				var pos models.Position
+				if err := json.Unmarshal(line, &pos); err != nil || pos.PositionID == "" {
+					if err == nil {
+						err = fmt.Errorf("missing positionId")
+					}
+					fmt.Fprintf(os.Stderr, "Warning: skipping invalid JSON at line %d in %s: %v\n", i+1, fileName, err)
+					result.Skipped++
+					continue
+				}
+				if err := appendVersion(tx, &pos, line, recordedAt); err != nil {
+					return nil, err
+				}
+				positions[pos.PositionID] = true
+				result.Versions++
+			}
+		}
+	}
+	result.Positions = len(positions)
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit import: %w", err)
+	}
+	return result, nil
+}
+
+// ExportJSONL 将数据库中的所有版本按写入顺序导出为目录下的 trades-YYYY-MM.jsonl
+//
+// 按仓位最新版本的开仓月份分文件，与 JSONLStorage 的布局一致，归档的旧版本同样导出到交易文件中。
+// 目录中已有交易记录文件时需要指定 overwrite，被覆盖的文件备份为带时间戳的 .backup。
+func (s *SQLiteStorage) ExportJSONL(dir string, overwrite bool) (*TransferResult, error) {
+	lock, err := fsutil.LockDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	existing, err := tradeFileNames(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 && !overwrite {
+		return nil, fmt.Errorf("%s already contains %d trade files", dir, len(existing))
+	}
+
+	// 仓位的所有版本写入最新版本开仓月份的文件，与 MovePosition 一致：
+	// 修改过开仓时间的仓位如果按各版本自己的开仓时间分文件，两个文件中会各有一个"最新版本"
+	rows, err := s.db.Query(`SELECT v.position_id, v.data, p.data FROM position_versions v
+		JOIN positions p ON p.position_id = v.position_id
+		ORDER BY v.seq`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query position versions: %w", err)
+	}
+	defer rows.Close()
+
+	target := NewJSONLStorage(dir)
+	files := make(map[string]*bytes.Buffer)
+	positions := make(map[string]string) // 仓位ID -> 导出的文件名
+	result := &TransferResult{}
+	for rows.Next() {
+		var positionID, data, latest string
+		if err := rows.Scan(&positionID, &data, &latest); err != nil {
+			return nil, fmt.Errorf("failed to read position version: %w", err)
+		}
+		name, ok := positions[positionID]
+		if !ok {
+			var pos models.Position
+			if err := json.Unmarshal([]byte(latest), &pos); err != nil {
+				return nil, fmt.Errorf("failed to parse position %s: %w", positionID, err)
+			}
+			name = filepath.Base(target.getFilePath(pos.OpenTime.Year(), pos.OpenTime.Month()))
+			positions[positionID] = name
+		}
+
+		buf, ok := files[name]
+		if !ok {
+			buf = &bytes.Buffer{}
+			files[name] = buf
+		}
+		buf.WriteString(data)
+		buf.WriteByte('\n')
+		result.Versions++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query position versions: %w", err)
+	}
+	result.Positions = len(positions)
+
+	// 覆盖前备份已有文件，并删除数据库中没有对应数据的旧文件，导出后目录内容与数据库一致
+	// 已有的归档文件同样备份后删除：导出的交易文件已包含所有版本，保留归档会重复读取旧版本
+	archives, err := jsonlFileNames(dir, "history-")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range append(existing, archives...) {
+		filePath := filepath.Join(dir, name)
+		if _, err := backupFile(filePath); err != nil {
+			return nil, fmt.Errorf("failed to create backup: %w", err)
+		}
+		if _, ok := files[name]; !ok {
+			if err := os.Remove(filePath); err != nil {
+				return nil, fmt.Errorf("failed to remove %s: %w", name, err)
+			}
+		}
+	}
+
+	for name, buf := range files {
+		if err := fsutil.WriteFileAtomic(filepath.Join(dir, name), buf.Bytes(), 0644); err != nil {
+			return nil, err
+		}
+		result.Files = append(result.Files, name)
+	}
+	sort.Strings(result.Files)
+
+	return result, nil
+}
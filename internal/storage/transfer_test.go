@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+	"trading-journal-cli/internal/models"
+)
+
+// versionQuantities 每个版本的数量，用于比较不同存储中的版本序列
+func versionQuantities(t *testing.T, s Storage, positionID string) []float64 {
+	t.Helper()
+	versions, err := s.PositionVersions(positionID)
+	if err != nil {
+		t.Fatalf("PositionVersions(%s) failed: %v", positionID, err)
+	}
+	quantities := make([]float64, len(versions))
+	for i, v := range versions {
+		quantities[i] = v.Quantity
+	}
+	return quantities
+}
+
+func equalQuantities(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestJSONLSQLiteRoundTrip(t *testing.T) {
+	src := NewJSONLStorage(t.TempDir())
+	january := time.Date(2025, 1, 10, 9, 0, 0, 0, time.Local)
+	february := time.Date(2025, 2, 3, 9, 0, 0, 0, time.Local)
+
+	// A 有三个版本且之后被压缩归档，B 在另一个月份有两个版本
+	versions := []*models.Position{
+		{PositionID: "A", AccountName: "main", Symbol: "BTC", OpenTime: january, Status: models.StatusOpen, Quantity: 3},
+		{PositionID: "A", AccountName: "main", Symbol: "BTC", OpenTime: january, Status: models.StatusOpen, Quantity: 2},
+		{PositionID: "B", AccountName: "main", Symbol: "ETH", OpenTime: february, Status: models.StatusOpen, Quantity: 5},
+		{PositionID: "A", AccountName: "main", Symbol: "BTC", OpenTime: january, Status: models.StatusClosed, Quantity: 0},
+		{PositionID: "B", AccountName: "main", Symbol: "ETH", OpenTime: february, Status: models.StatusOpen, Quantity: 4},
+	}
+	for _, pos := range versions {
+		if err := src.AppendPosition(pos); err != nil {
+			t.Fatalf("AppendPosition failed: %v", err)
+		}
+	}
+	if _, err := src.Compact(CompactOptions{}); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+
+	db, err := NewSQLiteStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewSQLiteStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	imported, err := db.ImportJSONL(src.dataDir, false)
+	if err != nil {
+		t.Fatalf("ImportJSONL failed: %v", err)
+	}
+	if imported.Positions != 2 || imported.Versions != 5 {
+		t.Errorf("Expected 2 positions and 5 versions imported, got %+v", imported)
+	}
+
+	dst := NewJSONLStorage(t.TempDir())
+	if _, err := db.ExportJSONL(dst.dataDir, false); err != nil {
+		t.Fatalf("ExportJSONL failed: %v", err)
+	}
+	// 覆盖导出到原目录时归档文件被并入交易文件，不会重复读取旧版本
+	if _, err := db.ExportJSONL(src.dataDir, true); err != nil {
+		t.Fatalf("ExportJSONL with overwrite failed: %v", err)
+	}
+
+	expected := map[string][]float64{"A": {3, 2, 0}, "B": {5, 4}}
+	for id, want := range expected {
+		for name, s := range map[string]Storage{"sqlite": db, "exported": dst, "overwritten": src} {
+			if got := versionQuantities(t, s, id); !equalQuantities(got, want) {
+				t.Errorf("%s: expected versions of %s %v, got %v", name, id, want, got)
+			}
+		}
+	}
+
+	// 最新版本（包括写入时间）在各存储中一致
+	want, _ := json.Marshal([]*models.Position{versions[3], versions[4]})
+	for _, s := range []Storage{db, dst, src} {
+		positions, err := s.ReadAllPositions()
+		if err != nil {
+			t.Fatalf("ReadAllPositions failed: %v", err)
+		}
+		data, _ := json.Marshal(positions)
+		if string(data) != string(want) {
+			t.Errorf("Expected latest positions %s, got %s", want, data)
+		}
+	}
+}
+
+func TestJSONLSQLiteRoundTrip_EditedOpenTime(t *testing.T) {
+	src := NewJSONLStorage(t.TempDir())
+	september := time.Date(2025, 9, 5, 9, 0, 0, 0, time.Local)
+	august := time.Date(2025, 8, 28, 9, 0, 0, 0, time.Local)
+
+	// 开仓时间从 9 月修改为 8 月：已有版本先移到 8 月的文件，再追加修改后的版本
+	if err := src.AppendPosition(&models.Position{PositionID: "A", Symbol: "BTC", OpenTime: september, Status: models.StatusOpen, Quantity: 1}); err != nil {
+		t.Fatalf("AppendPosition failed: %v", err)
+	}
+	if err := src.MovePosition("A", september, august); err != nil {
+		t.Fatalf("MovePosition failed: %v", err)
+	}
+	if err := src.AppendPosition(&models.Position{PositionID: "A", Symbol: "BTC", OpenTime: august, Status: models.StatusOpen, Quantity: 1}); err != nil {
+		t.Fatalf("AppendPosition failed: %v", err)
+	}
+
+	db, err := NewSQLiteStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewSQLiteStorage failed: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.ImportJSONL(src.dataDir, false); err != nil {
+		t.Fatalf("ImportJSONL failed: %v", err)
+	}
+
+	dst := NewJSONLStorage(t.TempDir())
+	exported, err := db.ExportJSONL(dst.dataDir, false)
+	if err != nil {
+		t.Fatalf("ExportJSONL failed: %v", err)
+	}
+
+	// 所有版本导出到最新开仓时间所在月份的文件
+	if len(exported.Files) != 1 || exported.Files[0] != "trades-2025-08.jsonl" {
+		t.Errorf("Expected only trades-2025-08.jsonl, got %v", exported.Files)
+	}
+	positions, err := dst.ReadAllPositions()
+	if err != nil {
+		t.Fatalf("ReadAllPositions failed: %v", err)
+	}
+	if len(positions) != 1 || !positions[0].OpenTime.Equal(august) {
+		t.Errorf("Expected one position opened in August, got %d", len(positions))
+	}
+	if got := versionQuantities(t, dst, "A"); len(got) != 2 {
+		t.Errorf("Expected 2 versions of A, got %d", len(got))
+	}
+}
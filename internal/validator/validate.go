@@ -3,6 +3,8 @@ package validator
 import (
 	"errors"
 	"fmt"
+	"math"
+	"strings"
 	"trading-journal-cli/internal/models"
 )
 
@@ -17,12 +19,70 @@ var (
 	ErrPositionNotFound      = errors.New("position not found")
 	ErrPositionAlreadyClosed = errors.New("position already closed")
 	ErrInvalidCloseQuantity  = errors.New("close quantity exceeds position quantity")
+	ErrInvalidEntryTime      = errors.New("entry time is before position open time")
+	ErrInvalidOpenTime       = errors.New("open time is after later entries, adjustments or fills")
+
+	// 账户风控限制
+	ErrRiskPerTradeExceeded = errors.New("risk per trade exceeds account limit")
+	ErrOpenRiskExceeded     = errors.New("total open risk exceeds account limit")
+	ErrSymbolPositionLimit  = errors.New("too many open positions for symbol")
+	ErrRiskRewardTooLow     = errors.New("risk reward ratio below account minimum")
+	ErrMarginUsageExceeded  = errors.New("margin usage exceeds account limit")
+	ErrNonPositiveBalance   = errors.New("account balance must be positive for percentage limits")
+	ErrAccountLocked        = errors.New("account is locked by circuit breaker")
 )
 
+// RiskLimitError 违反账户风控限制，可能同时违反多项
+// errors.Is 可匹配其中任意一项对应的 Err* 值
+type RiskLimitError struct {
+	Violations []RiskLimitViolation
+}
+
+// RiskLimitViolation 单项风控限制违反
+type RiskLimitViolation struct {
+	Err    error   // 对应的 Err* 值
+	Actual float64 // 开仓后的实际值
+	Limit  float64 // 限制值
+}
+
+// Error 单项违反的描述
+func (v RiskLimitViolation) Error() string {
+	return fmt.Sprintf("%v: %.2f (limit %.2f)", v.Err, v.Actual, v.Limit)
+}
+
+func (e *RiskLimitError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap 返回所有违反项对应的 Err* 值
+func (e *RiskLimitError) Unwrap() []error {
+	errs := make([]error, len(e.Violations))
+	for i, v := range e.Violations {
+		errs[i] = v.Err
+	}
+	return errs
+}
+
+// RiskExposure 开仓前账户的风险敞口，风险金额由调用方按合约乘数换算为账户币种
+type RiskExposure struct {
+	Balance         float64 // 账户余额
+	OpenRisk        float64 // 现有未平仓位按当前止损的可能亏损合计
+	OpenMargin      float64 // 现有未平仓位的保证金合计
+	SymbolPositions int     // 同品种现有未平仓位数量
+}
+
 // Validator 验证器接口
 type Validator interface {
 	ValidateOpenPosition(pos *models.Position) error
 	ValidateClosePosition(pos *models.Position, closeQuantity float64) error
+	ValidateAddToPosition(pos *models.Position, entry models.EntryFill) error
+	ValidateAdjustPosition(pos *models.Position, stopLoss, takeProfit, currentPrice float64) error
+	ValidateEditPosition(pos *models.Position) error
+	ValidateRiskLimits(pos *models.Position, limits *models.RiskLimits, exposure RiskExposure) error
 }
 
 // PositionValidator 仓位验证器
@@ -66,21 +126,25 @@ func (v *PositionValidator) ValidateOpenPosition(pos *models.Position) error {
 	}
 
 	// 验证止损止盈范围
-	if pos.Direction == models.DirectionLong {
-		// 做多: 止损 < 开仓价 < 止盈
-		if pos.StopLoss >= pos.OpenPrice {
-			return fmt.Errorf("%w: for long position, stop loss must be below open price", ErrStopLossRange)
+	return validateLevelRange(pos.Direction, pos.StopLoss, pos.TakeProfit, pos.OpenPrice, "open price")
+}
+
+// validateLevelRange 验证止损止盈相对参考价格的范围
+// 做多: 止损 < 参考价 < 止盈；做空: 止盈 < 参考价 < 止损
+func validateLevelRange(direction models.Direction, stopLoss, takeProfit, refPrice float64, refName string) error {
+	if direction == models.DirectionLong {
+		if stopLoss >= refPrice {
+			return fmt.Errorf("%w: for long position, stop loss must be below %s", ErrStopLossRange, refName)
 		}
-		if pos.TakeProfit <= pos.OpenPrice {
-			return fmt.Errorf("%w: for long position, take profit must be above open price", ErrTakeProfitRange)
+		if takeProfit <= refPrice {
+			return fmt.Errorf("%w: for long position, take profit must be above %s", ErrTakeProfitRange, refName)
 		}
-	} else if pos.Direction == models.DirectionShort {
-		// 做空: 止盈 < 开仓价 < 止损
-		if pos.StopLoss <= pos.OpenPrice {
-			return fmt.Errorf("%w: for short position, stop loss must be above open price", ErrStopLossRange)
+	} else if direction == models.DirectionShort {
+		if stopLoss <= refPrice {
+			return fmt.Errorf("%w: for short position, stop loss must be above %s", ErrStopLossRange, refName)
 		}
-		if pos.TakeProfit >= pos.OpenPrice {
-			return fmt.Errorf("%w: for short position, take profit must be below open price", ErrTakeProfitRange)
+		if takeProfit >= refPrice {
+			return fmt.Errorf("%w: for short position, take profit must be below %s", ErrTakeProfitRange, refName)
 		}
 	}
 
@@ -98,10 +162,142 @@ func (v *PositionValidator) ValidateClosePosition(pos *models.Position, closeQua
 	if closeQuantity <= 0 {
 		return fmt.Errorf("%w: close quantity must be positive", ErrInvalidQuantity)
 	}
-	if closeQuantity > pos.Quantity {
+	// 多次部分平仓后剩余数量带有浮点误差，差异在容差以内时允许全部平仓
+	if models.NormalizeQuantity(closeQuantity-pos.Quantity) > 0 {
 		return fmt.Errorf("%w: close quantity %.4f exceeds position quantity %.4f",
 			ErrInvalidCloseQuantity, closeQuantity, pos.Quantity)
 	}
 
 	return nil
 }
+
+// ValidateAddToPosition 验证加仓数据
+// 合并后的仓位还需通过 ValidateOpenPosition 检查止损止盈范围
+func (v *PositionValidator) ValidateAddToPosition(pos *models.Position, entry models.EntryFill) error {
+	// 验证仓位状态
+	if pos.Status == models.StatusClosed {
+		return ErrPositionAlreadyClosed
+	}
+
+	// 验证加仓价格和数量
+	if entry.Price <= 0 {
+		return fmt.Errorf("%w: entry price must be positive", ErrInvalidPrice)
+	}
+	if entry.Quantity <= 0 {
+		return fmt.Errorf("%w: entry quantity must be positive", ErrInvalidQuantity)
+	}
+	if entry.Margin < 0 {
+		return fmt.Errorf("%w: entry margin must not be negative", ErrInvalidPrice)
+	}
+
+	// 加仓时间不能早于开仓时间
+	if entry.Time.Before(pos.OpenTime) {
+		return ErrInvalidEntryTime
+	}
+
+	return nil
+}
+
+// ValidateAdjustPosition 验证止损止盈调整
+// currentPrice 大于 0 时以当前价格为参考（如移动止损到保本），否则以开仓价为参考
+func (v *PositionValidator) ValidateAdjustPosition(pos *models.Position, stopLoss, takeProfit, currentPrice float64) error {
+	// 验证仓位状态
+	if pos.Status == models.StatusClosed {
+		return ErrPositionAlreadyClosed
+	}
+
+	// 验证止损止盈必填
+	if stopLoss <= 0 {
+		return ErrInvalidStopLoss
+	}
+	if takeProfit <= 0 {
+		return ErrInvalidTakeProfit
+	}
+
+	if currentPrice > 0 {
+		return validateLevelRange(pos.Direction, stopLoss, takeProfit, currentPrice, "current price")
+	}
+	return validateLevelRange(pos.Direction, stopLoss, takeProfit, pos.OpenPrice, "open price")
+}
+
+// ValidateEditPosition 验证修改后的仓位
+// 按首次开仓成交和开仓时的止损止盈检查开仓规则；加仓、调整和平仓时间不能早于开仓时间
+func (v *PositionValidator) ValidateEditPosition(pos *models.Position) error {
+	opened := *pos
+	if len(pos.Entries) > 0 {
+		opened.OpenPrice = pos.Entries[0].Price
+		opened.Quantity = pos.Entries[0].Quantity
+	}
+	opened.StopLoss = pos.InitialStopLoss()
+	opened.TakeProfit = pos.InitialTakeProfit()
+	if err := v.ValidateOpenPosition(&opened); err != nil {
+		return err
+	}
+
+	for i, entry := range pos.Entries {
+		if i > 0 && entry.Time.Before(pos.OpenTime) {
+			return ErrInvalidOpenTime
+		}
+	}
+	for _, adj := range pos.Adjustments {
+		if adj.Time.Before(pos.OpenTime) {
+			return ErrInvalidOpenTime
+		}
+	}
+	for _, fill := range pos.Fills {
+		if fill.CloseTime.Before(pos.OpenTime) {
+			return ErrInvalidOpenTime
+		}
+	}
+
+	return nil
+}
+
+// ValidateRiskLimits 检查新仓位是否违反账户风控限制
+// 新仓位的风险取 pos.InitialRisk；返回 *RiskLimitError 列出所有违反项
+func (v *PositionValidator) ValidateRiskLimits(pos *models.Position, limits *models.RiskLimits, exposure RiskExposure) error {
+	if limits.IsEmpty() {
+		return nil
+	}
+
+	var violations []RiskLimitViolation
+	check := func(err error, actual, limit float64) {
+		if limit > 0 && actual > limit {
+			violations = append(violations, RiskLimitViolation{Err: err, Actual: actual, Limit: limit})
+		}
+	}
+
+	// 按余额比例的限制在余额不为正时无法计算，视为违反限制
+	hasPercentLimits := limits.MaxRiskPerTradePercent > 0 || limits.MaxOpenRiskPercent > 0 || limits.MaxMarginUsagePercent > 0
+	if exposure.Balance > 0 {
+		check(ErrRiskPerTradeExceeded,
+			pos.InitialRisk/exposure.Balance*100, limits.MaxRiskPerTradePercent)
+		check(ErrOpenRiskExceeded,
+			(exposure.OpenRisk+pos.InitialRisk)/exposure.Balance*100, limits.MaxOpenRiskPercent)
+		check(ErrMarginUsageExceeded,
+			(exposure.OpenMargin+pos.Margin)/exposure.Balance*100, limits.MaxMarginUsagePercent)
+	} else if hasPercentLimits {
+		violations = append(violations, RiskLimitViolation{Err: ErrNonPositiveBalance, Actual: exposure.Balance})
+	}
+
+	check(ErrSymbolPositionLimit,
+		float64(exposure.SymbolPositions+1), float64(limits.MaxPositionsPerSymbol))
+
+	// 风险回报比与合约乘数无关，按价格距离计算
+	if limits.MinRiskReward > 0 {
+		stopDistance := math.Abs(pos.OpenPrice - pos.StopLoss)
+		if stopDistance > 0 {
+			riskReward := math.Abs(pos.TakeProfit-pos.OpenPrice) / stopDistance
+			if riskReward < limits.MinRiskReward {
+				violations = append(violations, RiskLimitViolation{
+					Err: ErrRiskRewardTooLow, Actual: riskReward, Limit: limits.MinRiskReward,
+				})
+			}
+		}
+	}
+
+	if len(violations) > 0 {
+		return &RiskLimitError{Violations: violations}
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package validator
 
 import (
+	"errors"
 	"testing"
 	"time"
 	"trading-journal-cli/internal/models"
@@ -169,3 +170,214 @@ func TestValidateClosePosition_ExceedsQuantity(t *testing.T) {
 		t.Errorf("Expected error for close quantity exceeding position quantity")
 	}
 }
+
+func TestValidateAddToPosition_BeforeOpenTime(t *testing.T) {
+	validator := NewPositionValidator()
+
+	openTime := time.Now()
+	pos := &models.Position{
+		Status:   models.StatusOpen,
+		OpenTime: openTime,
+		Quantity: 1.0,
+	}
+
+	err := validator.ValidateAddToPosition(pos, models.EntryFill{
+		Time:     openTime.Add(-time.Hour),
+		Price:    100.0,
+		Quantity: 1.0,
+	})
+	if err != ErrInvalidEntryTime {
+		t.Errorf("Expected ErrInvalidEntryTime, got %v", err)
+	}
+}
+
+func TestValidateAddToPosition_AlreadyClosed(t *testing.T) {
+	validator := NewPositionValidator()
+
+	pos := &models.Position{
+		Status: models.StatusClosed,
+	}
+
+	err := validator.ValidateAddToPosition(pos, models.EntryFill{Price: 100.0, Quantity: 1.0})
+	if err != ErrPositionAlreadyClosed {
+		t.Errorf("Expected ErrPositionAlreadyClosed, got %v", err)
+	}
+}
+
+func TestValidateAdjustPosition_BreakevenWithCurrentPrice(t *testing.T) {
+	validator := NewPositionValidator()
+
+	pos := &models.Position{
+		Direction: models.DirectionLong,
+		OpenPrice: 100.0,
+		Status:    models.StatusOpen,
+	}
+
+	// 以开仓价为参考时，止损不能移动到保本位置
+	err := validator.ValidateAdjustPosition(pos, 100.0, 130.0, 0)
+	if err == nil || err.Error() != ErrStopLossRange.Error()+": for long position, stop loss must be below open price" {
+		t.Errorf("Expected stop loss range error, got %v", err)
+	}
+
+	// 以当前价格为参考时允许
+	err = validator.ValidateAdjustPosition(pos, 100.0, 130.0, 115.0)
+	if err != nil {
+		t.Errorf("Expected validation to pass, got error: %v", err)
+	}
+}
+
+func TestValidateAdjustPosition_InvalidTakeProfitRange_Short(t *testing.T) {
+	validator := NewPositionValidator()
+
+	pos := &models.Position{
+		Direction: models.DirectionShort,
+		OpenPrice: 100.0,
+		Status:    models.StatusOpen,
+	}
+
+	err := validator.ValidateAdjustPosition(pos, 95.0, 92.0, 90.0)
+	if err == nil || err.Error() != ErrTakeProfitRange.Error()+": for short position, take profit must be below current price" {
+		t.Errorf("Expected take profit range error, got %v", err)
+	}
+}
+
+func TestValidateEditPosition(t *testing.T) {
+	validator := NewPositionValidator()
+
+	openTime := time.Now()
+	closeTime := openTime.Add(time.Hour)
+	// 止损已移到开仓价之上，按开仓时的止损验证
+	pos := &models.Position{
+		Symbol:     "BTC",
+		MarketType: models.MarketTypeCrypto,
+		Direction:  models.DirectionLong,
+		OpenTime:   openTime,
+		OpenPrice:  100.0,
+		StopLoss:   105.0,
+		TakeProfit: 120.0,
+		Margin:     100.0,
+		Status:     models.StatusClosed,
+		Entries:    []models.EntryFill{{Time: openTime, Price: 100.0, Quantity: 1.0}},
+		Adjustments: []models.LevelAdjustment{{
+			Time: openTime.Add(time.Minute), OldStopLoss: 90.0, NewStopLoss: 105.0, OldTakeProfit: 120.0, NewTakeProfit: 120.0,
+		}},
+		Fills: []models.CloseFill{{CloseTime: closeTime, ClosePrice: 110.0, CloseQuantity: 1.0}},
+	}
+	if err := validator.ValidateEditPosition(pos); err != nil {
+		t.Errorf("Expected validation to pass, got error: %v", err)
+	}
+
+	// 开仓价改到开仓时的止损之下
+	wrongPrice := *pos
+	wrongPrice.Entries = []models.EntryFill{{Time: openTime, Price: 85.0, Quantity: 1.0}}
+	if err := validator.ValidateEditPosition(&wrongPrice); !errors.Is(err, ErrStopLossRange) {
+		t.Errorf("Expected ErrStopLossRange, got %v", err)
+	}
+
+	// 开仓时间改到平仓之后
+	wrongTime := *pos
+	wrongTime.OpenTime = closeTime.Add(time.Minute)
+	if err := validator.ValidateEditPosition(&wrongTime); err != ErrInvalidOpenTime {
+		t.Errorf("Expected ErrInvalidOpenTime, got %v", err)
+	}
+}
+
+func TestValidateRiskLimits(t *testing.T) {
+	validator := NewPositionValidator()
+
+	newPosition := func() *models.Position {
+		return &models.Position{
+			Symbol:      "BTC/USDT",
+			Direction:   models.DirectionLong,
+			OpenPrice:   100.0,
+			Quantity:    10.0,
+			StopLoss:    90.0,
+			TakeProfit:  130.0,
+			Margin:      1000.0,
+			InitialRisk: 100.0,
+		}
+	}
+
+	tests := []struct {
+		name     string
+		limits   *models.RiskLimits
+		exposure RiskExposure
+		expected error
+	}{
+		{"No limits", nil, RiskExposure{Balance: 1000}, nil},
+		{"Within limits", &models.RiskLimits{MaxRiskPerTradePercent: 2, MinRiskReward: 2},
+			RiskExposure{Balance: 10000}, nil},
+		{"Risk per trade", &models.RiskLimits{MaxRiskPerTradePercent: 2},
+			RiskExposure{Balance: 1000}, ErrRiskPerTradeExceeded},
+		{"Total open risk", &models.RiskLimits{MaxOpenRiskPercent: 5},
+			RiskExposure{Balance: 10000, OpenRisk: 450}, ErrOpenRiskExceeded},
+		{"Positions per symbol", &models.RiskLimits{MaxPositionsPerSymbol: 1},
+			RiskExposure{Balance: 10000, SymbolPositions: 1}, ErrSymbolPositionLimit},
+		{"Risk reward", &models.RiskLimits{MinRiskReward: 4},
+			RiskExposure{Balance: 10000}, ErrRiskRewardTooLow},
+		{"Margin usage", &models.RiskLimits{MaxMarginUsagePercent: 50},
+			RiskExposure{Balance: 10000, OpenMargin: 4500}, ErrMarginUsageExceeded},
+		{"Blown account with percentage limit", &models.RiskLimits{MaxOpenRiskPercent: 50},
+			RiskExposure{Balance: -100}, ErrNonPositiveBalance},
+		{"Zero balance without percentage limit", &models.RiskLimits{MaxPositionsPerSymbol: 2},
+			RiskExposure{Balance: 0}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.ValidateRiskLimits(newPosition(), tt.limits, tt.exposure)
+			if tt.expected == nil {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestValidateRiskLimits_MultipleViolations(t *testing.T) {
+	validator := NewPositionValidator()
+
+	pos := &models.Position{
+		Direction:   models.DirectionLong,
+		OpenPrice:   100.0,
+		StopLoss:    90.0,
+		TakeProfit:  110.0,
+		InitialRisk: 500.0,
+	}
+	limits := &models.RiskLimits{MaxRiskPerTradePercent: 1, MinRiskReward: 2}
+
+	err := validator.ValidateRiskLimits(pos, limits, RiskExposure{Balance: 10000})
+
+	var limitErr *RiskLimitError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("Expected *RiskLimitError, got %v", err)
+	}
+	if len(limitErr.Violations) != 2 {
+		t.Errorf("Expected 2 violations, got %d", len(limitErr.Violations))
+	}
+	if !errors.Is(err, ErrRiskPerTradeExceeded) || !errors.Is(err, ErrRiskRewardTooLow) {
+		t.Errorf("Expected error to match both violations, got %v", err)
+	}
+}
+
+func TestValidateClosePosition_FloatRemainder(t *testing.T) {
+	validator := NewPositionValidator()
+
+	// 0.3 部分平仓 0.1 后剩余数量为 0.19999999999999998
+	pos := &models.Position{
+		Status:   models.StatusOpen,
+		Quantity: 0.3 - 0.1,
+	}
+
+	if err := validator.ValidateClosePosition(pos, 0.2); err != nil {
+		t.Errorf("Expected closing the remaining quantity to pass, got error: %v", err)
+	}
+	if err := validator.ValidateClosePosition(pos, 0.2001); !errors.Is(err, ErrInvalidCloseQuantity) {
+		t.Errorf("Expected ErrInvalidCloseQuantity, got %v", err)
+	}
+}